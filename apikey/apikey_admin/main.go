@@ -0,0 +1,139 @@
+// Command apikey_admin manages API keys, either through the ApiKeyService of
+// a running server or directly in a key file:
+//
+//	apikey_admin [flags] create <name> <scope>...
+//	apikey_admin [flags] list
+//	apikey_admin [flags] revoke <id>
+//
+// Use -file to create the first admin key before any server runs, e.g.
+//
+//	apikey_admin -file apikeys.json create ops apikey.ApiKeyService
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grpc-go-new-course/apikey"
+	"github.com/grpc-go-new-course/apikey/apikeypb"
+	"github.com/grpc-go-new-course/rpcerror"
+	"github.com/grpc-go-new-course/tlsconfig"
+	"github.com/grpc-go-new-course/validation"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:50051", "server hosting the ApiKeyService")
+	apiKey := flag.String("api-key", os.Getenv("API_KEY"), "admin API key, defaults to $API_KEY")
+	file := flag.String("file", "", "manage this key file directly instead of calling a server")
+	includeRevoked := flag.Bool("include-revoked", false, "list revoked keys too")
+	var tlsFlags tlsconfig.ClientFlags
+	tlsFlags.Register(flag.CommandLine, "")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] create <name> <scope>... | list | revoke <id>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var client apikeypb.ApiKeyServiceClient
+	if *file != "" {
+		store, err := apikey.NewFileStore(*file)
+		if err != nil {
+			log.Fatalf("unable to open key file : %v", err)
+		}
+		client = localClient{apikey.NewService(store), apikey.Rules(validation.New())}
+	} else {
+		creds, err := tlsFlags.DialOption()
+		if err != nil {
+			log.Fatalf("error loading client certificates : %v", err)
+		}
+		conn, err := grpc.Dial(*addr, creds, grpc.WithPerRPCCredentials(apikey.Credentials(*apiKey)))
+		if err != nil {
+			log.Fatalf("unable to establish channel connection : %v", err)
+		}
+		defer conn.Close()
+		client = apikeypb.NewApiKeyServiceClient(conn)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	args := flag.Args()
+	switch {
+	case args[0] == "create" && len(args) >= 3:
+		res, err := client.CreateKey(ctx, &apikeypb.CreateKeyRequest{Name: args[1], Scopes: args[2:]})
+		if err != nil {
+			log.Fatalf("rpc CreateKey failed : %v", rpcerror.Describe(err))
+		}
+		printKey(res.GetApiKey())
+		fmt.Printf("secret: %s\n", res.GetSecret())
+		fmt.Println("Store the secret now, it cannot be shown again.")
+
+	case args[0] == "list" && len(args) == 1:
+		res, err := client.ListKeys(ctx, &apikeypb.ListKeysRequest{IncludeRevoked: *includeRevoked})
+		if err != nil {
+			log.Fatalf("rpc ListKeys failed : %v", rpcerror.Describe(err))
+		}
+		for _, key := range res.GetApiKeys() {
+			printKey(key)
+		}
+
+	case args[0] == "revoke" && len(args) == 2:
+		res, err := client.RevokeKey(ctx, &apikeypb.RevokeKeyRequest{Id: args[1]})
+		if err != nil {
+			log.Fatalf("rpc RevokeKey failed : %v", rpcerror.Describe(err))
+		}
+		printKey(res.GetApiKey())
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func printKey(key *apikeypb.ApiKey) {
+	fmt.Printf("%s  %-20s  created %s", key.GetId(), key.GetName(), formatTime(key.GetCreatedAt()))
+	if key.GetRevokedAt() != nil {
+		fmt.Printf("  revoked %s", formatTime(key.GetRevokedAt()))
+	}
+	fmt.Printf("  scopes %s\n", strings.Join(key.GetScopes(), ","))
+}
+
+func formatTime(ts *timestamppb.Timestamp) string {
+	return ts.AsTime().Local().Format(time.RFC3339)
+}
+
+// localClient calls a Service in process, for -file.
+type localClient struct {
+	service   *apikey.Service
+	validator *validation.Validator
+}
+
+func (c localClient) CreateKey(ctx context.Context, req *apikeypb.CreateKeyRequest, _ ...grpc.CallOption) (*apikeypb.CreateKeyResponse, error) {
+	if err := c.validator.Validate(req); err != nil {
+		return nil, err
+	}
+	return c.service.CreateKey(ctx, req)
+}
+
+func (c localClient) ListKeys(ctx context.Context, req *apikeypb.ListKeysRequest, _ ...grpc.CallOption) (*apikeypb.ListKeysResponse, error) {
+	return c.service.ListKeys(ctx, req)
+}
+
+func (c localClient) RevokeKey(ctx context.Context, req *apikeypb.RevokeKeyRequest, _ ...grpc.CallOption) (*apikeypb.RevokeKeyResponse, error) {
+	if err := c.validator.Validate(req); err != nil {
+		return nil, err
+	}
+	return c.service.RevokeKey(ctx, req)
+}
@@ -0,0 +1,114 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/grpc-go-new-course/rpcerror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying key as the API key of the call.
+func NewContext(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// FromContext returns the API key a call was authenticated with.
+func FromContext(ctx context.Context) (*Key, bool) {
+	key, ok := ctx.Value(contextKey{}).(*Key)
+	return key, ok
+}
+
+// Authenticator rejects calls without a valid, unrevoked API key whose
+// scopes cover the called method.
+type Authenticator struct {
+	store Store
+}
+
+// NewAuthenticator checks API keys against store.
+func NewAuthenticator(store Store) *Authenticator {
+	return &Authenticator{store: store}
+}
+
+// Authenticate returns ctx carrying the key of the call, or a status error
+// when the call may not proceed.
+func (a *Authenticator) Authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(MetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		return nil, rpcerror.New(codes.Unauthenticated, "missing API key",
+			rpcerror.ErrorInfo("API_KEY_MISSING", "metadata", MetadataKey),
+		)
+	}
+
+	key, err := a.store.Lookup(ctx, Hash(values[0]))
+	if err == ErrNotFound || (err == nil && key.Revoked()) {
+		return nil, rpcerror.New(codes.Unauthenticated, "invalid API key",
+			rpcerror.ErrorInfo("API_KEY_INVALID", "metadata", MetadataKey),
+		)
+	}
+	if err != nil {
+		return nil, rpcerror.New(codes.Unavailable, "unable to verify API key",
+			rpcerror.ErrorInfo("API_KEY_STORE_UNAVAILABLE"),
+		)
+	}
+
+	if !key.Allows(fullMethod) {
+		return nil, rpcerror.New(codes.PermissionDenied, "API key "+key.ID+" may not call "+fullMethod,
+			rpcerror.ErrorInfo("API_KEY_SCOPE", "key_id", key.ID, "method", fullMethod),
+		)
+	}
+	return NewContext(ctx, key), nil
+}
+
+// UnaryServerInterceptor authenticates unary calls.
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.Authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authenticates streams before their first message.
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.Authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// Credentials returns per-RPC credentials sending secret as the API key of
+// every call. They are also sent over plaintext connections, which should
+// only be used for local development.
+func Credentials(secret string) credentials.PerRPCCredentials {
+	return keyCredentials(secret)
+}
+
+type keyCredentials string
+
+func (k keyCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{MetadataKey: string(k)}, nil
+}
+
+func (k keyCredentials) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,113 @@
+package apikey
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/grpc-go-new-course/rpcerror"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAuthenticate(t *testing.T) {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "apikeys.json"))
+	if err != nil {
+		t.Fatalf("NewFileStore : %v", err)
+	}
+	ctx := context.Background()
+	create := func(name string, scopes ...string) (*Key, string) {
+		key, secret, err := New(name, scopes)
+		if err != nil {
+			t.Fatalf("New : %v", err)
+		}
+		if err := store.Create(ctx, key); err != nil {
+			t.Fatalf("Create : %v", err)
+		}
+		return key, secret
+	}
+	greeter, greeterSecret := create("greeter", "greet.GreetService")
+	revoked, revokedSecret := create("revoked", "*")
+	if _, err := store.Revoke(ctx, revoked.ID, time.Now()); err != nil {
+		t.Fatalf("Revoke : %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		method string
+		code   codes.Code
+		reason string
+	}{
+		{name: "valid", secret: greeterSecret, method: "/greet.GreetService/Greet", code: codes.OK},
+		{name: "missing", method: "/greet.GreetService/Greet", code: codes.Unauthenticated, reason: "API_KEY_MISSING"},
+		{name: "unknown", secret: "gak_unknown", method: "/greet.GreetService/Greet", code: codes.Unauthenticated, reason: "API_KEY_INVALID"},
+		{name: "revoked", secret: revokedSecret, method: "/greet.GreetService/Greet", code: codes.Unauthenticated, reason: "API_KEY_INVALID"},
+		{name: "out of scope", secret: greeterSecret, method: "/blog.BlogService/CreateBlog", code: codes.PermissionDenied, reason: "API_KEY_SCOPE"},
+	}
+	auth := NewAuthenticator(store)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCtx := ctx
+			if tt.secret != "" {
+				callCtx = metadata.NewIncomingContext(ctx, metadata.Pairs(MetadataKey, tt.secret))
+			}
+			authCtx, err := auth.Authenticate(callCtx, tt.method)
+			if !grpctest.AssertCode(t, err, tt.code) {
+				return
+			}
+			if err != nil {
+				if reason := rpcerror.FromError(err).ErrorInfo.GetReason(); reason != tt.reason {
+					t.Errorf("got reason %q, want %q", reason, tt.reason)
+				}
+				return
+			}
+			if key, ok := FromContext(authCtx); !ok || key.ID != greeter.ID {
+				t.Errorf("got key %v in the context, want %s", key, greeter.ID)
+			}
+		})
+	}
+}
+
+func TestKeyAllows(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		method string
+		want   bool
+	}{
+		{scopes: []string{"*"}, method: "/blog.BlogService/CreateBlog", want: true},
+		{scopes: []string{"blog.BlogService"}, method: "/blog.BlogService/CreateBlog", want: true},
+		{scopes: []string{"/blog.BlogService/ReadBlog"}, method: "/blog.BlogService/ReadBlog", want: true},
+		{scopes: []string{"/blog.BlogService/ReadBlog"}, method: "/blog.BlogService/CreateBlog"},
+		{scopes: []string{"blog.BlogService"}, method: "/greet.GreetService/Greet"},
+		{scopes: nil, method: "/greet.GreetService/Greet"},
+	}
+	for _, tt := range tests {
+		if got := (&Key{Scopes: tt.scopes}).Allows(tt.method); got != tt.want {
+			t.Errorf("scopes %v allow %s = %v, want %v", tt.scopes, tt.method, got, tt.want)
+		}
+	}
+}
+
+func TestValidScope(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{"*", true},
+		{"greet.GreetService", true},
+		{"/greet.GreetService/Greet", true},
+		{"GreetService", false},
+		{"greet..GreetService", false},
+		{"/greet.GreetService/", false},
+		{"/greet.GreetService/Greet/extra", false},
+		{"greet.*", false},
+	}
+	for _, tt := range tests {
+		if got := ValidScope(tt.scope); got != tt.want {
+			t.Errorf("ValidScope(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
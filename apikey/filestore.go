@@ -0,0 +1,175 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileStore keeps API keys in a JSON file, rewritten on every change. The
+// file is read again when another process, such as apikey_admin -file,
+// changes it. It suits single instance deployments and local development.
+type FileStore struct {
+	path string
+
+	mu      sync.Mutex
+	keys    map[string]*Key
+	modTime time.Time
+	size    int64
+}
+
+// NewFileStore loads the keys saved in path. A missing file is created on
+// the first change.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, keys: make(map[string]*Key)}
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// refresh reads the file again if it changed since it was last read or
+// written.
+func (s *FileStore) refresh() error {
+	info, err := os.Stat(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading API keys : %v", err)
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("reading API keys : %v", err)
+	}
+	var keys []*Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("parsing API keys %q : %v", s.path, err)
+	}
+	s.keys = make(map[string]*Key, len(keys))
+	for _, key := range keys {
+		s.keys[key.ID] = key
+	}
+	s.modTime, s.size = info.ModTime(), info.Size()
+	return nil
+}
+
+func (s *FileStore) Create(ctx context.Context, key *Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	if _, ok := s.keys[key.ID]; ok {
+		return fmt.Errorf("api key %q already exists", key.ID)
+	}
+	s.keys[key.ID] = key
+	if err := s.save(); err != nil {
+		delete(s.keys, key.ID)
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) List(ctx context.Context) ([]*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s.sorted(), nil
+}
+
+func (s *FileStore) Lookup(ctx context.Context, hash string) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	for _, key := range s.keys {
+		if key.Hash == hash {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *FileStore) Revoke(ctx context.Context, id string, at time.Time) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !key.Revoked() {
+		key.RevokedAt = &at
+		if err := s.save(); err != nil {
+			key.RevokedAt = nil
+			return nil, err
+		}
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (s *FileStore) sorted() []*Key {
+	keys := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		copied := *key
+		keys = append(keys, &copied)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys
+}
+
+// save writes the keys to a temporary file renamed over path, so readers
+// never see a partial file.
+func (s *FileStore) save() error {
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), ".apikeys-*")
+	if err != nil {
+		return fmt.Errorf("saving API keys : %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving API keys : %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving API keys : %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("saving API keys : %v", err)
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime, s.size = info.ModTime(), info.Size()
+	}
+	return nil
+}
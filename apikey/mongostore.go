@@ -0,0 +1,73 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps API keys in a MongoDB collection, letting several server
+// instances share them.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore uses collection, making sure key hashes are indexed.
+func NewMongoStore(ctx context.Context, collection *mongo.Collection) (*MongoStore, error) {
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MongoStore{collection: collection}, nil
+}
+
+func (s *MongoStore) Create(ctx context.Context, key *Key) error {
+	_, err := s.collection.InsertOne(ctx, key)
+	return err
+}
+
+func (s *MongoStore) List(ctx context.Context) ([]*Key, error) {
+	cursor, err := s.collection.Find(ctx, bson.D{},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var keys []*Key
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *MongoStore) Lookup(ctx context.Context, hash string) (*Key, error) {
+	return s.findOne(ctx, bson.M{"hash": hash})
+}
+
+func (s *MongoStore) Revoke(ctx context.Context, id string, at time.Time) (*Key, error) {
+	_, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": at}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s.findOne(ctx, bson.M{"_id": id})
+}
+
+func (s *MongoStore) findOne(ctx context.Context, filter bson.M) (*Key, error) {
+	key := &Key{}
+	err := s.collection.FindOne(ctx, filter).Decode(key)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,104 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grpc-go-new-course/apikey/apikeypb"
+	"github.com/grpc-go-new-course/rpcerror"
+	"github.com/grpc-go-new-course/validation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AdminService is the scope a key needs to manage other keys.
+const AdminService = "apikey.ApiKeyService"
+
+// Service implements ApiKeyService on top of a Store.
+type Service struct {
+	apikeypb.UnimplementedApiKeyServiceServer
+	store Store
+}
+
+// NewService manages the keys of store.
+func NewService(store Store) *Service {
+	return &Service{store: store}
+}
+
+// Rules adds the request rules of ApiKeyService to v.
+func Rules(v *validation.Validator) *validation.Validator {
+	return v.
+		Add(&apikeypb.CreateKeyRequest{},
+			validation.Required("name"),
+			validation.Length("name", 1, 100),
+			validation.Required("scopes"),
+		).
+		Add(&apikeypb.RevokeKeyRequest{},
+			validation.Required("id"),
+			validation.Pattern("id", "^[0-9a-f]{16}$"),
+		)
+}
+
+func (s *Service) CreateKey(ctx context.Context, req *apikeypb.CreateKeyRequest) (*apikeypb.CreateKeyResponse, error) {
+	for i, scope := range req.GetScopes() {
+		if !ValidScope(scope) {
+			return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid scope %q", scope),
+				rpcerror.ErrorInfo("INVALID_REQUEST", "message", "CreateKeyRequest"),
+				rpcerror.BadRequest(fmt.Sprintf("scopes[%d]", i), `must be "*", a service like "greet.GreetService" or a method like "/greet.GreetService/Greet"`),
+			)
+		}
+	}
+
+	key, secret, err := New(req.GetName(), req.GetScopes())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to create API key : %v", err)
+	}
+	if err := s.store.Create(ctx, key); err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to save API key : %v", err)
+	}
+	return &apikeypb.CreateKeyResponse{ApiKey: toProto(key), Secret: secret}, nil
+}
+
+func (s *Service) ListKeys(ctx context.Context, req *apikeypb.ListKeysRequest) (*apikeypb.ListKeysResponse, error) {
+	keys, err := s.store.List(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to list API keys : %v", err)
+	}
+	res := &apikeypb.ListKeysResponse{}
+	for _, key := range keys {
+		if key.Revoked() && !req.GetIncludeRevoked() {
+			continue
+		}
+		res.ApiKeys = append(res.ApiKeys, toProto(key))
+	}
+	return res, nil
+}
+
+func (s *Service) RevokeKey(ctx context.Context, req *apikeypb.RevokeKeyRequest) (*apikeypb.RevokeKeyResponse, error) {
+	key, err := s.store.Revoke(ctx, req.GetId(), time.Now().UTC())
+	if err == ErrNotFound {
+		return nil, rpcerror.New(codes.NotFound, fmt.Sprintf("No result Found : API key %q", req.GetId()),
+			rpcerror.ErrorInfo("API_KEY_NOT_FOUND", "key_id", req.GetId()),
+			rpcerror.ResourceInfo("api_key", req.GetId(), "API key does not exist"),
+		)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to revoke API key : %v", err)
+	}
+	return &apikeypb.RevokeKeyResponse{ApiKey: toProto(key)}, nil
+}
+
+func toProto(key *Key) *apikeypb.ApiKey {
+	pb := &apikeypb.ApiKey{
+		Id:        key.ID,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+		CreatedAt: timestamppb.New(key.CreatedAt),
+	}
+	if key.RevokedAt != nil {
+		pb.RevokedAt = timestamppb.New(*key.RevokedAt)
+	}
+	return pb
+}
@@ -2,19 +2,61 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"os"
 
+	"github.com/grpc-go-new-course/apikey"
 	"github.com/grpc-go-new-course/blog/blogpb"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/rpcerror"
+	"github.com/grpc-go-new-course/tlsconfig"
+	"github.com/grpc-go-new-course/tracing"
 	"google.golang.org/grpc"
 )
 
 var ctx context.Context = context.Background()
 
 func main() {
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP gRPC collector receiving traces, e.g. localhost:4317")
+	traceFile := flag.String("trace-file", "", "file receiving traces as JSON lines when no OTLP endpoint is set")
+	addr := flag.String("addr", "localhost:50051", `server to dial, e.g. "unix:///run/blog/blog.sock" or "unix-abstract:blog"`)
+	apiKey := flag.String("api-key", os.Getenv("API_KEY"), "API key sent with every call, defaults to $API_KEY")
+	var tlsFlags tlsconfig.ClientFlags
+	tlsFlags.Register(flag.CommandLine, "")
+	flag.Parse()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName:  "blog_client",
+		OTLPEndpoint: *otlpEndpoint,
+		File:         *traceFile,
+	})
+	if err != nil {
+		log.Fatalf("unable to set up tracing : %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
-	conn, err := grpc.Dial("localhost:50051", grpc.WithInsecure())
+	creds, err := tlsFlags.DialOption()
+	if err != nil {
+		log.Fatalf("error loading client certificates : %v", err)
+	}
+	dialOpts := []grpc.DialOption{
+		creds,
+		grpc.WithChainUnaryInterceptor(
+			tracing.UnaryClientInterceptor(),
+			logging.UnaryClientInterceptor(),
+		),
+		grpc.WithChainStreamInterceptor(
+			tracing.StreamClientInterceptor(),
+			logging.StreamClientInterceptor(),
+		),
+	}
+	if *apiKey != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(apikey.Credentials(*apiKey)))
+	}
+	conn, err := grpc.Dial(*addr, dialOpts...)
 
 	if err != nil {
 		log.Fatalf("unable to connect : %v", err)
@@ -47,7 +89,7 @@ func createblog(client blogpb.BlogServiceClient) string {
 	resp, err := client.CreateBlog(ctx, req)
 
 	if err != nil {
-		log.Fatalf("error calling rpc CreateBlog : %v", err)
+		log.Fatalf("error calling rpc CreateBlog : %v", rpcerror.Describe(err))
 
 	}
 	log.Printf("Response : Blog Created : %v \n ", resp.GetBlog())
@@ -63,7 +105,7 @@ func readblog(client blogpb.BlogServiceClient, blogID string) {
 	resp, err := client.ReadBlog(ctx, req)
 
 	if err != nil {
-		log.Fatalf("error calling rpc ReadBlog : %v", err)
+		log.Fatalf("error calling rpc ReadBlog : %v", rpcerror.Describe(err))
 
 	}
 
@@ -86,7 +128,7 @@ func updateblog(client blogpb.BlogServiceClient, blogID string) {
 	resp, err := client.UpdateBlog(ctx, req)
 
 	if err != nil {
-		log.Fatalf("error calling rpc UpdateBlog : %v", err)
+		log.Fatalf("error calling rpc UpdateBlog : %v", rpcerror.Describe(err))
 	}
 
 	log.Printf("Update Response : %v \n", resp.GetBlog())
@@ -100,7 +142,7 @@ func deleteblog(client blogpb.BlogServiceClient, blogID string) {
 	resp, err := client.DeleteBlog(ctx, req)
 
 	if err != nil {
-		log.Fatalf("error calling rpc DeleteBlog : %v", err)
+		log.Fatalf("error calling rpc DeleteBlog : %v", rpcerror.Describe(err))
 	}
 
 	log.Printf("Deleted Blog Respnse : %v \n", resp)
@@ -114,7 +156,7 @@ func listblog(client blogpb.BlogServiceClient) {
 	stream, err := client.ListBlog(ctx, req)
 
 	if err != nil {
-		log.Fatalf("error calling ListBlog rpc : %v", err)
+		log.Fatalf("error calling ListBlog rpc : %v", rpcerror.Describe(err))
 	}
 
 	for {
@@ -125,7 +167,7 @@ func listblog(client blogpb.BlogServiceClient) {
 			break
 		}
 		if err != nil {
-			log.Fatalf("error receiving server stream : %v", err)
+			log.Fatalf("error receiving server stream : %v", rpcerror.Describe(err))
 		}
 		log.Printf("List Blog : Response : %v \n", res.GetBlog())
 
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryStore is a blogStore keeping blogs in memory, in insertion order.
+type memoryStore struct {
+	mu    sync.Mutex
+	blogs []blogItem
+}
+
+func (s *memoryStore) Insert(ctx context.Context, item blogItem) (primitive.ObjectID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item.ID = primitive.NewObjectID()
+	s.blogs = append(s.blogs, item)
+	return item.ID, nil
+}
+
+func (s *memoryStore) Find(ctx context.Context, id primitive.ObjectID) (blogItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.index(id); i >= 0 {
+		return s.blogs[i], nil
+	}
+	return blogItem{}, errBlogNotFound
+}
+
+func (s *memoryStore) Replace(ctx context.Context, item blogItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(item.ID)
+	if i < 0 {
+		return errBlogNotFound
+	}
+	s.blogs[i] = item
+	return nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return errBlogNotFound
+	}
+	s.blogs = append(s.blogs[:i], s.blogs[i+1:]...)
+	return nil
+}
+
+func (s *memoryStore) List(ctx context.Context, fn func(blogItem) error) error {
+	s.mu.Lock()
+	blogs := append([]blogItem(nil), s.blogs...)
+	s.mu.Unlock()
+
+	for _, item := range blogs {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) index(id primitive.ObjectID) int {
+	for i, item := range s.blogs {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -2,17 +2,28 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
-	"net"
 	"os"
 	"os/signal"
+	"time"
 
+	"github.com/grpc-go-new-course/apikey"
+	"github.com/grpc-go-new-course/apikey/apikeypb"
 	"github.com/grpc-go-new-course/blog/blogpb"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/grpc-go-new-course/interceptors"
+	"github.com/grpc-go-new-course/listener"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/metrics"
+	"github.com/grpc-go-new-course/ratelimit"
+	"github.com/grpc-go-new-course/rpcerror"
+	"github.com/grpc-go-new-course/tlsconfig"
+	"github.com/grpc-go-new-course/tracing"
+	"github.com/grpc-go-new-course/validation"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
@@ -22,15 +33,14 @@ import (
 const (
 	BLOGDATABASE   = "mydb"
 	BLOGCOLLECTION = "blog"
-)
 
-var (
-	ctx        context.Context
-	collection *mongo.Collection
+	APIKEYCOLLECTION = "apikeys"
 )
 
 type server struct {
 	blogpb.UnimplementedBlogServiceServer
+	store  blogStore
+	logger *zap.Logger
 }
 
 type blogItem struct {
@@ -40,8 +50,32 @@ type blogItem struct {
 	Title    string             `bson:"title"`
 }
 
-func (*server) CreateBlog(ctx context.Context, req *blogpb.CreateBlogRequest) (*blogpb.CreateBlogResponse, error) {
-	fmt.Println("Creating Blog ")
+func (item blogItem) toProto() *blogpb.Blog {
+	return &blogpb.Blog{
+		Id:       item.ID.Hex(),
+		AuthorId: item.AuthorID,
+		Title:    item.Title,
+		Content:  item.Content,
+	}
+}
+
+// blogNotFound reports that no blog exists with blogID.
+func blogNotFound(blogID string) error {
+	return rpcerror.New(codes.NotFound, fmt.Sprintf("No result Found : blog %q", blogID),
+		rpcerror.ErrorInfo("BLOG_NOT_FOUND", "blog_id", blogID),
+		rpcerror.ResourceInfo("blog", blogID, "blog does not exist or has been deleted"),
+	)
+}
+
+// invalidBlogID reports a blog ID that is not a valid ObjectID.
+func invalidBlogID(field, blogID string, err error) error {
+	return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("Cannot Parse ID : %q, err : %v", blogID, err),
+		rpcerror.BadRequest(field, "must be a 24 character hex ObjectID"),
+	)
+}
+
+func (s *server) CreateBlog(ctx context.Context, req *blogpb.CreateBlogRequest) (*blogpb.CreateBlogResponse, error) {
+	logging.ForRequest(ctx, s.logger).Info("Creating Blog")
 
 	blog := req.GetBlog()
 
@@ -51,199 +85,240 @@ func (*server) CreateBlog(ctx context.Context, req *blogpb.CreateBlogRequest) (*
 		Title:    blog.GetTitle(),
 	}
 
-	result, err := collection.InsertOne(ctx, blogData)
-
+	pObjectID, err := s.store.Insert(ctx, blogData)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, fmt.Sprintf("Internal error : %v", err))
+		return nil, err
 	}
+	blogData.ID = pObjectID
 
-	pObjectID, ok := result.InsertedID.(primitive.ObjectID)
-
-	if !ok {
-		return nil, status.Errorf(codes.Internal, fmt.Sprintf("could not convert to ObjectID : %v", err))
-	}
-
-	return &blogpb.CreateBlogResponse{
-		Blog: &blogpb.Blog{
-			Id:       pObjectID.Hex(),
-			AuthorId: blog.GetAuthorId(),
-			Content:  blog.GetContent(),
-			Title:    blog.GetTitle()},
-	}, nil
+	return &blogpb.CreateBlogResponse{Blog: blogData.toProto()}, nil
 
 }
 
-func (*server) ReadBlog(ctx context.Context, req *blogpb.ReadBlogRequest) (*blogpb.ReadBlogResponse, error) {
+func (s *server) ReadBlog(ctx context.Context, req *blogpb.ReadBlogRequest) (*blogpb.ReadBlogResponse, error) {
 
-	fmt.Println("Reading blog ")
+	logging.ForRequest(ctx, s.logger).Info("Reading blog")
 
 	blogID := req.GetBlogId()
 
 	pObjectID, err := primitive.ObjectIDFromHex(blogID)
 
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, fmt.Sprintf("Cannot Parse ID : %q, err : %v", blogID, err))
+		return nil, invalidBlogID("blog_id", blogID, err)
 
 	}
 
-	filter := bson.M{"_id": pObjectID}
-	singleResult := collection.FindOne(ctx, filter)
-
-	var blog blogItem
-	if err := singleResult.Decode(&blog); err != nil {
-		return nil, status.Errorf(codes.NotFound, fmt.Sprintf("No result Found : %v", err))
+	blog, err := s.store.Find(ctx, pObjectID)
+	if err == errBlogNotFound {
+		return nil, blogNotFound(blogID)
+	} else if err != nil {
+		return nil, err
 	}
 
-	return &blogpb.ReadBlogResponse{Blog: &blogpb.Blog{
-		Id:       blog.ID.Hex(),
-		AuthorId: blog.AuthorID,
-		Title:    blog.Title,
-		Content:  blog.Content,
-	}}, nil
+	return &blogpb.ReadBlogResponse{Blog: blog.toProto()}, nil
 
 }
 
-func (*server) UpdateBlog(ctx context.Context, req *blogpb.UpdateBlogRequest) (*blogpb.UpdateBlogResponse, error) {
+func (s *server) UpdateBlog(ctx context.Context, req *blogpb.UpdateBlogRequest) (*blogpb.UpdateBlogResponse, error) {
 
-	fmt.Println("Updating Blog")
+	logging.ForRequest(ctx, s.logger).Info("Updating Blog")
 
 	blog := req.GetBlog()
 	blogID := blog.GetId()
 
 	pObjectID, err := primitive.ObjectIDFromHex(blogID)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "unable to Parse ID : %q Err : %v", blogID, err)
+		return nil, invalidBlogID("blog.id", blogID, err)
 	}
 
-	filter := bson.M{"_id": pObjectID}
-	singleResult := collection.FindOne(ctx, filter)
-
-	var data blogItem
-
-	if err := singleResult.Decode(&data); err != nil {
-		return nil, status.Errorf(codes.NotFound, "No document found : %v", err)
+	data, err := s.store.Find(ctx, pObjectID)
+	if err == errBlogNotFound {
+		return nil, blogNotFound(blogID)
+	} else if err != nil {
+		return nil, err
 	}
 
 	data.AuthorID = blog.AuthorId
 	data.Content = blog.Content
 	data.Title = blog.Title
 
-	updateResult, err := collection.ReplaceOne(ctx, filter, data)
-
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "cannot update mongo document : %v", err)
-
+	if err := s.store.Replace(ctx, data); err == errBlogNotFound {
+		return nil, blogNotFound(blogID)
+	} else if err != nil {
+		return nil, err
 	}
 
-	if updateResult.ModifiedCount != 1 {
-
-		return nil, status.Errorf(codes.Internal, "failed to update One document : %v", err)
-	}
-	return &blogpb.UpdateBlogResponse{
-		Blog: &blogpb.Blog{
-			Id:       data.ID.Hex(),
-			AuthorId: data.AuthorID,
-			Title:    data.Title,
-			Content:  data.Content,
-		},
-	}, nil
+	return &blogpb.UpdateBlogResponse{Blog: data.toProto()}, nil
 
 }
 
-func (*server) DeleteBlog(ctx context.Context, req *blogpb.DeleteBlogRequest) (*blogpb.DeleteBlogResponse, error) {
-	fmt.Println("Deleting Blog")
+func (s *server) DeleteBlog(ctx context.Context, req *blogpb.DeleteBlogRequest) (*blogpb.DeleteBlogResponse, error) {
+	logging.ForRequest(ctx, s.logger).Info("Deleting Blog")
 	blogID := req.GetBlogId()
 
 	pObjectID, err := primitive.ObjectIDFromHex(blogID)
 
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "cannot parse id : %q err : %v", blogID, err)
+		return nil, invalidBlogID("blog_id", blogID, err)
 	}
 
-	filter := bson.M{"_id": pObjectID}
-	deletedResult, err := collection.DeleteOne(ctx, filter)
-
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "could not deleted document : %v", err)
-	}
-
-	if deletedResult.DeletedCount == 0 {
-		return nil, status.Errorf(codes.NotFound, "blogs not found to be deleted  %v", err)
+	if err := s.store.Delete(ctx, pObjectID); err == errBlogNotFound {
+		return nil, blogNotFound(blogID)
+	} else if err != nil {
+		return nil, err
 	}
 
 	return &blogpb.DeleteBlogResponse{BlogId: req.GetBlogId()}, nil
 
 }
 
-func (*server) ListBlog(req *blogpb.ListBlogRequest, stream blogpb.BlogService_ListBlogServer) error {
-	fmt.Println("Listing Blogs")
-
-	filter := bson.M{}
-	cursor, err := collection.Find(ctx, filter)
+func (s *server) ListBlog(req *blogpb.ListBlogRequest, stream blogpb.BlogService_ListBlogServer) error {
+	ctx := stream.Context()
+	logger := logging.ForRequest(ctx, s.logger)
+	logger.Info("Listing Blogs")
 
-	if err != nil {
-		return status.Errorf(codes.Internal, "internal error : %v", err)
-	}
-
-	defer cursor.Close(ctx)
-
-	for cursor.Next(ctx) {
-		var data blogItem
-
-		if err := cursor.Decode(&data); err != nil {
-			return status.Errorf(codes.Internal, "Error while decoding Mongo document : %v", err)
+	return s.store.List(ctx, func(data blogItem) error {
+		if err := stream.Send(&blogpb.ListBlogResponse{Blog: data.toProto()}); err != nil {
+			logger.Warn("error sending blog to client", zap.Error(err))
+			return status.Errorf(status.Code(err), "error sending blog to client : %v", err)
 		}
-		stream.Send(&blogpb.ListBlogResponse{
-			Blog: &blogpb.Blog{
-				Id:       data.ID.Hex(),
-				AuthorId: data.AuthorID,
-				Title:    data.Title,
-				Content:  data.Content,
-			},
-		})
+		return nil
+	})
 
-	}
-	if err := cursor.Err(); err != nil {
-		return status.Errorf(codes.Internal, "Cursor error : %v", err)
+}
 
+// objectIDPattern matches the hex form of a Mongo ObjectID.
+const objectIDPattern = "^[0-9a-fA-F]{24}$"
+
+// requestRules bounds the blog fields clients write and requires well-formed
+// ObjectIDs wherever a blog is addressed by id.
+func requestRules() *validation.Validator {
+	blogFields := []validation.Rule{
+		validation.Required("blog"),
+		validation.Required("blog.author_id"),
+		validation.Length("blog.author_id", 1, 100),
+		validation.Required("blog.title"),
+		validation.Length("blog.title", 1, 200),
+		validation.Length("blog.content", 1, 100000),
 	}
-	return nil
-
+	return validation.New().
+		Add(&blogpb.CreateBlogRequest{}, blogFields...).
+		Add(&blogpb.UpdateBlogRequest{}, append(blogFields,
+			validation.Required("blog.id"),
+			validation.Pattern("blog.id", objectIDPattern),
+		)...).
+		Add(&blogpb.ReadBlogRequest{},
+			validation.Required("blog_id"),
+			validation.Pattern("blog_id", objectIDPattern),
+		).
+		Add(&blogpb.DeleteBlogRequest{},
+			validation.Required("blog_id"),
+			validation.Pattern("blog_id", objectIDPattern),
+		)
 }
 
 func main() {
 
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	metricsAddr := flag.String("metrics-addr", "0.0.0.0:9093", "address serving Prometheus metrics on /metrics")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP gRPC collector receiving traces, e.g. localhost:4317")
+	traceFile := flag.String("trace-file", "", "file receiving traces as JSON lines when no OTLP endpoint is set")
+	apiKeys := flag.String("api-keys", "", `API keys clients must present: "mongo" for the blog database, or a JSON file, empty disables API key authentication`)
+	var listenFlags listener.Flags
+	listenFlags.Register(flag.CommandLine, "0.0.0.0:50051")
+	var tlsFlags tlsconfig.ServerFlags
+	tlsFlags.Register(flag.CommandLine, "", "")
+	flag.Parse()
+
+	logger, err := logging.New("blog_server")
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName:  "blog_server",
+		OTLPEndpoint: *otlpEndpoint,
+		File:         *traceFile,
+	})
+	if err != nil {
+		logger.Fatal("unable to set up tracing", zap.Error(err))
+	}
 
-	lis, err := net.Listen("tcp", "0.0.0.0:50051")
+	listeners, err := listenFlags.Listen()
 
 	if err != nil {
-		log.Fatalf("unable to create tcp Listener : %v", err)
+		logger.Fatal("unable to create tcp Listener", zap.Error(err))
 
 	}
 
 	//Creating DB
 	client, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
 	if err != nil {
-		log.Fatalf("unable to create mongodb client : %v", err)
+		logger.Fatal("unable to create mongodb client", zap.Error(err))
 	}
-	err = client.Connect(ctx)
+	err = client.Connect(context.Background())
 	if err != nil {
-		log.Fatalf("client unable to connect : %v", err)
+		logger.Fatal("client unable to connect", zap.Error(err))
 	}
 
-	collection = client.Database(BLOGDATABASE).Collection(BLOGCOLLECTION)
+	blogs := client.Database(BLOGDATABASE).Collection(BLOGCOLLECTION)
+
+	validator := apikey.Rules(requestRules())
+	limiter := ratelimit.New(ratelimit.Config{
+		Default: ratelimit.Limit{PerSecond: 20, Burst: 40},
+		Methods: map[string]ratelimit.Limit{
+			"/blog.BlogService/CreateBlog": {PerSecond: 2, Burst: 10},
+		},
+	})
 
-	grpcServer := grpc.NewServer()
+	serverMetrics := metrics.New()
+	go func() {
+		logger.Fatal("metrics endpoint stopped", zap.Error(serverMetrics.Serve(*metricsAddr)))
+	}()
 
-	blogpb.RegisterBlogServiceServer(grpcServer, &server{})
+	creds, err := tlsFlags.ServerOption(context.Background(), tlsconfig.ReloaderOptions{
+		Logger:   logger,
+		OnReload: serverMetrics.ObserveCertReload,
+	})
+	if err != nil {
+		logger.Fatal("error loading certificates", zap.Error(err))
+	}
+
+	var keyStore apikey.Store
+	switch *apiKeys {
+	case "":
+	case "mongo":
+		keyStore, err = apikey.NewMongoStore(context.Background(), client.Database(BLOGDATABASE).Collection(APIKEYCOLLECTION))
+	default:
+		keyStore, err = apikey.NewFileStore(*apiKeys)
+	}
+	if err != nil {
+		logger.Fatal("unable to load API keys", zap.Error(err))
+	}
+
+	serverOpts := interceptors.ServerOptions(interceptors.Config{
+		Logger:    logger,
+		Metrics:   serverMetrics,
+		KeyStore:  keyStore,
+		Limiter:   limiter,
+		Validator: validator,
+	})
+	grpcServer := grpc.NewServer(append(serverOpts, creds)...)
+
+	blogpb.RegisterBlogServiceServer(grpcServer, &server{
+		store:  &mongoStore{collection: blogs, metrics: serverMetrics},
+		logger: logger,
+	})
+	if keyStore != nil {
+		apikeypb.RegisterApiKeyServiceServer(grpcServer, apikey.NewService(keyStore))
+	}
 	//use reflection for evans cli
 	reflection.Register(grpcServer)
 
 	go func() {
-		log.Println("Starting the gRPC server")
-		if err := grpcServer.Serve(lis); err != nil {
-			log.Fatalf("unable to initialize server : %v", err)
+		logger.Info("Starting the gRPC server", zap.Strings("addresses", listener.Addrs(listeners)))
+		if err := listener.Serve(grpcServer, listeners); err != nil {
+			logger.Fatal("unable to initialize server", zap.Error(err))
 
 		}
 
@@ -255,15 +330,21 @@ func main() {
 
 	<-ch
 
-	log.Println("Shutting down gRPC server")
+	logger.Info("Shutting down gRPC server")
 	grpcServer.Stop()
 
-	log.Println("Shutting down Mongodb")
+	logger.Info("Shutting down Mongodb")
+
+	if err := client.Disconnect(context.Background()); err != nil {
+		logger.Fatal("error disconnecting from MONGODB DATABASE", zap.Error(err))
+	}
 
-	if err := client.Disconnect(ctx); err != nil {
-		log.Fatalf("error disconnecting from MONGODB DATABASE : %v", err)
+	tracingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		logger.Warn("error flushing traces", zap.Error(err))
 	}
 
-	log.Println("Final shut down ")
+	logger.Info("Final shut down")
 
 }
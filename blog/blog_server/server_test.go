@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/grpc-go-new-course/blog/blogpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func startBlog(t *testing.T) blogpb.BlogServiceClient {
+	validator := requestRules()
+	conn := grpctest.Start(t, func(s *grpc.Server) {
+		blogpb.RegisterBlogServiceServer(s, &server{store: &memoryStore{}, logger: zap.NewNop()})
+	},
+		grpc.ChainUnaryInterceptor(validator.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(validator.StreamServerInterceptor()),
+	)
+	return blogpb.NewBlogServiceClient(conn)
+}
+
+func createBlog(t *testing.T, client blogpb.BlogServiceClient, title string) *blogpb.Blog {
+	t.Helper()
+	res, err := client.CreateBlog(context.Background(), &blogpb.CreateBlogRequest{
+		Blog: &blogpb.Blog{AuthorId: "ama", Title: title, Content: "content of " + title},
+	})
+	if err != nil {
+		t.Fatalf("CreateBlog : %v", err)
+	}
+	return res.GetBlog()
+}
+
+// missingID is a well formed ObjectID no blog has.
+var missingID = primitive.NewObjectID().Hex()
+
+func TestCreateBlog(t *testing.T) {
+	client := startBlog(t)
+
+	tests := []struct {
+		name string
+		blog *blogpb.Blog
+		code codes.Code
+	}{
+		{name: "valid", blog: &blogpb.Blog{AuthorId: "ama", Title: "First", Content: "Hello"}},
+		{name: "missing blog", code: codes.InvalidArgument},
+		{name: "missing title", blog: &blogpb.Blog{AuthorId: "ama", Content: "Hello"}, code: codes.InvalidArgument},
+		{name: "missing author", blog: &blogpb.Blog{Title: "First"}, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.CreateBlog(context.Background(), &blogpb.CreateBlogRequest{Blog: tt.blog})
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+			if _, err := primitive.ObjectIDFromHex(res.GetBlog().GetId()); err != nil {
+				t.Errorf("got invalid ID %q", res.GetBlog().GetId())
+			}
+			if res.GetBlog().GetTitle() != tt.blog.GetTitle() || res.GetBlog().GetContent() != tt.blog.GetContent() {
+				t.Errorf("got %v, want %v", res.GetBlog(), tt.blog)
+			}
+		})
+	}
+}
+
+func TestReadBlog(t *testing.T) {
+	client := startBlog(t)
+	blog := createBlog(t, client, "First")
+
+	tests := []struct {
+		name   string
+		blogID string
+		code   codes.Code
+	}{
+		{name: "existing", blogID: blog.GetId()},
+		{name: "missing", blogID: missingID, code: codes.NotFound},
+		{name: "malformed ID", blogID: "not-an-id", code: codes.InvalidArgument},
+		{name: "empty ID", code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.ReadBlog(context.Background(), &blogpb.ReadBlogRequest{BlogId: tt.blogID})
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+			if res.GetBlog().GetId() != blog.GetId() || res.GetBlog().GetTitle() != blog.GetTitle() {
+				t.Errorf("got %v, want %v", res.GetBlog(), blog)
+			}
+		})
+	}
+}
+
+func TestUpdateBlog(t *testing.T) {
+	client := startBlog(t)
+	blog := createBlog(t, client, "First")
+
+	tests := []struct {
+		name string
+		blog *blogpb.Blog
+		code codes.Code
+	}{
+		{name: "existing", blog: &blogpb.Blog{Id: blog.GetId(), AuthorId: "kofi", Title: "Updated", Content: "New"}},
+		{name: "missing", blog: &blogpb.Blog{Id: missingID, AuthorId: "kofi", Title: "Updated"}, code: codes.NotFound},
+		{name: "malformed ID", blog: &blogpb.Blog{Id: "42", AuthorId: "kofi", Title: "Updated"}, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.UpdateBlog(context.Background(), &blogpb.UpdateBlogRequest{Blog: tt.blog})
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+			if res.GetBlog().GetTitle() != "Updated" || res.GetBlog().GetAuthorId() != "kofi" {
+				t.Errorf("got %v, want %v", res.GetBlog(), tt.blog)
+			}
+
+			read, err := client.ReadBlog(context.Background(), &blogpb.ReadBlogRequest{BlogId: blog.GetId()})
+			if err != nil {
+				t.Fatalf("ReadBlog : %v", err)
+			}
+			if read.GetBlog().GetContent() != "New" {
+				t.Errorf("update not stored, got %v", read.GetBlog())
+			}
+		})
+	}
+}
+
+func TestDeleteBlog(t *testing.T) {
+	client := startBlog(t)
+	blog := createBlog(t, client, "First")
+
+	tests := []struct {
+		name   string
+		blogID string
+		code   codes.Code
+	}{
+		{name: "existing", blogID: blog.GetId()},
+		{name: "already deleted", blogID: blog.GetId(), code: codes.NotFound},
+		{name: "malformed ID", blogID: "zz", code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.DeleteBlog(context.Background(), &blogpb.DeleteBlogRequest{BlogId: tt.blogID})
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+			if res.GetBlogId() != tt.blogID {
+				t.Errorf("got %q, want %q", res.GetBlogId(), tt.blogID)
+			}
+		})
+	}
+}
+
+func TestListBlog(t *testing.T) {
+	tests := []struct {
+		name   string
+		titles []string
+	}{
+		{name: "empty"},
+		{name: "several", titles: []string{"First", "Second", "Third"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := startBlog(t)
+			for _, title := range tt.titles {
+				createBlog(t, client, title)
+			}
+
+			stream, err := client.ListBlog(context.Background(), &blogpb.ListBlogRequest{})
+			if err != nil {
+				t.Fatalf("ListBlog : %v", err)
+			}
+			var titles []string
+			for {
+				res, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Recv : %v", err)
+				}
+				titles = append(titles, res.GetBlog().GetTitle())
+			}
+			if len(titles) != len(tt.titles) {
+				t.Fatalf("got %q, want %q", titles, tt.titles)
+			}
+			for i := range titles {
+				if titles[i] != tt.titles[i] {
+					t.Fatalf("got %q, want %q", titles, tt.titles)
+				}
+			}
+		})
+	}
+}
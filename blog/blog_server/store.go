@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grpc-go-new-course/metrics"
+	"github.com/grpc-go-new-course/rpcerror"
+	"github.com/grpc-go-new-course/tracing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+)
+
+// errBlogNotFound is returned by a blogStore when no blog has the given ID.
+var errBlogNotFound = errors.New("blog not found")
+
+// blogStore persists blogs. Errors other than errBlogNotFound are status
+// errors ready to be returned to clients.
+type blogStore interface {
+	Insert(ctx context.Context, item blogItem) (primitive.ObjectID, error)
+	Find(ctx context.Context, id primitive.ObjectID) (blogItem, error)
+	Replace(ctx context.Context, item blogItem) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// List calls fn with every blog until fn returns an error, which List
+	// then returns unchanged.
+	List(ctx context.Context, fn func(blogItem) error) error
+}
+
+// mongoStore keeps blogs in a MongoDB collection, tracing and timing every
+// operation.
+type mongoStore struct {
+	collection *mongo.Collection
+	metrics    *metrics.Metrics
+}
+
+// track starts a child span of ctx for a MongoDB operation. The returned
+// function ends the span and records the operation latency.
+func (s *mongoStore) track(ctx context.Context, operation string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracing.Tracer().Start(ctx, "mongo."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mongodb"),
+			attribute.String("db.name", BLOGDATABASE),
+			attribute.String("db.mongodb.collection", BLOGCOLLECTION),
+			attribute.String("db.operation", operation),
+		),
+	)
+	return ctx, func(err error) {
+		s.metrics.ObserveMongo(operation, start, err)
+		tracing.End(span, err)
+	}
+}
+
+func (s *mongoStore) Insert(ctx context.Context, item blogItem) (primitive.ObjectID, error) {
+	mongoCtx, done := s.track(ctx, "insert_one")
+	result, err := s.collection.InsertOne(mongoCtx, item)
+	done(err)
+
+	if err != nil {
+		return primitive.NilObjectID, mongoError("insert_one", err)
+	}
+
+	pObjectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return primitive.NilObjectID, rpcerror.New(codes.Internal, fmt.Sprintf("could not convert to ObjectID : %v", result.InsertedID),
+			rpcerror.ErrorInfo("INVALID_OBJECT_ID"),
+		)
+	}
+	return pObjectID, nil
+}
+
+func (s *mongoStore) Find(ctx context.Context, id primitive.ObjectID) (blogItem, error) {
+	mongoCtx, done := s.track(ctx, "find_one")
+	singleResult := s.collection.FindOne(mongoCtx, bson.M{"_id": id})
+	done(singleResult.Err())
+
+	var item blogItem
+	if err := singleResult.Decode(&item); err == mongo.ErrNoDocuments {
+		return blogItem{}, errBlogNotFound
+	} else if err != nil {
+		return blogItem{}, mongoError("find_one", err)
+	}
+	return item, nil
+}
+
+func (s *mongoStore) Replace(ctx context.Context, item blogItem) error {
+	mongoCtx, done := s.track(ctx, "replace_one")
+	updateResult, err := s.collection.ReplaceOne(mongoCtx, bson.M{"_id": item.ID}, item)
+	done(err)
+
+	if err != nil {
+		return mongoError("replace_one", err)
+	}
+	// an update repeating the stored content matches without modifying
+	if updateResult.MatchedCount == 0 {
+		return errBlogNotFound
+	}
+	return nil
+}
+
+func (s *mongoStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	mongoCtx, done := s.track(ctx, "delete_one")
+	deletedResult, err := s.collection.DeleteOne(mongoCtx, bson.M{"_id": id})
+	done(err)
+
+	if err != nil {
+		return mongoError("delete_one", err)
+	}
+	if deletedResult.DeletedCount == 0 {
+		return errBlogNotFound
+	}
+	return nil
+}
+
+func (s *mongoStore) List(ctx context.Context, fn func(blogItem) error) error {
+	mongoCtx, done := s.track(ctx, "find")
+	cursor, err := s.collection.Find(mongoCtx, bson.M{})
+	done(err)
+
+	if err != nil {
+		return mongoError("find", err)
+	}
+	defer cursor.Close(ctx)
+
+	// the cursor fetches further batches from Mongo while iterating
+	mongoCtx, done = s.track(ctx, "cursor_next")
+	for cursor.Next(mongoCtx) {
+		var item blogItem
+		if err := cursor.Decode(&item); err != nil {
+			done(err)
+			return mongoError("decode", err)
+		}
+		if err := fn(item); err != nil {
+			done(nil)
+			return err
+		}
+	}
+	done(cursor.Err())
+	if err := cursor.Err(); err != nil {
+		return mongoError("cursor_next", err)
+	}
+	return nil
+}
+
+// mongoError converts a failed MongoDB operation into a status error. Timeouts
+// and network errors are reported as Unavailable so clients can retry them.
+func mongoError(operation string, err error) error {
+	if mongo.IsTimeout(err) || mongo.IsNetworkError(err) {
+		return rpcerror.New(codes.Unavailable, fmt.Sprintf("database unavailable during %s : %v", operation, err),
+			rpcerror.ErrorInfo("DATABASE_UNAVAILABLE", "operation", operation),
+			rpcerror.RetryInfo(time.Second),
+		)
+	}
+	return rpcerror.New(codes.Internal, fmt.Sprintf("database error during %s : %v", operation, err),
+		rpcerror.ErrorInfo("DATABASE_ERROR", "operation", operation),
+	)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/grpc-go-new-course/metrics"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testStore checks the behavior the handlers rely on, so memoryStore can
+// stand in for mongoStore.
+func testStore(t *testing.T, store blogStore) {
+	ctx := context.Background()
+	item := blogItem{AuthorID: "ama", Title: "First", Content: "Hello"}
+	id, err := store.Insert(ctx, item)
+	if err != nil {
+		t.Fatalf("Insert : %v", err)
+	}
+	item.ID = id
+
+	if found, err := store.Find(ctx, id); err != nil || found != item {
+		t.Fatalf("Find : got %v, %v, want %v", found, err, item)
+	}
+
+	item.Title = "Updated"
+	if err := store.Replace(ctx, item); err != nil {
+		t.Fatalf("Replace : %v", err)
+	}
+	// the content is already stored, which is not a failure
+	if err := store.Replace(ctx, item); err != nil {
+		t.Errorf("Replace without change : %v", err)
+	}
+	if found, err := store.Find(ctx, id); err != nil || found != item {
+		t.Errorf("Find after Replace : got %v, %v, want %v", found, err, item)
+	}
+
+	missing := blogItem{ID: primitive.NewObjectID(), Title: "Missing"}
+	if err := store.Replace(ctx, missing); err != errBlogNotFound {
+		t.Errorf("Replace of a missing blog : got %v, want errBlogNotFound", err)
+	}
+	if _, err := store.Find(ctx, missing.ID); err != errBlogNotFound {
+		t.Errorf("Find of a missing blog : got %v, want errBlogNotFound", err)
+	}
+
+	var listed []blogItem
+	if err := store.List(ctx, func(item blogItem) error {
+		listed = append(listed, item)
+		return nil
+	}); err != nil || len(listed) != 1 || listed[0] != item {
+		t.Errorf("List : got %v, %v, want %v", listed, err, item)
+	}
+
+	if err := store.Delete(ctx, id); err != nil {
+		t.Fatalf("Delete : %v", err)
+	}
+	if err := store.Delete(ctx, id); err != errBlogNotFound {
+		t.Errorf("Delete of a deleted blog : got %v, want errBlogNotFound", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, &memoryStore{})
+}
+
+// TestMongoStore runs against the MongoDB at $BLOG_TEST_MONGO_URI, e.g.
+// mongodb://localhost:27017, in a collection dropped afterwards.
+func TestMongoStore(t *testing.T) {
+	uri := os.Getenv("BLOG_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("BLOG_TEST_MONGO_URI not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to %s : %v", uri, err)
+	}
+	defer client.Disconnect(ctx)
+
+	collection := client.Database(BLOGDATABASE).Collection("blog_store_test_" + primitive.NewObjectID().Hex())
+	defer collection.Drop(ctx)
+	testStore(t, &mongoStore{collection: collection, metrics: metrics.New()})
+}
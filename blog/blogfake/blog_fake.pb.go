@@ -0,0 +1,89 @@
+// Code generated by protoc-gen-go-grpcfake. DO NOT EDIT.
+// source: blog/blogpb/blog.proto
+
+// Package blogfake provides a fake blogpb.BlogServiceClient, and fakes of its
+// streams, for unit testing code that calls the blog service without
+// running a server.
+package blogfake
+
+import (
+	context "context"
+	blogpb "github.com/grpc-go-new-course/blog/blogpb"
+	fakegrpc "github.com/grpc-go-new-course/fakegrpc"
+	grpc "google.golang.org/grpc"
+)
+
+// Client is a fake blogpb.BlogServiceClient. Every call is recorded,
+// then answered by the matching Func field, or fails with Unimplemented
+// when that field is nil. Call options are ignored.
+type Client struct {
+	fakegrpc.Recorder
+
+	CreateBlogFunc func(ctx context.Context, in *blogpb.CreateBlogRequest) (*blogpb.CreateBlogResponse, error)
+	ReadBlogFunc   func(ctx context.Context, in *blogpb.ReadBlogRequest) (*blogpb.ReadBlogResponse, error)
+	UpdateBlogFunc func(ctx context.Context, in *blogpb.UpdateBlogRequest) (*blogpb.UpdateBlogResponse, error)
+	DeleteBlogFunc func(ctx context.Context, in *blogpb.DeleteBlogRequest) (*blogpb.DeleteBlogResponse, error)
+	ListBlogFunc   func(ctx context.Context, in *blogpb.ListBlogRequest) (blogpb.BlogService_ListBlogClient, error)
+}
+
+var _ blogpb.BlogServiceClient = (*Client)(nil)
+
+func (c *Client) CreateBlog(ctx context.Context, in *blogpb.CreateBlogRequest, opts ...grpc.CallOption) (*blogpb.CreateBlogResponse, error) {
+	c.Record(ctx, "CreateBlog", in)
+	if c.CreateBlogFunc == nil {
+		return nil, fakegrpc.Unimplemented("CreateBlog")
+	}
+	return c.CreateBlogFunc(ctx, in)
+}
+
+func (c *Client) ReadBlog(ctx context.Context, in *blogpb.ReadBlogRequest, opts ...grpc.CallOption) (*blogpb.ReadBlogResponse, error) {
+	c.Record(ctx, "ReadBlog", in)
+	if c.ReadBlogFunc == nil {
+		return nil, fakegrpc.Unimplemented("ReadBlog")
+	}
+	return c.ReadBlogFunc(ctx, in)
+}
+
+func (c *Client) UpdateBlog(ctx context.Context, in *blogpb.UpdateBlogRequest, opts ...grpc.CallOption) (*blogpb.UpdateBlogResponse, error) {
+	c.Record(ctx, "UpdateBlog", in)
+	if c.UpdateBlogFunc == nil {
+		return nil, fakegrpc.Unimplemented("UpdateBlog")
+	}
+	return c.UpdateBlogFunc(ctx, in)
+}
+
+func (c *Client) DeleteBlog(ctx context.Context, in *blogpb.DeleteBlogRequest, opts ...grpc.CallOption) (*blogpb.DeleteBlogResponse, error) {
+	c.Record(ctx, "DeleteBlog", in)
+	if c.DeleteBlogFunc == nil {
+		return nil, fakegrpc.Unimplemented("DeleteBlog")
+	}
+	return c.DeleteBlogFunc(ctx, in)
+}
+
+func (c *Client) ListBlog(ctx context.Context, in *blogpb.ListBlogRequest, opts ...grpc.CallOption) (blogpb.BlogService_ListBlogClient, error) {
+	c.Record(ctx, "ListBlog", in)
+	if c.ListBlogFunc == nil {
+		return nil, fakegrpc.Unimplemented("ListBlog")
+	}
+	return c.ListBlogFunc(ctx, in)
+}
+
+// ListBlogStream is a fake blogpb.BlogService_ListBlogClient.
+// Recv returns Responses in order, then Err, or io.EOF when Err is nil.
+type ListBlogStream struct {
+	fakegrpc.ClientStream
+	Responses []*blogpb.ListBlogResponse
+	Err       error
+
+	cursor fakegrpc.Cursor
+}
+
+var _ blogpb.BlogService_ListBlogClient = (*ListBlogStream)(nil)
+
+func (s *ListBlogStream) Recv() (*blogpb.ListBlogResponse, error) {
+	i, err := s.cursor.Next(len(s.Responses), s.Err)
+	if err != nil {
+		return nil, err
+	}
+	return s.Responses[i], nil
+}
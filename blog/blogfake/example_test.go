@@ -0,0 +1,56 @@
+package blogfake_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/grpc-go-new-course/blog/blogfake"
+	"github.com/grpc-go-new-course/blog/blogpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// titles is the code under test: it only depends on the client interface.
+func titles(ctx context.Context, client blogpb.BlogServiceClient) ([]string, error) {
+	stream, err := client.ListBlog(ctx, &blogpb.ListBlogRequest{})
+	if err != nil {
+		return nil, err
+	}
+	var titles []string
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return titles, nil
+		}
+		if err != nil {
+			return titles, err
+		}
+		titles = append(titles, res.GetBlog().GetTitle())
+	}
+}
+
+func Example() {
+	client := &blogfake.Client{
+		ListBlogFunc: func(ctx context.Context, in *blogpb.ListBlogRequest) (blogpb.BlogService_ListBlogClient, error) {
+			return &blogfake.ListBlogStream{
+				Responses: []*blogpb.ListBlogResponse{
+					{Blog: &blogpb.Blog{Title: "First"}},
+					{Blog: &blogpb.Blog{Title: "Second"}},
+				},
+				Err: status.Error(codes.Unavailable, "database unavailable"),
+			}, nil
+		},
+	}
+
+	got, err := titles(context.Background(), client)
+	fmt.Println(got, status.Code(err))
+	fmt.Println(len(client.CallsTo("ListBlog")), "call")
+
+	_, err = client.ReadBlog(context.Background(), &blogpb.ReadBlogRequest{BlogId: "42"})
+	fmt.Println(status.Code(err))
+	// Output:
+	// [First Second] Unavailable
+	// 1 call
+	// Unimplemented
+}
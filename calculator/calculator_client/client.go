@@ -2,21 +2,64 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/grpc-go-new-course/apikey"
 	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/calculator/units/unitspb"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/rpcerror"
+	"github.com/grpc-go-new-course/tlsconfig"
+	"github.com/grpc-go-new-course/tracing"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 func main() {
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP gRPC collector receiving traces, e.g. localhost:4317")
+	traceFile := flag.String("trace-file", "", "file receiving traces as JSON lines when no OTLP endpoint is set")
+	addr := flag.String("addr", "localhost:50000", `server to dial, e.g. "unix:///run/calculator/calculator.sock" or "unix-abstract:calculator"`)
+	apiKey := flag.String("api-key", os.Getenv("API_KEY"), "API key sent with every call, defaults to $API_KEY")
+	repl := flag.Bool("repl", false, "evaluate the expressions read from standard input in a calculator session")
+	var tlsFlags tlsconfig.ClientFlags
+	tlsFlags.Register(flag.CommandLine, "")
+	flag.Parse()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName:  "calculator_client",
+		OTLPEndpoint: *otlpEndpoint,
+		File:         *traceFile,
+	})
+	if err != nil {
+		log.Fatalf("unable to set up tracing : %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
-	conn, err := grpc.Dial("localhost:50000", grpc.WithInsecure())
+	creds, err := tlsFlags.DialOption()
+	if err != nil {
+		log.Fatalf("error loading client certificates : %v", err)
+	}
+	dialOpts := []grpc.DialOption{
+		creds,
+		grpc.WithChainUnaryInterceptor(
+			tracing.UnaryClientInterceptor(),
+			logging.UnaryClientInterceptor(),
+		),
+		grpc.WithChainStreamInterceptor(
+			tracing.StreamClientInterceptor(),
+			logging.StreamClientInterceptor(),
+		),
+	}
+	if *apiKey != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(apikey.Credentials(*apiKey)))
+	}
+	conn, err := grpc.Dial(*addr, dialOpts...)
 
 	if err != nil {
 		log.Fatalf("could not establish client connection %v", err)
@@ -25,12 +68,23 @@ func main() {
 	defer conn.Close()
 
 	client := calculatorpb.NewCalculatorServiceClient(conn)
+	if *repl {
+		doSession(client, os.Stdin, os.Stdout)
+		return
+	}
 	//doServerStreaming(client)
 
 	//doUnary(client)
 	//doClientStreaming(client)
 	//BiDi(client)
 	doUnaryError(client)
+	//doBigArithmetic(client)
+	//doEvaluate(client)
+	//doComputeStatistics(client)
+	//doStreamAggregate(client)
+	//doComputeMatrix(client)
+	//doRoot(client)
+	//doConvertUnits(unitspb.NewUnitServiceClient(conn))
 
 }
 
@@ -42,7 +96,7 @@ func doUnary(client calculatorpb.CalculatorServiceClient) {
 	resp, err := client.Sum(context.Background(), &req)
 
 	if err != nil {
-		log.Fatalf("rpc Sum call failed : %v", err)
+		log.Fatalf("rpc Sum call failed : %v", rpcerror.Describe(err))
 
 	}
 
@@ -57,7 +111,7 @@ func doServerStreaming(client calculatorpb.CalculatorServiceClient) {
 	stream, err := client.PrimeNumberDecomposition(context.Background(), req)
 
 	if err != nil {
-		log.Fatalf("rpc PrimeNumberDecomposition failed : %v", err)
+		log.Fatalf("rpc PrimeNumberDecomposition failed : %v", rpcerror.Describe(err))
 
 	}
 
@@ -68,10 +122,10 @@ func doServerStreaming(client calculatorpb.CalculatorServiceClient) {
 		}
 
 		if err != nil {
-			log.Fatalf("Server Streaming of results failed : %v", err)
+			log.Fatalf("Server Streaming of results failed : %v", rpcerror.Describe(err))
 		}
 
-		log.Printf(" Server Streaming result : %v \n", res.GetPrimeFactor())
+		log.Printf(" Server Streaming result : %v^%v \n", res.GetPrimeFactor(), res.GetMultiplicity())
 
 	}
 
@@ -90,7 +144,7 @@ func doClientStreaming(client calculatorpb.CalculatorServiceClient) {
 	stream, err := client.ComputeAverage(context.Background())
 
 	if err != nil {
-		log.Fatalf("error calling rpc ComputeAverage : %v", err)
+		log.Fatalf("error calling rpc ComputeAverage : %v", rpcerror.Describe(err))
 
 	}
 
@@ -102,7 +156,7 @@ func doClientStreaming(client calculatorpb.CalculatorServiceClient) {
 
 	resp, err := stream.CloseAndRecv()
 	if err != nil {
-		log.Fatalf("error getting Compute Average Server Response : %v", err)
+		log.Fatalf("error getting Compute Average Server Response : %v", rpcerror.Describe(err))
 
 	}
 	log.Printf(" Response : Average is %v", resp.GetAverage())
@@ -113,7 +167,7 @@ func BiDi(client calculatorpb.CalculatorServiceClient) {
 	stream, err := client.FindMaximum(context.Background())
 
 	if err != nil {
-		log.Fatalf("error calling rpc FindMaximum : %v", err)
+		log.Fatalf("error calling rpc FindMaximum : %v", rpcerror.Describe(err))
 	}
 
 	var wg sync.WaitGroup
@@ -147,7 +201,7 @@ func BiDi(client calculatorpb.CalculatorServiceClient) {
 
 			}
 			if err != nil {
-				log.Fatalf("error reading server streams : %v", err)
+				log.Fatalf("error reading server streams : %v", rpcerror.Describe(err))
 			}
 			log.Printf("The maximum is : %v \n", resp.GetMaximum())
 		}
@@ -166,17 +220,16 @@ func doUnaryError(client calculatorpb.CalculatorServiceClient) {
 		res, err := client.SquareRoot(context.Background(), req)
 
 		if err != nil {
-			status, ok := status.FromError(err)
-			if ok {
-				log.Println(status.Message())
-
-				if status.Code() == codes.InvalidArgument {
-					log.Fatalf("error sending Invalid argument : %v", err)
+			details := rpcerror.FromError(err)
 
+			if details.Code == codes.InvalidArgument {
+				for _, violation := range details.BadRequest.GetFieldViolations() {
+					log.Printf("invalid field %s : %s", violation.GetField(), violation.GetDescription())
 				}
+				log.Fatalf("error sending Invalid argument : %v", details)
 
 			}
-			log.Fatalf("Error calling rpc  SquareRoot : %v", err)
+			log.Fatalf("Error calling rpc  SquareRoot : %v", details)
 
 		}
 
@@ -187,3 +240,135 @@ func doUnaryError(client calculatorpb.CalculatorServiceClient) {
 	errCall(client, -23)
 
 }
+
+func doBigArithmetic(client calculatorpb.CalculatorServiceClient) {
+
+	scale := int32(2)
+	req := &calculatorpb.BigArithmeticRequest{
+		Operation:    calculatorpb.BigOperation_BIG_DIVIDE,
+		FirstNumber:  &calculatorpb.BigNumber{Value: "100000000000000000000000.00"},
+		SecondNumber: &calculatorpb.BigNumber{Value: "3"},
+		Scale:        &scale,
+		Rounding:     calculatorpb.RoundingMode_ROUND_HALF_EVEN,
+	}
+	res, err := client.BigArithmetic(context.Background(), req)
+	if err != nil {
+		log.Fatalf("rpc BigArithmetic failed : %v", rpcerror.Describe(err))
+	}
+	log.Printf("Response from BigArithmetic : %v", res.GetResult().GetValue())
+}
+
+func doEvaluate(client calculatorpb.CalculatorServiceClient) {
+
+	req := &calculatorpb.EvaluateRequest{
+		Expression: "2 * (3 + sqrt(16)) / x",
+		Variables:  map[string]float64{"x": 7},
+	}
+	res, err := client.Evaluate(context.Background(), req)
+	if err != nil {
+		log.Fatalf("rpc Evaluate failed : %v", rpcerror.Describe(err))
+	}
+	log.Printf("%s = %v", req.GetExpression(), res.GetResult())
+}
+
+func doComputeStatistics(client calculatorpb.CalculatorServiceClient) {
+
+	stream, err := client.ComputeStatistics(context.Background())
+	if err != nil {
+		log.Fatalf("rpc ComputeStatistics failed : %v", rpcerror.Describe(err))
+	}
+	requests := []*calculatorpb.ComputeStatisticsRequest{
+		{Numbers: []float64{3, 1.5, 8}, Percentiles: []float64{90, 99}},
+		{Numbers: []float64{-2, 40, 7.25}},
+	}
+	for _, req := range requests {
+		if err := stream.Send(req); err != nil {
+			log.Fatalf("error sending numbers : %v", err)
+		}
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		log.Fatalf("rpc ComputeStatistics failed : %v", rpcerror.Describe(err))
+	}
+	log.Printf("Statistics : %v", res)
+}
+
+func doStreamAggregate(client calculatorpb.CalculatorServiceClient) {
+
+	stream, err := client.StreamAggregate(context.Background())
+	if err != nil {
+		log.Fatalf("rpc StreamAggregate failed : %v", rpcerror.Describe(err))
+	}
+
+	go func() {
+		// the maximum of every 3 numbers, reported after each new number
+		config := &calculatorpb.AggregateConfig{
+			Aggregation: calculatorpb.Aggregation_AGGREGATION_MAX,
+			Window: &calculatorpb.Window{
+				Size:  &calculatorpb.Window_Count{Count: 3},
+				Slide: &calculatorpb.Window_SlideCount{SlideCount: 1},
+			},
+		}
+		stream.Send(&calculatorpb.StreamAggregateRequest{
+			Message: &calculatorpb.StreamAggregateRequest_Config{Config: config},
+		})
+		for _, number := range []float64{4, 7, 2, 19, 4, 6, 32} {
+			stream.Send(&calculatorpb.StreamAggregateRequest{
+				Message: &calculatorpb.StreamAggregateRequest_Value{Value: &calculatorpb.AggregateValue{Value: number}},
+			})
+		}
+		stream.CloseSend()
+	}()
+
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Fatalf("error reading aggregates : %v", rpcerror.Describe(err))
+		}
+		log.Printf("maximum of numbers %d to %d : %v", res.GetStartIndex(), res.GetEndIndex()-1, res.GetValue())
+	}
+}
+
+func doComputeMatrix(client calculatorpb.CalculatorServiceClient) {
+
+	// 2x + y = 5, x - 3y = -1
+	req := &calculatorpb.MatrixRequest{
+		Operation: calculatorpb.MatrixOperation_MATRIX_SOLVE,
+		First:     &calculatorpb.Matrix{Rows: 2, Columns: 2, Values: []float64{2, 1, 1, -3}},
+		Second:    &calculatorpb.Matrix{Rows: 2, Columns: 1, Values: []float64{5, -1}},
+	}
+	res, err := client.ComputeMatrix(context.Background(), req)
+	if err != nil {
+		log.Fatalf("rpc ComputeMatrix failed : %v", rpcerror.Describe(err))
+	}
+	log.Printf("x, y = %v", res.GetMatrix().GetValues())
+}
+
+func doRoot(client calculatorpb.CalculatorServiceClient) {
+
+	scale := int32(50)
+	req := &calculatorpb.RootRequest{
+		Radicand: &calculatorpb.RootRequest_Decimal{Decimal: &calculatorpb.BigNumber{Value: "-2"}},
+		Degree:   4,
+		Scale:    &scale,
+		Complex:  true,
+	}
+	res, err := client.Root(context.Background(), req)
+	if err != nil {
+		log.Fatalf("rpc Root failed : %v", rpcerror.Describe(err))
+	}
+	log.Printf("fourth root of -2 : %s + %si", res.GetRealDecimal().GetValue(), res.GetImaginaryDecimal().GetValue())
+}
+
+func doConvertUnits(client unitspb.UnitServiceClient) {
+
+	req := &unitspb.ConvertUnitsRequest{Value: 100, From: "Mbit/s", To: "MB/s"}
+	res, err := client.ConvertUnits(context.Background(), req)
+	if err != nil {
+		log.Fatalf("rpc ConvertUnits failed : %v", rpcerror.Describe(err))
+	}
+	log.Printf("%v %s = %v %s (%s)", req.GetValue(), req.GetFrom(), res.GetValue(), req.GetTo(), res.GetDimension())
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/rpcerror"
+)
+
+// doSession reads expressions from in, one per line, and evaluates them in
+// a server side session. ":history" lists the session, ":clear" resets it.
+func doSession(client calculatorpb.CalculatorServiceClient, in io.Reader, out io.Writer) {
+
+	ctx := context.Background()
+	created, err := client.CreateSession(ctx, &calculatorpb.CreateSessionRequest{})
+	if err != nil {
+		log.Fatalf("rpc CreateSession failed : %v", rpcerror.Describe(err))
+	}
+	id := created.GetSessionId()
+	fmt.Fprintf(out, "session %s, use ans for the previous result, :history, :clear or :quit\n> ", id)
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+		case ":quit":
+			return
+		case ":history":
+			res, err := client.GetHistory(ctx, &calculatorpb.GetHistoryRequest{SessionId: id})
+			if err != nil {
+				log.Fatalf("rpc GetHistory failed : %v", rpcerror.Describe(err))
+			}
+			for _, entry := range res.GetEntries() {
+				fmt.Fprintf(out, "%s = %v\n", entry.GetExpression(), entry.GetResult())
+			}
+			names := make([]string, 0, len(res.GetVariables()))
+			for name := range res.GetVariables() {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Fprintf(out, "  %s : %v\n", name, res.GetVariables()[name])
+			}
+		case ":clear":
+			if _, err := client.ClearSession(ctx, &calculatorpb.ClearSessionRequest{SessionId: id}); err != nil {
+				log.Fatalf("rpc ClearSession failed : %v", rpcerror.Describe(err))
+			}
+		default:
+			res, err := client.EvaluateInSession(ctx, &calculatorpb.EvaluateInSessionRequest{SessionId: id, Expression: line})
+			if err != nil {
+				// mistakes in expressions should not end the session
+				fmt.Fprintln(out, rpcerror.FromError(err).Message)
+				break
+			}
+			fmt.Fprintln(out, res.GetEntry().GetResult())
+		}
+		fmt.Fprint(out, "> ")
+	}
+}
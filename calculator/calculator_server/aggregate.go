@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/calculator/window"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/rpcerror"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	maxWindowCount    = 10000
+	maxTopK           = 1000
+	minWindowDuration = time.Millisecond
+	maxWindowDuration = 24 * time.Hour
+	// maxBufferedValues bounds the values a time window stream holds
+	maxBufferedValues = 100000
+)
+
+var aggregations = map[calculatorpb.Aggregation]window.Aggregation{
+	calculatorpb.Aggregation_AGGREGATION_MAX:   window.Max,
+	calculatorpb.Aggregation_AGGREGATION_MIN:   window.Min,
+	calculatorpb.Aggregation_AGGREGATION_SUM:   window.Sum,
+	calculatorpb.Aggregation_AGGREGATION_MEAN:  window.Mean,
+	calculatorpb.Aggregation_AGGREGATION_TOP_K: window.TopK,
+}
+
+func invalidConfig(field, description string) error {
+	return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid aggregation config : %s %s", field, description),
+		rpcerror.ErrorInfo("INVALID_AGGREGATE_CONFIG", "field", field),
+		rpcerror.BadRequest(field, description),
+	)
+}
+
+// aggregator applies an AggregateConfig to the values of a stream.
+type aggregator struct {
+	aggregation window.Aggregation
+	k           int
+	count       *window.CountWindows
+	time        *window.TimeWindows
+	eventTime   bool
+}
+
+func newAggregator(cfg *calculatorpb.AggregateConfig) (*aggregator, error) {
+	a := &aggregator{k: int(cfg.GetK())}
+	var ok bool
+	if a.aggregation, ok = aggregations[cfg.GetAggregation()]; !ok {
+		return nil, invalidConfig("config.aggregation", "is not a known aggregation")
+	}
+	if a.aggregation == window.TopK && (a.k < 1 || a.k > maxTopK) {
+		return nil, invalidConfig("config.k", fmt.Sprintf("must be between 1 and %d for top K", maxTopK))
+	}
+
+	w := cfg.GetWindow()
+	switch size := w.GetSize().(type) {
+	case *calculatorpb.Window_Count:
+		if size.Count < 1 || size.Count > maxWindowCount {
+			return nil, invalidConfig("config.window.count", fmt.Sprintf("must be between 1 and %d", maxWindowCount))
+		}
+		slide := size.Count
+		switch s := w.GetSlide().(type) {
+		case *calculatorpb.Window_SlideCount:
+			slide = s.SlideCount
+		case *calculatorpb.Window_SlideDuration:
+			return nil, invalidConfig("config.window.slide_duration", "cannot slide a count window")
+		}
+		if slide < 1 || slide > size.Count {
+			return nil, invalidConfig("config.window.slide_count", "must be between 1 and the window count")
+		}
+		a.count = window.NewCountWindows(int(size.Count), int(slide))
+
+	case *calculatorpb.Window_Duration:
+		d := size.Duration.AsDuration()
+		if !size.Duration.IsValid() || d < minWindowDuration || d > maxWindowDuration {
+			return nil, invalidConfig("config.window.duration", fmt.Sprintf("must be between %v and %v", minWindowDuration, maxWindowDuration))
+		}
+		slide := d
+		switch s := w.GetSlide().(type) {
+		case *calculatorpb.Window_SlideDuration:
+			slide = s.SlideDuration.AsDuration()
+		case *calculatorpb.Window_SlideCount:
+			return nil, invalidConfig("config.window.slide_count", "cannot slide a time window")
+		}
+		if slide < minWindowDuration || slide > d {
+			return nil, invalidConfig("config.window.slide_duration", fmt.Sprintf("must be between %v and the window duration", minWindowDuration))
+		}
+		// every value lands in d/slide windows, all closed at once
+		if d/slide > maxWindowCount {
+			return nil, invalidConfig("config.window.slide_duration", fmt.Sprintf("must be at least the window duration / %d", maxWindowCount))
+		}
+		a.time = window.NewTimeWindows(d, slide, maxBufferedValues)
+		a.eventTime = w.GetEventTime()
+
+	default:
+		return nil, invalidConfig("config.window", "must set a count or a duration")
+	}
+	return a, nil
+}
+
+// add records v and returns the windows it closes.
+func (a *aggregator) add(ctx context.Context, v *calculatorpb.AggregateValue, now time.Time) ([]window.Window, error) {
+	if math.IsNaN(v.GetValue()) || math.IsInf(v.GetValue(), 0) {
+		return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid value : %v", v.GetValue()),
+			rpcerror.BadRequest("value.value", "must be finite"),
+		)
+	}
+	if a.count != nil {
+		return a.count.Add(v.GetValue()), nil
+	}
+
+	at := now
+	if a.eventTime {
+		if v.GetTimestamp() == nil {
+			return nil, rpcerror.New(codes.InvalidArgument, "value without timestamp",
+				rpcerror.BadRequest("value.timestamp", "is required with event time windows"),
+			)
+		}
+		if !v.GetTimestamp().IsValid() {
+			return nil, invalidTimestamp(v.GetTimestamp().String())
+		}
+		at = v.GetTimestamp().AsTime()
+	}
+	switch err := a.time.Add(v.GetValue(), at); {
+	case errors.Is(err, window.ErrOutOfRange):
+		return nil, invalidTimestamp(at.String())
+	case err != nil:
+		return nil, rpcerror.New(codes.ResourceExhausted, fmt.Sprintf("%v waiting for their windows to close", err),
+			rpcerror.ErrorInfo("TOO_MANY_BUFFERED_VALUES", "limit", fmt.Sprint(maxBufferedValues)),
+		)
+	}
+	closed, err := a.time.Advance(ctx, at)
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	return closed, nil
+}
+
+func invalidTimestamp(timestamp string) error {
+	return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid timestamp : %s", timestamp),
+		rpcerror.BadRequest("value.timestamp", "must be between 1678 and 2262"),
+	)
+}
+
+// processingTime reports whether the windows close on the server clock.
+func (a *aggregator) processingTime() bool {
+	return a.time != nil && !a.eventTime
+}
+
+// wakeUp returns when the open window closes on the server clock, for
+// processing time windows.
+func (a *aggregator) wakeUp() (time.Time, bool) {
+	if !a.processingTime() {
+		return time.Time{}, false
+	}
+	return a.time.NextEnd()
+}
+
+func (a *aggregator) flush(ctx context.Context) ([]window.Window, error) {
+	if a.count != nil {
+		return a.count.Flush(), nil
+	}
+	return a.time.Flush(ctx)
+}
+
+func (a *aggregator) response(w window.Window) *calculatorpb.StreamAggregateResponse {
+	result := window.Aggregate(a.aggregation, a.k, w.Values)
+	res := &calculatorpb.StreamAggregateResponse{
+		Count:   int64(result.Count),
+		Value:   result.Value,
+		Top:     result.Top,
+		Partial: w.Partial,
+	}
+	if a.count != nil {
+		res.StartIndex, res.EndIndex = w.From, w.To
+	} else {
+		res.StartTime, res.EndTime = timestamppb.New(w.Start), timestamppb.New(w.End)
+		res.LateValues = a.time.Late()
+	}
+	return res
+}
+
+func (s *server) StreamAggregate(stream calculatorpb.CalculatorService_StreamAggregateServer) error {
+
+	ctx := stream.Context()
+	logger := logging.ForRequest(ctx, s.logger)
+
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		logger.Warn("error trying to get client data streams", zap.Error(err))
+		return status.Errorf(status.Code(err), "error trying to get client data streams : %v", err)
+	}
+	if first.GetConfig() == nil {
+		return invalidConfig("config", "must be the first message of the stream")
+	}
+	agg, err := newAggregator(first.GetConfig())
+	if err != nil {
+		return err
+	}
+
+	send := func(windows []window.Window) error {
+		for _, w := range windows {
+			if err := stream.Send(agg.response(w)); err != nil {
+				logger.Warn("error trying to send server data stream", zap.Error(err))
+				return status.Errorf(status.Code(err), "error trying to send server data stream : %v", err)
+			}
+		}
+		return nil
+	}
+
+	// only this goroutine receives, so no Recv outlives the handler; windows
+	// closing on the server clock are sent by closeOnTime, which stops before
+	// the handler returns
+	var mu sync.Mutex // guards agg and stream.Send
+	wake := make(chan struct{}, 1)
+	done := make(chan struct{})
+	var timerErr error
+	var wg sync.WaitGroup
+	if agg.processingTime() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := closeOnTime(ctx, &mu, agg, send, wake, done); err != nil {
+				mu.Lock()
+				timerErr = err
+				mu.Unlock()
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(done)
+
+	for {
+		req, err := stream.Recv()
+
+		mu.Lock()
+		if timerErr != nil {
+			// the stream is already broken, Recv failed or is about to
+			err := timerErr
+			mu.Unlock()
+			return err
+		}
+		mu.Unlock()
+
+		if err == io.EOF {
+			mu.Lock()
+			defer mu.Unlock()
+			closed, err := agg.flush(ctx)
+			if err != nil {
+				return status.FromContextError(err).Err()
+			}
+			return send(closed)
+		}
+		if err != nil {
+			logger.Warn("error trying to get client data streams", zap.Error(err))
+			return status.Errorf(status.Code(err), "error trying to get client data streams : %v", err)
+		}
+		if req.GetValue() == nil {
+			return invalidConfig("config", "must only be sent as the first message")
+		}
+
+		mu.Lock()
+		closed, err := agg.add(ctx, req.GetValue(), time.Now())
+		if err == nil {
+			err = send(closed)
+		}
+		mu.Unlock()
+		if err != nil {
+			return err
+		}
+
+		// the value may open a window closing before the one awaited
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// closeOnTime sends the processing time windows of agg as the server clock
+// closes them, even when no value arrives, until done is closed. A wake up
+// makes it look again for the next window to close.
+func closeOnTime(ctx context.Context, mu *sync.Mutex, agg *aggregator, send func([]window.Window) error, wake, done <-chan struct{}) error {
+	var timer *time.Timer
+	stopTimer := func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	defer stopTimer()
+
+	for {
+		stopTimer()
+		mu.Lock()
+		end, ok := agg.wakeUp()
+		mu.Unlock()
+		var timeout <-chan time.Time
+		if ok {
+			timer = time.NewTimer(time.Until(end))
+			timeout = timer.C
+		}
+
+		select {
+		case <-done:
+			return nil
+		case <-wake:
+		case <-timeout:
+			mu.Lock()
+			closed, err := agg.time.Advance(ctx, time.Now())
+			if err != nil {
+				err = status.FromContextError(err).Err()
+			} else {
+				err = send(closed)
+			}
+			mu.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/grpc-go-new-course/ratelimit"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func configMessage(cfg *calculatorpb.AggregateConfig) *calculatorpb.StreamAggregateRequest {
+	return &calculatorpb.StreamAggregateRequest{Message: &calculatorpb.StreamAggregateRequest_Config{Config: cfg}}
+}
+
+func valueMessage(v float64, at time.Time) *calculatorpb.StreamAggregateRequest {
+	value := &calculatorpb.AggregateValue{Value: v}
+	if !at.IsZero() {
+		value.Timestamp = timestamppb.New(at)
+	}
+	return &calculatorpb.StreamAggregateRequest{Message: &calculatorpb.StreamAggregateRequest_Value{Value: value}}
+}
+
+func countWindow(count, slide int32) *calculatorpb.Window {
+	w := &calculatorpb.Window{Size: &calculatorpb.Window_Count{Count: count}}
+	if slide != 0 {
+		w.Slide = &calculatorpb.Window_SlideCount{SlideCount: slide}
+	}
+	return w
+}
+
+// describeUpdate renders an update compactly, e.g. "[0,3)=7" or
+// "[10s,20s)=[9 8]*".
+func describeUpdate(res *calculatorpb.StreamAggregateResponse) string {
+	var s string
+	if res.GetStartTime() != nil {
+		s = fmt.Sprintf("[%ds,%ds)", res.GetStartTime().GetSeconds(), res.GetEndTime().GetSeconds())
+	} else {
+		s = fmt.Sprintf("[%d,%d)", res.GetStartIndex(), res.GetEndIndex())
+	}
+	if res.GetTop() != nil {
+		s += fmt.Sprintf("=%v", res.GetTop())
+	} else {
+		s += fmt.Sprintf("=%v", res.GetValue())
+	}
+	if res.GetPartial() {
+		s += "*"
+	}
+	if res.GetLateValues() > 0 {
+		s += fmt.Sprintf(" late %d", res.GetLateValues())
+	}
+	return s
+}
+
+func TestStreamAggregate(t *testing.T) {
+	client := startCalculator(t)
+
+	at := func(seconds int64) time.Time { return time.Unix(seconds, 0) }
+	eventTime := func(size, slide time.Duration) *calculatorpb.Window {
+		return &calculatorpb.Window{
+			Size:      &calculatorpb.Window_Duration{Duration: durationpb.New(size)},
+			Slide:     &calculatorpb.Window_SlideDuration{SlideDuration: durationpb.New(slide)},
+			EventTime: true,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		config   *calculatorpb.AggregateConfig
+		values   []float64
+		times    []time.Time
+		want     string
+		code     codes.Code
+		noConfig bool
+	}{
+		{
+			name:   "tumbling max",
+			config: &calculatorpb.AggregateConfig{Window: countWindow(3, 0)},
+			values: []float64{1, 5, 3, -2, -7, -1, 4},
+			want:   "[0,3)=5 [3,6)=-1 [6,7)=4*",
+		},
+		{
+			name:   "sliding sum",
+			config: &calculatorpb.AggregateConfig{Aggregation: calculatorpb.Aggregation_AGGREGATION_SUM, Window: countWindow(3, 1)},
+			values: []float64{1, 2, 3, 4},
+			want:   "[0,3)=6 [1,4)=9 [2,4)=7*",
+		},
+		{
+			name:   "mean",
+			config: &calculatorpb.AggregateConfig{Aggregation: calculatorpb.Aggregation_AGGREGATION_MEAN, Window: countWindow(2, 0)},
+			values: []float64{1, 2, 10, 20},
+			want:   "[0,2)=1.5 [2,4)=15",
+		},
+		{
+			name:   "top k",
+			config: &calculatorpb.AggregateConfig{Aggregation: calculatorpb.Aggregation_AGGREGATION_TOP_K, K: 2, Window: countWindow(4, 0)},
+			values: []float64{3, 9, 1, 8},
+			want:   "[0,4)=[9 8]",
+		},
+		{
+			name:   "event time min",
+			config: &calculatorpb.AggregateConfig{Aggregation: calculatorpb.Aggregation_AGGREGATION_MIN, Window: eventTime(10*time.Second, 10*time.Second)},
+			values: []float64{5, 3, 7, 1, 2},
+			times:  []time.Time{at(1), at(4), at(12), at(8), at(25)},
+			want:   "[0s,10s)=3 [10s,20s)=7 late 1 [20s,30s)=2*",
+		},
+		{
+			name:   "missing config",
+			values: []float64{1}, noConfig: true,
+			code: codes.InvalidArgument,
+		},
+		{
+			name:   "missing window",
+			config: &calculatorpb.AggregateConfig{},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "slide beyond size",
+			config: &calculatorpb.AggregateConfig{Window: countWindow(3, 4)},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "top k without k",
+			config: &calculatorpb.AggregateConfig{Aggregation: calculatorpb.Aggregation_AGGREGATION_TOP_K, Window: countWindow(3, 0)},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "event time without timestamp",
+			config: &calculatorpb.AggregateConfig{Window: eventTime(time.Second, time.Second)},
+			values: []float64{1},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "timestamp before 1678",
+			config: &calculatorpb.AggregateConfig{Window: eventTime(time.Second, time.Second)},
+			values: []float64{1},
+			times:  []time.Time{time.Date(1600, 1, 1, 0, 0, 0, 0, time.UTC)},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "timestamp after 2262",
+			config: &calculatorpb.AggregateConfig{Window: eventTime(time.Second, time.Second)},
+			values: []float64{1},
+			times:  []time.Time{time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC)},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "too many windows per value",
+			config: &calculatorpb.AggregateConfig{Window: eventTime(24*time.Hour, time.Millisecond)},
+			code:   codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.StreamAggregate(context.Background())
+			if err != nil {
+				t.Fatalf("StreamAggregate : %v", err)
+			}
+			if !tt.noConfig {
+				if err := stream.Send(configMessage(tt.config)); err != nil {
+					t.Fatalf("Send : %v", err)
+				}
+			}
+			for i, v := range tt.values {
+				var when time.Time
+				if tt.times != nil {
+					when = tt.times[i]
+				}
+				// the server may already have failed the stream
+				if err := stream.Send(valueMessage(v, when)); err != nil {
+					break
+				}
+			}
+			stream.CloseSend()
+
+			var updates []string
+			for {
+				res, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					grpctest.AssertCode(t, err, tt.code)
+					return
+				}
+				updates = append(updates, describeUpdate(res))
+			}
+			grpctest.AssertCode(t, nil, tt.code)
+			if got := strings.Join(updates, " "); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Processing time windows close on the server clock, without waiting for
+// another value.
+func TestStreamAggregateProcessingTime(t *testing.T) {
+	client := startCalculator(t)
+
+	stream, err := client.StreamAggregate(context.Background())
+	if err != nil {
+		t.Fatalf("StreamAggregate : %v", err)
+	}
+	cfg := &calculatorpb.AggregateConfig{
+		Aggregation: calculatorpb.Aggregation_AGGREGATION_SUM,
+		Window:      &calculatorpb.Window{Size: &calculatorpb.Window_Duration{Duration: durationpb.New(50 * time.Millisecond)}},
+	}
+	if err := stream.Send(configMessage(cfg)); err != nil {
+		t.Fatalf("Send : %v", err)
+	}
+	for _, v := range []float64{1, 2, 3} {
+		if err := stream.Send(valueMessage(v, time.Time{})); err != nil {
+			t.Fatalf("Send : %v", err)
+		}
+	}
+
+	// values may straddle two windows
+	sum := 0.0
+	deadline := time.Now().Add(5 * time.Second)
+	for sum < 6 && time.Now().Before(deadline) {
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv : %v", err)
+		}
+		if res.GetPartial() || res.GetEndTime().AsTime().Sub(res.GetStartTime().AsTime()) != 50*time.Millisecond {
+			t.Fatalf("got update %v", res)
+		}
+		sum += res.GetValue()
+	}
+	if sum != 6 {
+		t.Errorf("got windows summing to %v, want 6", sum)
+	}
+	stream.CloseSend()
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("Recv after CloseSend : %v, want io.EOF", err)
+	}
+}
+
+// The handler fails the stream on an invalid value while the client is still
+// sending; run with -race, no receive may outlive it.
+func TestStreamAggregateFailsWhileClientSends(t *testing.T) {
+	validator := requestRules()
+	limiter := ratelimit.New(ratelimit.Config{
+		StreamMessages: map[string]ratelimit.Limit{
+			// the config, ten values and the invalid one pass, the next fails
+			"/calculator.CalculatorService/StreamAggregate": {PerSecond: 1, Burst: 12},
+		},
+	})
+	conn := grpctest.Start(t, func(s *grpc.Server) {
+		calculatorpb.RegisterCalculatorServiceServer(s, &server{logger: zap.NewNop()})
+	},
+		grpc.ChainStreamInterceptor(limiter.StreamServerInterceptor(), validator.StreamServerInterceptor()),
+	)
+	client := calculatorpb.NewCalculatorServiceClient(conn)
+
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := client.StreamAggregate(ctx)
+		if err != nil {
+			t.Fatalf("StreamAggregate : %v", err)
+		}
+		cfg := &calculatorpb.AggregateConfig{
+			Window: &calculatorpb.Window{Size: &calculatorpb.Window_Duration{Duration: durationpb.New(time.Millisecond)}},
+		}
+		if err := stream.Send(configMessage(cfg)); err != nil {
+			t.Fatalf("Send : %v", err)
+		}
+
+		sent := make(chan struct{})
+		go func() {
+			defer close(sent)
+			for j := 0; ; j++ {
+				v := float64(j)
+				if j == 10 {
+					v = math.NaN()
+				}
+				if err := stream.Send(valueMessage(v, time.Time{})); err != nil {
+					return
+				}
+			}
+		}()
+
+		for err == nil {
+			_, err = stream.Recv()
+		}
+		grpctest.AssertCode(t, err, codes.InvalidArgument)
+		cancel()
+		<-sent
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/calculator/decimal"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/rpcerror"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+// defaultDivisionScale is the number of digits kept by divisions when the
+// request sets no scale.
+const defaultDivisionScale = 20
+
+// bigNumberPattern matches the numbers decimal.Parse accepts.
+const bigNumberPattern = `^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`
+
+var roundingModes = map[calculatorpb.RoundingMode]decimal.RoundingMode{
+	calculatorpb.RoundingMode_ROUND_HALF_EVEN: decimal.HalfEven,
+	calculatorpb.RoundingMode_ROUND_HALF_UP:   decimal.HalfUp,
+	calculatorpb.RoundingMode_ROUND_HALF_DOWN: decimal.HalfDown,
+	calculatorpb.RoundingMode_ROUND_UP:        decimal.Up,
+	calculatorpb.RoundingMode_ROUND_DOWN:      decimal.Down,
+	calculatorpb.RoundingMode_ROUND_CEILING:   decimal.Ceiling,
+	calculatorpb.RoundingMode_ROUND_FLOOR:     decimal.Floor,
+}
+
+func (s *server) BigArithmetic(ctx context.Context, req *calculatorpb.BigArithmeticRequest) (*calculatorpb.BigArithmeticResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("BigArithmetic request", zap.Stringer("request", req))
+
+	first, err := decimal.Parse(req.GetFirstNumber().GetValue())
+	if err != nil {
+		return nil, invalidBigNumber("first_number.value", err)
+	}
+	second, err := decimal.Parse(req.GetSecondNumber().GetValue())
+	if err != nil {
+		return nil, invalidBigNumber("second_number.value", err)
+	}
+	mode, ok := roundingModes[req.GetRounding()]
+	if !ok {
+		return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("unknown rounding mode : %v", req.GetRounding()),
+			rpcerror.BadRequest("rounding", "is not a known rounding mode"),
+		)
+	}
+	scale := defaultDivisionScale
+	if req.Scale != nil {
+		scale = int(req.GetScale())
+	}
+
+	var result *decimal.Decimal
+	switch req.GetOperation() {
+	case calculatorpb.BigOperation_BIG_ADD:
+		result, err = decimal.Add(first, second)
+	case calculatorpb.BigOperation_BIG_SUBTRACT:
+		result, err = decimal.Sub(first, second)
+	case calculatorpb.BigOperation_BIG_MULTIPLY:
+		result, err = decimal.Mul(first, second)
+	case calculatorpb.BigOperation_BIG_DIVIDE:
+		result, err = decimal.Quo(first, second, scale, mode)
+	case calculatorpb.BigOperation_BIG_POWER:
+		result, err = decimal.Pow(first, second, scale, mode)
+	case calculatorpb.BigOperation_BIG_MODULO:
+		result, err = decimal.Mod(first, second)
+	default:
+		return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("unknown operation : %v", req.GetOperation()),
+			rpcerror.BadRequest("operation", "is not a known operation"),
+		)
+	}
+	if err != nil {
+		return nil, bigArithmeticError(req.GetOperation(), err)
+	}
+
+	switch {
+	case req.Scale != nil:
+		result = result.Round(scale, mode)
+	case req.GetOperation() == calculatorpb.BigOperation_BIG_DIVIDE, req.GetOperation() == calculatorpb.BigOperation_BIG_POWER:
+		// quotients carry the default scale, drop its padding
+		result = result.Trim()
+	}
+
+	return &calculatorpb.BigArithmeticResponse{
+		Result: &calculatorpb.BigNumber{Value: result.String()},
+	}, nil
+}
+
+func invalidBigNumber(field string, err error) error {
+	return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid %s : %v", field, err),
+		rpcerror.ErrorInfo("INVALID_BIG_NUMBER", "field", field),
+		rpcerror.BadRequest(field, err.Error()),
+	)
+}
+
+func bigArithmeticError(op calculatorpb.BigOperation, err error) error {
+	switch {
+	case errors.Is(err, decimal.ErrDivisionByZero):
+		return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("%v : %v", op, err),
+			rpcerror.ErrorInfo("DIVISION_BY_ZERO", "operation", op.String()),
+			rpcerror.BadRequest("second_number.value", "must not be zero"),
+		)
+	case errors.Is(err, decimal.ErrNotInteger):
+		return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("%v : %v", op, err),
+			rpcerror.BadRequest("second_number.value", "must be an integer"),
+		)
+	case errors.Is(err, decimal.ErrTooLarge):
+		return rpcerror.New(codes.OutOfRange, fmt.Sprintf("%v : %v", op, err),
+			rpcerror.ErrorInfo("RESULT_TOO_LARGE", "operation", op.String(), "max_digits", fmt.Sprint(decimal.MaxDigits)),
+		)
+	}
+	return rpcerror.New(codes.Internal, fmt.Sprintf("%v : %v", op, err))
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/calculator/expr"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/rpcerror"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+// maxExpressionLength bounds the expressions accepted by Evaluate.
+const maxExpressionLength = 4096
+
+func (s *server) Evaluate(ctx context.Context, req *calculatorpb.EvaluateRequest) (*calculatorpb.EvaluateResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("Evaluate request", zap.Stringer("request", req))
+
+	result, err := expr.Eval(req.GetExpression(), req.GetVariables())
+	if err != nil {
+		return nil, expressionError(err, "variables")
+	}
+	return &calculatorpb.EvaluateResponse{Result: result}, nil
+}
+
+// expressionError turns parse and evaluation errors into InvalidArgument
+// statuses locating the mistake in the expression. Unbound variables are
+// reported against variablesField.
+func expressionError(err error, variablesField string) error {
+	var syntaxErr *expr.SyntaxError
+	var evalErr *expr.EvalError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return rpcerror.New(codes.InvalidArgument, err.Error(),
+			rpcerror.ErrorInfo("EXPRESSION_SYNTAX", "position", fmt.Sprint(syntaxErr.Pos)),
+			rpcerror.BadRequest("expression", err.Error()),
+		)
+	case errors.As(err, &evalErr) && evalErr.Variable != "":
+		return rpcerror.New(codes.InvalidArgument, err.Error(),
+			rpcerror.ErrorInfo("UNBOUND_VARIABLE", "position", fmt.Sprint(evalErr.Pos), "variable", evalErr.Variable),
+			rpcerror.BadRequest(variablesField, fmt.Sprintf("has no value for %q", evalErr.Variable)),
+		)
+	case errors.As(err, &evalErr):
+		return rpcerror.New(codes.InvalidArgument, err.Error(),
+			rpcerror.ErrorInfo("EXPRESSION_EVALUATION", "position", fmt.Sprint(evalErr.Pos)),
+			rpcerror.BadRequest("expression", err.Error()),
+		)
+	}
+	return rpcerror.New(codes.Internal, err.Error())
+}
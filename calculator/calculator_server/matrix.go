@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/calculator/matrix"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/rpcerror"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxMatrixDimension bounds the rows and columns of a matrix, so a product
+// or an inverse takes about a second at most.
+const maxMatrixDimension = 1000
+
+func invalidMatrix(field, description string) error {
+	return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid matrix : %s %s", field, description),
+		rpcerror.ErrorInfo("INVALID_MATRIX", "field", field),
+		rpcerror.BadRequest(field, description),
+	)
+}
+
+// operand is the shape of a Matrix or a MatrixShape, named after its field.
+type operand struct {
+	field      string
+	set        bool
+	rows, cols int
+}
+
+type shaped interface {
+	GetRows() int32
+	GetColumns() int32
+}
+
+func operandOf(field string, m shaped, set bool) operand {
+	return operand{field: field, set: set, rows: int(m.GetRows()), cols: int(m.GetColumns())}
+}
+
+func (o operand) String() string { return fmt.Sprintf("%dx%d", o.rows, o.cols) }
+
+// binaryOperation reports whether op takes a second matrix.
+func binaryOperation(op calculatorpb.MatrixOperation) bool {
+	switch op {
+	case calculatorpb.MatrixOperation_MATRIX_ADD, calculatorpb.MatrixOperation_MATRIX_MULTIPLY, calculatorpb.MatrixOperation_MATRIX_SOLVE:
+		return true
+	}
+	return false
+}
+
+// checkOperands validates the shapes of the operands of op, before any value
+// is read.
+func checkOperands(op calculatorpb.MatrixOperation, first, second operand) error {
+	if _, ok := calculatorpb.MatrixOperation_name[int32(op)]; !ok {
+		return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("unknown operation : %v", op),
+			rpcerror.BadRequest("operation", "is not a known operation"),
+		)
+	}
+	operands := []operand{first}
+	if binaryOperation(op) {
+		operands = append(operands, second)
+	} else if second.set {
+		return invalidMatrix(second.field, fmt.Sprintf("must not be set for %v", op))
+	}
+	for _, o := range operands {
+		if !o.set {
+			return invalidMatrix(o.field, fmt.Sprintf("is required for %v", op))
+		}
+		if o.rows < 1 || o.rows > maxMatrixDimension || o.cols < 1 || o.cols > maxMatrixDimension {
+			return invalidMatrix(o.field, fmt.Sprintf("must have between 1 and %d rows and columns, got %v", maxMatrixDimension, o))
+		}
+	}
+
+	mismatch := func(description string) error {
+		return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("%v : %v and %v matrices : %s %s", op, first, second, second.field, description),
+			rpcerror.ErrorInfo("DIMENSION_MISMATCH", "operation", op.String(), "first", first.String(), "second", second.String()),
+			rpcerror.BadRequest(second.field, description),
+		)
+	}
+	switch op {
+	case calculatorpb.MatrixOperation_MATRIX_ADD:
+		if first.rows != second.rows || first.cols != second.cols {
+			return mismatch(fmt.Sprintf("must be %v like the first matrix", first))
+		}
+	case calculatorpb.MatrixOperation_MATRIX_MULTIPLY:
+		if first.cols != second.rows {
+			return mismatch(fmt.Sprintf("must have %d rows, the columns of the first matrix", first.cols))
+		}
+	case calculatorpb.MatrixOperation_MATRIX_DETERMINANT, calculatorpb.MatrixOperation_MATRIX_INVERSE, calculatorpb.MatrixOperation_MATRIX_SOLVE:
+		if first.rows != first.cols {
+			return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("%v : %v matrix is not square", op, first),
+				rpcerror.ErrorInfo("NOT_SQUARE_MATRIX", "operation", op.String(), "first", first.String()),
+				rpcerror.BadRequest(first.field, "must be square"),
+			)
+		}
+		if op == calculatorpb.MatrixOperation_MATRIX_SOLVE && second.rows != first.rows {
+			return mismatch(fmt.Sprintf("must have %d rows, the rows of the first matrix", first.rows))
+		}
+	}
+	return nil
+}
+
+// checkValues rejects the values of a row or a matrix that are not finite.
+func checkValues(field string, values []float64) error {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return invalidMatrix(field, fmt.Sprintf("must only hold finite numbers, got %v", v))
+		}
+	}
+	return nil
+}
+
+// computeMatrix applies op to operands whose shapes checkOperands accepted,
+// the first read from firstField. Operations other than MATRIX_DETERMINANT
+// return a matrix.
+func computeMatrix(op calculatorpb.MatrixOperation, firstField string, first, second *matrix.Matrix) (*matrix.Matrix, float64, error) {
+	var result *matrix.Matrix
+	var err error
+	switch op {
+	case calculatorpb.MatrixOperation_MATRIX_ADD:
+		result, err = matrix.Add(first, second)
+	case calculatorpb.MatrixOperation_MATRIX_MULTIPLY:
+		result, err = matrix.Mul(first, second)
+	case calculatorpb.MatrixOperation_MATRIX_TRANSPOSE:
+		result = matrix.Transpose(first)
+	case calculatorpb.MatrixOperation_MATRIX_DETERMINANT:
+		det, err := matrix.Det(first)
+		if err != nil {
+			return nil, 0, rpcerror.New(codes.Internal, fmt.Sprintf("%v : %v", op, err))
+		}
+		return nil, det, nil
+	case calculatorpb.MatrixOperation_MATRIX_INVERSE:
+		result, err = matrix.Inverse(first)
+	case calculatorpb.MatrixOperation_MATRIX_SOLVE:
+		result, err = matrix.Solve(first, second)
+	}
+	if errors.Is(err, matrix.ErrSingular) {
+		return nil, 0, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("%v : %v", op, err),
+			rpcerror.ErrorInfo("SINGULAR_MATRIX", "operation", op.String()),
+			rpcerror.BadRequest(firstField, "must be invertible"),
+		)
+	}
+	if err != nil {
+		return nil, 0, rpcerror.New(codes.Internal, fmt.Sprintf("%v : %v", op, err))
+	}
+	return result, 0, nil
+}
+
+func (s *server) ComputeMatrix(ctx context.Context, req *calculatorpb.MatrixRequest) (*calculatorpb.MatrixResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("ComputeMatrix request",
+		zap.Stringer("operation", req.GetOperation()),
+		zap.Int32("rows", req.GetFirst().GetRows()),
+		zap.Int32("columns", req.GetFirst().GetColumns()),
+	)
+
+	op := req.GetOperation()
+	if err := checkOperands(op,
+		operandOf("first", req.GetFirst(), req.First != nil),
+		operandOf("second", req.GetSecond(), req.Second != nil),
+	); err != nil {
+		return nil, err
+	}
+	first, err := toMatrix("first", req.GetFirst())
+	if err != nil {
+		return nil, err
+	}
+	var second *matrix.Matrix
+	if binaryOperation(op) {
+		if second, err = toMatrix("second", req.GetSecond()); err != nil {
+			return nil, err
+		}
+	}
+
+	result, det, err := computeMatrix(op, "first", first, second)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return &calculatorpb.MatrixResponse{Result: &calculatorpb.MatrixResponse_Determinant{Determinant: det}}, nil
+	}
+	return &calculatorpb.MatrixResponse{Result: &calculatorpb.MatrixResponse_Matrix{Matrix: &calculatorpb.Matrix{
+		Rows:    int32(result.Rows()),
+		Columns: int32(result.Cols()),
+		Values:  result.Data(),
+	}}}, nil
+}
+
+func toMatrix(field string, m *calculatorpb.Matrix) (*matrix.Matrix, error) {
+	if err := checkValues(field+".values", m.GetValues()); err != nil {
+		return nil, err
+	}
+	if want := int(m.GetRows()) * int(m.GetColumns()); len(m.GetValues()) != want {
+		return nil, invalidMatrix(field+".values", fmt.Sprintf("must hold %d values for a %dx%d matrix, got %d",
+			want, m.GetRows(), m.GetColumns(), len(m.GetValues())))
+	}
+	return matrix.New(int(m.GetRows()), int(m.GetColumns()), m.GetValues())
+}
+
+func (s *server) StreamMatrix(stream calculatorpb.CalculatorService_StreamMatrixServer) error {
+
+	logger := logging.ForRequest(stream.Context(), s.logger)
+
+	recv := func() (*calculatorpb.StreamMatrixRequest, error) {
+		req, err := stream.Recv()
+		if err != nil && err != io.EOF {
+			logger.Warn("error trying to get client data streams", zap.Error(err))
+			return nil, status.Errorf(status.Code(err), "error trying to get client data streams : %v", err)
+		}
+		return req, err
+	}
+
+	req, err := recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	header := req.GetHeader()
+	if header == nil {
+		return invalidMatrix("header", "must be the first message of the stream")
+	}
+	logger.Info("StreamMatrix request", zap.Stringer("header", header))
+
+	op := header.GetOperation()
+	shapes := []operand{
+		operandOf("header.first", header.GetFirst(), header.First != nil),
+		operandOf("header.second", header.GetSecond(), header.Second != nil),
+	}
+	if err := checkOperands(op, shapes[0], shapes[1]); err != nil {
+		return err
+	}
+	if !binaryOperation(op) {
+		shapes = shapes[:1]
+	}
+
+	// the rows of the first matrix, then those of the second
+	operands := make([]*matrix.Matrix, 2)
+	for i, shape := range shapes {
+		operands[i] = matrix.Zeros(shape.rows, shape.cols)
+		for r := 0; r < shape.rows; r++ {
+			req, err := recv()
+			if err == io.EOF {
+				return invalidMatrix("row", fmt.Sprintf("must be sent %d times for the %v matrix of %s, got %d", shape.rows, shape, shape.field, r))
+			}
+			if err != nil {
+				return err
+			}
+			if req.GetHeader() != nil {
+				return invalidMatrix("header", "must only be sent as the first message")
+			}
+			values := req.GetRow().GetValues()
+			if len(values) != shape.cols {
+				return invalidMatrix("row.values", fmt.Sprintf("must hold %d values in the rows of %s, got %d in row %d", shape.cols, shape.field, len(values), r))
+			}
+			if err := checkValues("row.values", values); err != nil {
+				return err
+			}
+			copy(operands[i].Row(r), values)
+		}
+	}
+
+	result, det, err := computeMatrix(op, shapes[0].field, operands[0], operands[1])
+	if err != nil {
+		return err
+	}
+
+	send := func(res *calculatorpb.StreamMatrixResponse) error {
+		if err := stream.Send(res); err != nil {
+			logger.Warn("error trying to send server data stream", zap.Error(err))
+			return status.Errorf(status.Code(err), "error trying to send server data stream : %v", err)
+		}
+		return nil
+	}
+	if result == nil {
+		return send(&calculatorpb.StreamMatrixResponse{Message: &calculatorpb.StreamMatrixResponse_Determinant{Determinant: det}})
+	}
+	if err := send(&calculatorpb.StreamMatrixResponse{Message: &calculatorpb.StreamMatrixResponse_Shape{Shape: &calculatorpb.MatrixShape{
+		Rows:    int32(result.Rows()),
+		Columns: int32(result.Cols()),
+	}}}); err != nil {
+		return err
+	}
+	for r := 0; r < result.Rows(); r++ {
+		if err := send(&calculatorpb.StreamMatrixResponse{Message: &calculatorpb.StreamMatrixResponse_Row{Row: &calculatorpb.MatrixRow{
+			Values: result.Row(r),
+		}}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/grpc-go-new-course/rpcerror"
+	"google.golang.org/grpc/codes"
+)
+
+func newMatrix(rows, columns int32, values ...float64) *calculatorpb.Matrix {
+	return &calculatorpb.Matrix{Rows: rows, Columns: columns, Values: values}
+}
+
+func TestComputeMatrix(t *testing.T) {
+	client := startCalculator(t)
+
+	square := newMatrix(2, 2, 4, 7, 2, 6)
+	tests := []struct {
+		name          string
+		operation     calculatorpb.MatrixOperation
+		first, second *calculatorpb.Matrix
+		want          *calculatorpb.Matrix
+		determinant   float64
+		code          codes.Code
+		reason        string
+	}{
+		{
+			name:  "add",
+			first: newMatrix(1, 3, 1, 2, 3), second: newMatrix(1, 3, 10, 20, 30),
+			want: newMatrix(1, 3, 11, 22, 33),
+		},
+		{
+			name: "multiply", operation: calculatorpb.MatrixOperation_MATRIX_MULTIPLY,
+			first: newMatrix(2, 3, 1, 2, 3, 4, 5, 6), second: newMatrix(3, 1, 1, 0, -1),
+			want: newMatrix(2, 1, -2, -2),
+		},
+		{
+			name: "transpose", operation: calculatorpb.MatrixOperation_MATRIX_TRANSPOSE,
+			first: newMatrix(2, 3, 1, 2, 3, 4, 5, 6),
+			want:  newMatrix(3, 2, 1, 4, 2, 5, 3, 6),
+		},
+		{
+			name: "determinant", operation: calculatorpb.MatrixOperation_MATRIX_DETERMINANT,
+			first: square, determinant: 10,
+		},
+		{
+			name: "inverse", operation: calculatorpb.MatrixOperation_MATRIX_INVERSE,
+			first: square,
+			want:  newMatrix(2, 2, 0.6, -0.7, -0.2, 0.4),
+		},
+		{
+			name: "solve", operation: calculatorpb.MatrixOperation_MATRIX_SOLVE,
+			first: square, second: newMatrix(2, 1, 18, 14),
+			want: newMatrix(2, 1, 1, 2),
+		},
+		{
+			name:  "add mismatch",
+			first: newMatrix(1, 3, 1, 2, 3), second: newMatrix(3, 1, 1, 2, 3),
+			code: codes.InvalidArgument, reason: "DIMENSION_MISMATCH",
+		},
+		{
+			name: "multiply mismatch", operation: calculatorpb.MatrixOperation_MATRIX_MULTIPLY,
+			first: newMatrix(1, 3, 1, 2, 3), second: newMatrix(1, 3, 1, 2, 3),
+			code: codes.InvalidArgument, reason: "DIMENSION_MISMATCH",
+		},
+		{
+			name: "determinant of non square", operation: calculatorpb.MatrixOperation_MATRIX_DETERMINANT,
+			first: newMatrix(1, 2, 1, 2),
+			code:  codes.InvalidArgument, reason: "NOT_SQUARE_MATRIX",
+		},
+		{
+			name: "singular", operation: calculatorpb.MatrixOperation_MATRIX_INVERSE,
+			first: newMatrix(2, 2, 1, 2, 2, 4),
+			code:  codes.InvalidArgument, reason: "SINGULAR_MATRIX",
+		},
+		{
+			name:  "missing values",
+			first: newMatrix(2, 2, 1, 2, 3), second: newMatrix(2, 2, 1, 2, 3, 4),
+			code: codes.InvalidArgument, reason: "INVALID_MATRIX",
+		},
+		{
+			name:  "missing second",
+			first: newMatrix(1, 1, 1),
+			code:  codes.InvalidArgument, reason: "INVALID_MATRIX",
+		},
+		{
+			name: "infinite value", operation: calculatorpb.MatrixOperation_MATRIX_TRANSPOSE,
+			first: newMatrix(1, 1, math.Inf(1)),
+			code:  codes.InvalidArgument, reason: "INVALID_MATRIX",
+		},
+		{
+			name:  "too large",
+			first: newMatrix(1, maxMatrixDimension+1), second: newMatrix(1, maxMatrixDimension+1),
+			code: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.ComputeMatrix(context.Background(), &calculatorpb.MatrixRequest{
+				Operation: tt.operation,
+				First:     tt.first,
+				Second:    tt.second,
+			})
+			if !grpctest.AssertCode(t, err, tt.code) {
+				return
+			}
+			if err != nil {
+				if reason := rpcerror.FromError(err).ErrorInfo.GetReason(); tt.reason != "" && reason != tt.reason {
+					t.Errorf("got reason %s, want %s", reason, tt.reason)
+				}
+				return
+			}
+			if tt.want == nil {
+				if got := res.GetDeterminant(); math.Abs(got-tt.determinant) > 1e-12 {
+					t.Errorf("got determinant %v, want %v", got, tt.determinant)
+				}
+				return
+			}
+			got := res.GetMatrix()
+			if got.GetRows() != tt.want.GetRows() || got.GetColumns() != tt.want.GetColumns() {
+				t.Fatalf("got %dx%d matrix, want %dx%d", got.GetRows(), got.GetColumns(), tt.want.GetRows(), tt.want.GetColumns())
+			}
+			for i, v := range got.GetValues() {
+				if math.Abs(v-tt.want.GetValues()[i]) > 1e-12 {
+					t.Fatalf("got %v, want %v", got.GetValues(), tt.want.GetValues())
+				}
+			}
+		})
+	}
+}
+
+func TestStreamMatrix(t *testing.T) {
+	client := startCalculator(t)
+
+	header := func(op calculatorpb.MatrixOperation, first, second *calculatorpb.MatrixShape) *calculatorpb.StreamMatrixRequest {
+		return &calculatorpb.StreamMatrixRequest{Message: &calculatorpb.StreamMatrixRequest_Header{
+			Header: &calculatorpb.MatrixHeader{Operation: op, First: first, Second: second},
+		}}
+	}
+	row := func(values ...float64) *calculatorpb.StreamMatrixRequest {
+		return &calculatorpb.StreamMatrixRequest{Message: &calculatorpb.StreamMatrixRequest_Row{
+			Row: &calculatorpb.MatrixRow{Values: values},
+		}}
+	}
+	shape := func(rows, columns int32) *calculatorpb.MatrixShape {
+		return &calculatorpb.MatrixShape{Rows: rows, Columns: columns}
+	}
+
+	tests := []struct {
+		name     string
+		requests []*calculatorpb.StreamMatrixRequest
+		want     [][]float64
+		code     codes.Code
+	}{
+		{
+			name: "multiply",
+			requests: []*calculatorpb.StreamMatrixRequest{
+				header(calculatorpb.MatrixOperation_MATRIX_MULTIPLY, shape(2, 2), shape(2, 3)),
+				row(1, 2), row(3, 4),
+				row(1, 0, 1), row(0, 1, 1),
+			},
+			want: [][]float64{{2, 3}, {1, 2, 3}, {3, 4, 7}},
+		},
+		{
+			name: "determinant",
+			requests: []*calculatorpb.StreamMatrixRequest{
+				header(calculatorpb.MatrixOperation_MATRIX_DETERMINANT, shape(2, 2), nil),
+				row(4, 7), row(2, 6),
+			},
+			want: [][]float64{{10}},
+		},
+		{
+			name: "header mismatch",
+			requests: []*calculatorpb.StreamMatrixRequest{
+				header(calculatorpb.MatrixOperation_MATRIX_ADD, shape(2, 2), shape(2, 3)),
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name:     "row first",
+			requests: []*calculatorpb.StreamMatrixRequest{row(1, 2)},
+			code:     codes.InvalidArgument,
+		},
+		{
+			name: "short row",
+			requests: []*calculatorpb.StreamMatrixRequest{
+				header(calculatorpb.MatrixOperation_MATRIX_TRANSPOSE, shape(2, 2), nil),
+				row(1, 2), row(3),
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "missing rows",
+			requests: []*calculatorpb.StreamMatrixRequest{
+				header(calculatorpb.MatrixOperation_MATRIX_SOLVE, shape(2, 2), shape(2, 1)),
+				row(1, 2), row(3, 4), row(5),
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.StreamMatrix(context.Background())
+			if err != nil {
+				t.Fatalf("StreamMatrix : %v", err)
+			}
+			for _, req := range tt.requests {
+				// the server may already have failed the stream
+				if err := stream.Send(req); err != nil {
+					break
+				}
+			}
+			stream.CloseSend()
+
+			// the determinant, or the shape then the rows
+			var got [][]float64
+			for {
+				res, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					grpctest.AssertCode(t, err, tt.code)
+					return
+				}
+				switch m := res.GetMessage().(type) {
+				case *calculatorpb.StreamMatrixResponse_Determinant:
+					got = append(got, []float64{m.Determinant})
+				case *calculatorpb.StreamMatrixResponse_Shape:
+					got = append(got, []float64{float64(m.Shape.GetRows()), float64(m.Shape.GetColumns())})
+				case *calculatorpb.StreamMatrixResponse_Row:
+					got = append(got, m.Row.GetValues())
+				}
+			}
+			grpctest.AssertCode(t, nil, tt.code)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
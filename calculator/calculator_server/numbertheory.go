@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/calculator/primes"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/rpcerror"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxPrimesRangeSpan bounds the numbers covered by one PrimesInRange
+	maxPrimesRangeSpan = 1000000000
+	// primesBatchSize is the number of primes sent per PrimesInRange message
+	primesBatchSize = 1000
+)
+
+// factorContext bounds ctx by the time budget of a factorization or of a
+// PrimesInRange sieve.
+func (s *server) factorContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.factorBudget > 0 {
+		return context.WithTimeout(ctx, s.factorBudget)
+	}
+	return context.WithCancel(ctx)
+}
+
+// factorError reports work, such as "factorization of 42", stopped by its
+// budget or by the client.
+func (s *server) factorError(ctx context.Context, work string, err error) error {
+	switch {
+	case ctx.Err() != nil:
+		// the client gave up, either by cancelling or through its deadline
+		return status.FromContextError(ctx.Err()).Err()
+	case errors.Is(err, context.DeadlineExceeded):
+		return rpcerror.New(codes.DeadlineExceeded, fmt.Sprintf("%s exceeded the time budget of %v", work, s.factorBudget),
+			rpcerror.ErrorInfo("FACTORIZATION_TIME_BUDGET", "budget", s.factorBudget.String()),
+		)
+	}
+	return err
+}
+
+func (s *server) IsPrime(ctx context.Context, req *calculatorpb.IsPrimeRequest) (*calculatorpb.IsPrimeResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("IsPrime request", zap.Stringer("request", req))
+
+	number := req.GetNumber()
+	if number < 2 {
+		return &calculatorpb.IsPrimeResponse{}, nil
+	}
+	if primes.IsPrime(uint64(number)) {
+		return &calculatorpb.IsPrimeResponse{IsPrime: true}, nil
+	}
+
+	factorCtx, cancel := s.factorContext(ctx)
+	defer cancel()
+	factors, err := primes.Factors(factorCtx, uint64(number))
+	if err != nil {
+		return nil, s.factorError(ctx, fmt.Sprintf("factorization of %v", number), err)
+	}
+	return &calculatorpb.IsPrimeResponse{SmallestFactor: int64(factors[0].Prime)}, nil
+}
+
+// absolutes returns the absolute values of numbers, rejecting math.MinInt64
+// which has none as an int64.
+func absolutes(numbers []int64) ([]uint64, error) {
+	abs := make([]uint64, len(numbers))
+	for i, n := range numbers {
+		if n == math.MinInt64 {
+			return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("numbers[%d] is out of range : %v", i, n),
+				rpcerror.BadRequest(fmt.Sprintf("numbers[%d]", i), "must be greater than -9223372036854775808"),
+			)
+		}
+		if n < 0 {
+			n = -n
+		}
+		abs[i] = uint64(n)
+	}
+	return abs, nil
+}
+
+func (s *server) GCD(ctx context.Context, req *calculatorpb.GCDRequest) (*calculatorpb.GCDResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("GCD request", zap.Stringer("request", req))
+
+	numbers, err := absolutes(req.GetNumbers())
+	if err != nil {
+		return nil, err
+	}
+	gcd := uint64(0)
+	for _, n := range numbers {
+		gcd = primes.GCD(gcd, n)
+	}
+	return &calculatorpb.GCDResponse{Gcd: int64(gcd)}, nil
+}
+
+func (s *server) LCM(ctx context.Context, req *calculatorpb.LCMRequest) (*calculatorpb.LCMResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("LCM request", zap.Stringer("request", req))
+
+	numbers, err := absolutes(req.GetNumbers())
+	if err != nil {
+		return nil, err
+	}
+	lcm := uint64(1)
+	for _, n := range numbers {
+		var ok bool
+		if lcm, ok = primes.LCM(lcm, n); !ok || lcm > math.MaxInt64 {
+			return nil, rpcerror.New(codes.OutOfRange, "least common multiple overflows int64",
+				rpcerror.ErrorInfo("LCM_OVERFLOW"),
+			)
+		}
+	}
+	return &calculatorpb.LCMResponse{Lcm: int64(lcm)}, nil
+}
+
+// modulo returns n mod m in [0, m).
+func modulo(n, m int64) uint64 {
+	r := n % m
+	if r < 0 {
+		r += m
+	}
+	return uint64(r)
+}
+
+func noInverse(number, modulus int64) error {
+	return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("%v has no inverse modulo %v", number, modulus),
+		rpcerror.ErrorInfo("NO_MODULAR_INVERSE", "number", fmt.Sprint(number), "modulus", fmt.Sprint(modulus)),
+		rpcerror.BadRequest("number", "must be coprime with modulus"),
+	)
+}
+
+func (s *server) ModPow(ctx context.Context, req *calculatorpb.ModPowRequest) (*calculatorpb.ModPowResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("ModPow request", zap.Stringer("request", req))
+
+	m := req.GetModulus()
+	if m < 1 {
+		return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("Received non-positive modulus : %v", m),
+			rpcerror.BadRequest("modulus", "must be positive"),
+		)
+	}
+	base := modulo(req.GetBase(), m)
+	exponent := req.GetExponent()
+	if exponent < 0 {
+		inverse, ok := primes.ModInverse(base, uint64(m))
+		if !ok {
+			return nil, noInverse(req.GetBase(), m)
+		}
+		// -(exponent+1)+1 cannot overflow, even for math.MinInt64
+		return &calculatorpb.ModPowResponse{Result: int64(primes.ModPow(inverse, uint64(-(exponent+1))+1, uint64(m)))}, nil
+	}
+	return &calculatorpb.ModPowResponse{Result: int64(primes.ModPow(base, uint64(exponent), uint64(m)))}, nil
+}
+
+func (s *server) ModInverse(ctx context.Context, req *calculatorpb.ModInverseRequest) (*calculatorpb.ModInverseResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("ModInverse request", zap.Stringer("request", req))
+
+	m := req.GetModulus()
+	if m < 1 {
+		return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("Received non-positive modulus : %v", m),
+			rpcerror.BadRequest("modulus", "must be positive"),
+		)
+	}
+	inverse, ok := primes.ModInverse(modulo(req.GetNumber(), m), uint64(m))
+	if !ok {
+		return nil, noInverse(req.GetNumber(), m)
+	}
+	return &calculatorpb.ModInverseResponse{Inverse: int64(inverse)}, nil
+}
+
+func (s *server) PrimesInRange(req *calculatorpb.PrimesInRangeRequest, stream calculatorpb.CalculatorService_PrimesInRangeServer) error {
+
+	logger := logging.ForRequest(stream.Context(), s.logger)
+	logger.Info("PrimesInRange request", zap.Stringer("request", req))
+
+	start, end := req.GetStart(), req.GetEnd()
+	switch {
+	case start < 0:
+		return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("Received negative start : %v", start),
+			rpcerror.BadRequest("start", "must not be negative"),
+		)
+	case end < start:
+		return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("range end %v is below its start %v", end, start),
+			rpcerror.BadRequest("end", "must not be less than start"),
+		)
+	case end-start >= maxPrimesRangeSpan:
+		return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("range of %v numbers is too large", end-start+1),
+			rpcerror.BadRequest("end", fmt.Sprintf("must be less than start + %d", maxPrimesRangeSpan)),
+		)
+	}
+
+	send := func(batch []int64) error {
+		if err := stream.Send(&calculatorpb.PrimesInRangeResponse{Primes: batch}); err != nil {
+			logger.Warn("error sending primes", zap.Error(err))
+			return status.Errorf(status.Code(err), "error sending primes : %v", err)
+		}
+		return nil
+	}
+	// a range of a billion numbers near math.MaxInt64 takes as long to sieve
+	// as the hardest factorization
+	ctx, cancel := s.factorContext(stream.Context())
+	defer cancel()
+
+	batch := make([]int64, 0, primesBatchSize)
+	err := primes.PrimesInRange(ctx, uint64(start), uint64(end), func(p uint64) error {
+		batch = append(batch, int64(p))
+		if len(batch) < primesBatchSize {
+			return nil
+		}
+		err := send(batch)
+		batch = make([]int64, 0, primesBatchSize)
+		return err
+	})
+	if err != nil {
+		return s.factorError(stream.Context(), fmt.Sprintf("sieving %v to %v", start, end), err)
+	}
+	if len(batch) > 0 {
+		return send(batch)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/grpc-go-new-course/rpcerror"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+func TestIsPrime(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		number   int64
+		want     bool
+		smallest int64
+	}{
+		{number: 97, want: true},
+		{number: 9223372036854775783, want: true},
+		{number: 91, smallest: 7},
+		{number: 2147483647 * 2147483629, smallest: 2147483629},
+		{number: 1},
+		{number: -7},
+	}
+	for _, tt := range tests {
+		res, err := client.IsPrime(context.Background(), &calculatorpb.IsPrimeRequest{Number: tt.number})
+		if err != nil {
+			t.Fatalf("IsPrime(%d) : %v", tt.number, err)
+		}
+		if res.GetIsPrime() != tt.want || res.GetSmallestFactor() != tt.smallest {
+			t.Errorf("IsPrime(%d) = %v, %d, want %v, %d", tt.number, res.GetIsPrime(), res.GetSmallestFactor(), tt.want, tt.smallest)
+		}
+	}
+}
+
+func TestGCDAndLCM(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		name     string
+		numbers  []int64
+		gcd, lcm int64
+		code     codes.Code
+		lcmCode  codes.Code
+	}{
+		{name: "several", numbers: []int64{12, 18, -30}, gcd: 6, lcm: 180},
+		{name: "single", numbers: []int64{-7}, gcd: 7, lcm: 7},
+		{name: "with zero", numbers: []int64{0, 5}, gcd: 5, lcm: 0},
+		{name: "lcm overflow", numbers: []int64{9223372036854775783, 1000000007}, gcd: 1, lcmCode: codes.OutOfRange},
+		{name: "min int64", numbers: []int64{math.MinInt64}, code: codes.InvalidArgument, lcmCode: codes.InvalidArgument},
+		{name: "empty", code: codes.InvalidArgument, lcmCode: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gcd, err := client.GCD(context.Background(), &calculatorpb.GCDRequest{Numbers: tt.numbers})
+			if grpctest.AssertCode(t, err, tt.code) && err == nil && gcd.GetGcd() != tt.gcd {
+				t.Errorf("got GCD %d, want %d", gcd.GetGcd(), tt.gcd)
+			}
+			lcm, err := client.LCM(context.Background(), &calculatorpb.LCMRequest{Numbers: tt.numbers})
+			if grpctest.AssertCode(t, err, tt.lcmCode) && err == nil && lcm.GetLcm() != tt.lcm {
+				t.Errorf("got LCM %d, want %d", lcm.GetLcm(), tt.lcm)
+			}
+		})
+	}
+}
+
+func TestModPow(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		name                    string
+		base, exponent, modulus int64
+		want                    int64
+		code                    codes.Code
+	}{
+		{name: "positive", base: 4, exponent: 13, modulus: 497, want: 445},
+		{name: "negative base", base: -2, exponent: 3, modulus: 5, want: 2},
+		{name: "negative exponent", base: 3, exponent: -1, modulus: 11, want: 4},
+		{name: "min int64 exponent", base: 1, exponent: math.MinInt64, modulus: 7, want: 1},
+		{name: "no inverse", base: 6, exponent: -1, modulus: 9, code: codes.InvalidArgument},
+		{name: "zero modulus", base: 2, exponent: 2, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.ModPow(context.Background(), &calculatorpb.ModPowRequest{Base: tt.base, Exponent: tt.exponent, Modulus: tt.modulus})
+			if grpctest.AssertCode(t, err, tt.code) && err == nil && res.GetResult() != tt.want {
+				t.Errorf("got %d, want %d", res.GetResult(), tt.want)
+			}
+		})
+	}
+}
+
+func TestModInverse(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		name            string
+		number, modulus int64
+		want            int64
+		code            codes.Code
+	}{
+		{name: "invertible", number: 10, modulus: 17, want: 12},
+		{name: "negative", number: -3, modulus: 11, want: 7},
+		{name: "not coprime", number: 6, modulus: 9, code: codes.InvalidArgument},
+		{name: "negative modulus", number: 3, modulus: -11, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.ModInverse(context.Background(), &calculatorpb.ModInverseRequest{Number: tt.number, Modulus: tt.modulus})
+			if grpctest.AssertCode(t, err, tt.code) && err == nil && res.GetInverse() != tt.want {
+				t.Errorf("got %d, want %d", res.GetInverse(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrimesInRange(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		name       string
+		start, end int64
+		count      int
+		first      int64
+		last       int64
+		code       codes.Code
+	}{
+		{name: "small", start: 0, end: 30, count: 10, first: 2, last: 29},
+		// several batches
+		{name: "below a million", start: 0, end: 1000000, count: 78498, first: 2, last: 999983},
+		{name: "large", start: math.MaxInt64 - 1000, end: math.MaxInt64, count: 23, last: 9223372036854775783},
+		{name: "empty", start: 24, end: 28},
+		{name: "reversed", start: 30, end: 20, code: codes.InvalidArgument},
+		{name: "negative", start: -10, end: 20, code: codes.InvalidArgument},
+		{name: "too large", start: 0, end: maxPrimesRangeSpan, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.PrimesInRange(context.Background(), &calculatorpb.PrimesInRangeRequest{Start: tt.start, End: tt.end})
+			if err != nil {
+				t.Fatalf("PrimesInRange : %v", err)
+			}
+			var got []int64
+			for {
+				res, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					grpctest.AssertCode(t, err, tt.code)
+					return
+				}
+				if len(res.GetPrimes()) > primesBatchSize {
+					t.Fatalf("got a batch of %d primes", len(res.GetPrimes()))
+				}
+				got = append(got, res.GetPrimes()...)
+			}
+			grpctest.AssertCode(t, nil, tt.code)
+			if len(got) != tt.count {
+				t.Fatalf("got %d primes, want %d", len(got), tt.count)
+			}
+			if tt.count > 0 && (tt.first != 0 && got[0] != tt.first || got[len(got)-1] != tt.last) {
+				t.Errorf("got primes from %d to %d, want %d to %d", got[0], got[len(got)-1], tt.first, tt.last)
+			}
+		})
+	}
+}
+
+func TestPrimesInRangeTimeBudget(t *testing.T) {
+	client := startCalculatorServer(t, &server{logger: zap.NewNop(), factorBudget: time.Nanosecond})
+
+	stream, err := client.PrimesInRange(context.Background(), &calculatorpb.PrimesInRangeRequest{Start: math.MaxInt64 - maxPrimesRangeSpan + 1, End: math.MaxInt64})
+	if err != nil {
+		t.Fatalf("PrimesInRange : %v", err)
+	}
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	if grpctest.AssertCode(t, err, codes.DeadlineExceeded) {
+		if reason := rpcerror.FromError(err).ErrorInfo.GetReason(); reason != "FACTORIZATION_TIME_BUDGET" {
+			t.Errorf("got reason %q, want FACTORIZATION_TIME_BUDGET", reason)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/calculator/decimal"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/rpcerror"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// defaultRootScale is the number of digits kept by the roots of
+	// decimals when the request sets no scale.
+	defaultRootScale = 20
+	maxRootDegree    = 100
+)
+
+func (s *server) Root(ctx context.Context, req *calculatorpb.RootRequest) (*calculatorpb.RootResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("Root request", zap.Stringer("request", req))
+
+	degree := int(req.GetDegree())
+	if degree == 0 {
+		degree = 2
+	}
+
+	switch radicand := req.GetRadicand().(type) {
+	case *calculatorpb.RootRequest_Number:
+		if req.Scale != nil {
+			return nil, rpcerror.New(codes.InvalidArgument, "scale set for the root of a number",
+				rpcerror.BadRequest("scale", "only applies to the roots of decimals"),
+			)
+		}
+		return numberRoot(radicand.Number, degree, req.GetComplex())
+
+	case *calculatorpb.RootRequest_Decimal:
+		x, err := decimal.Parse(radicand.Decimal.GetValue())
+		if err != nil {
+			return nil, invalidBigNumber("decimal.value", err)
+		}
+		mode, ok := roundingModes[req.GetRounding()]
+		if !ok {
+			return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("unknown rounding mode : %v", req.GetRounding()),
+				rpcerror.BadRequest("rounding", "is not a known rounding mode"),
+			)
+		}
+		scale := defaultRootScale
+		if req.Scale != nil {
+			scale = int(req.GetScale())
+		}
+		// without a scale, drop the padding of exact roots like √4
+		trim := func(d *decimal.Decimal) *calculatorpb.BigNumber {
+			if req.Scale == nil {
+				d = d.Trim()
+			}
+			return &calculatorpb.BigNumber{Value: d.String()}
+		}
+
+		if x.Sign() < 0 && degree%2 == 0 {
+			if !req.GetComplex() {
+				return nil, negativeEvenRoot("decimal.value", degree)
+			}
+			re, im, err := decimal.ComplexRoot(x, degree, scale, mode)
+			if err != nil {
+				return nil, rpcerror.New(codes.Internal, fmt.Sprintf("root of degree %d : %v", degree, err))
+			}
+			return &calculatorpb.RootResponse{RealDecimal: trim(re), ImaginaryDecimal: trim(im)}, nil
+		}
+		root, err := decimal.Root(x, degree, scale, mode)
+		if err != nil {
+			return nil, rpcerror.New(codes.Internal, fmt.Sprintf("root of degree %d : %v", degree, err))
+		}
+		return &calculatorpb.RootResponse{RealDecimal: trim(root)}, nil
+	}
+
+	return nil, rpcerror.New(codes.InvalidArgument, "no radicand",
+		rpcerror.BadRequest("number", "or decimal is required"),
+	)
+}
+
+func numberRoot(x float64, degree int, complex bool) (*calculatorpb.RootResponse, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid number : %v", x),
+			rpcerror.BadRequest("number", "must be finite"),
+		)
+	}
+
+	var r float64
+	switch abs := math.Abs(x); degree {
+	case 2:
+		r = math.Sqrt(abs)
+	case 3:
+		r = math.Cbrt(abs)
+	default:
+		r = math.Pow(abs, 1/float64(degree))
+	}
+	switch {
+	case x >= 0:
+		return &calculatorpb.RootResponse{Real: r}, nil
+	case degree%2 == 1:
+		return &calculatorpb.RootResponse{Real: -r}, nil
+	case !complex:
+		return nil, negativeEvenRoot("number", degree)
+	case degree == 2:
+		// cos(π/2) is not quite zero in floating point
+		return &calculatorpb.RootResponse{Imaginary: r}, nil
+	}
+	angle := math.Pi / float64(degree)
+	return &calculatorpb.RootResponse{Real: r * math.Cos(angle), Imaginary: r * math.Sin(angle)}, nil
+}
+
+func negativeEvenRoot(field string, degree int) error {
+	return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("Received negative number : no real root of degree %d", degree),
+		rpcerror.ErrorInfo("NEGATIVE_EVEN_ROOT", "degree", fmt.Sprint(degree)),
+		rpcerror.BadRequest(field, "must not be negative for a root of even degree, unless complex is set"),
+	)
+}
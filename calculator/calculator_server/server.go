@@ -2,13 +2,32 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
-	"log"
 	"math"
-	"net"
+	"os"
+	"os/signal"
+	"time"
 
+	"github.com/grpc-go-new-course/apikey"
+	"github.com/grpc-go-new-course/apikey/apikeypb"
 	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/calculator/decimal"
+	"github.com/grpc-go-new-course/calculator/primes"
+	"github.com/grpc-go-new-course/calculator/stats"
+	"github.com/grpc-go-new-course/calculator/units"
+	"github.com/grpc-go-new-course/calculator/units/unitspb"
+	"github.com/grpc-go-new-course/interceptors"
+	"github.com/grpc-go-new-course/listener"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/metrics"
+	"github.com/grpc-go-new-course/ratelimit"
+	"github.com/grpc-go-new-course/rpcerror"
+	"github.com/grpc-go-new-course/tlsconfig"
+	"github.com/grpc-go-new-course/tracing"
+	"github.com/grpc-go-new-course/validation"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/reflection"
@@ -17,11 +36,17 @@ import (
 
 type server struct {
 	calculatorpb.UnimplementedCalculatorServiceServer
+	logger   *zap.Logger
+	sessions *sessionStore
+	// factorBudget bounds the time spent factorizing one number or sieving
+	// one PrimesInRange request, zero means no limit besides the client
+	// deadline
+	factorBudget time.Duration
 }
 
-func (*server) Sum(ctx context.Context, req *calculatorpb.SumRequest) (*calculatorpb.SumResponse, error) {
+func (s *server) Sum(ctx context.Context, req *calculatorpb.SumRequest) (*calculatorpb.SumResponse, error) {
 
-	log.Printf("Server called with : %v,\n ", req)
+	logging.ForRequest(ctx, s.logger).Info("Sum request", zap.Stringer("request", req))
 	sumResult := req.GetFirstNumber() + req.GetSecondNumber()
 	res := &calculatorpb.SumResponse{
 		SumResult: sumResult,
@@ -30,68 +55,70 @@ func (*server) Sum(ctx context.Context, req *calculatorpb.SumRequest) (*calculat
 
 }
 
-func (*server) PrimeNumberDecomposition(req *calculatorpb.PrimeNumberDecompositionRequest, stream calculatorpb.CalculatorService_PrimeNumberDecompositionServer) error {
+func (s *server) PrimeNumberDecomposition(req *calculatorpb.PrimeNumberDecompositionRequest, stream calculatorpb.CalculatorService_PrimeNumberDecompositionServer) error {
 
-	fmt.Printf("Received Request : %v \n", req)
+	logger := logging.ForRequest(stream.Context(), s.logger)
+	logger.Info("PrimeNumberDecomposition request", zap.Stringer("request", req))
 
 	number := req.GetNumber()
-	k := int64(2)
-
-	for number > 1 {
-		if number%k == 0 {
-			res := &calculatorpb.PrimeNumberDecompositionResponse{
-				PrimeFactor: k,
-			}
-			stream.Send(res)
-			number /= k
+	if number < 1 {
+		return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("Received non-positive number : %v", number),
+			rpcerror.BadRequest("number", "must be positive"),
+		)
+	}
 
-		} else {
-			k++
+	ctx, cancel := s.factorContext(stream.Context())
+	defer cancel()
 
+	err := primes.Factorize(ctx, uint64(number), func(f primes.Factor) error {
+		res := &calculatorpb.PrimeNumberDecompositionResponse{
+			PrimeFactor:  int64(f.Prime),
+			Multiplicity: int32(f.Multiplicity),
 		}
-
+		if err := stream.Send(res); err != nil {
+			logger.Warn("error sending prime factor", zap.Error(err))
+			return status.Errorf(status.Code(err), "error sending prime factor : %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return s.factorError(stream.Context(), fmt.Sprintf("factorization of %v", number), err)
 	}
-
 	return nil
 
 }
 
-func (*server) ComputeAverage(stream calculatorpb.CalculatorService_ComputeAverageServer) error {
+func (s *server) ComputeAverage(stream calculatorpb.CalculatorService_ComputeAverageServer) error {
 
-	avg := func(nums []int64) float64 {
-		length := len(nums)
-		sum := int64(0)
-		for _, num := range nums {
-			sum += num
-
-		}
-		average := float64(sum) / float64(length)
-		return average
-
-	}
-
-	var numbers []int64
+	// a running mean, rather than summing every number, cannot overflow
+	var summary stats.Summary
 
 	for {
 		req, err := stream.Recv()
 		if err == io.EOF {
-
+			if summary.Count() == 0 {
+				return emptyStream("number")
+			}
 			return stream.SendAndClose(&calculatorpb.ComputeAverageResponse{
-				Average: avg(numbers),
+				Average: summary.Mean(),
 			})
 
 		}
 		if err != nil {
-			log.Fatalf("error trying to get client stream : %v", err)
+			logging.ForRequest(stream.Context(), s.logger).Warn("error trying to get client stream", zap.Error(err))
+			return status.Errorf(status.Code(err), "error trying to get client stream : %v", err)
 		}
-		numbers = append(numbers, req.GetNumber())
+		summary.Add(float64(req.GetNumber()))
 
 	}
 }
 
-func (*server) FindMaximum(stream calculatorpb.CalculatorService_FindMaximumServer) error {
+func (s *server) FindMaximum(stream calculatorpb.CalculatorService_FindMaximumServer) error {
 
-	maximum := int32(math.Inf(-1))
+	logger := logging.ForRequest(stream.Context(), s.logger)
+	// the first number is a new maximum whatever its value, even math.MinInt32
+	var maximum int32
+	first := true
 
 	for {
 
@@ -103,18 +130,20 @@ func (*server) FindMaximum(stream calculatorpb.CalculatorService_FindMaximumServ
 		}
 
 		if err != nil {
-			log.Fatalf("error trying to get client data streams : %v", err)
+			logger.Warn("error trying to get client data streams", zap.Error(err))
+			return status.Errorf(status.Code(err), "error trying to get client data streams : %v", err)
 		}
 
 		number := req.GetNumber()
 
-		if number > maximum {
+		if first || number > maximum {
+			first = false
 			maximum = number
 			if err := stream.Send(&calculatorpb.FindMaximumResponse{
 				Maximum: maximum,
 			}); err != nil {
-				log.Fatalf("error trying to send server data stream : %v", err)
-
+				logger.Warn("error trying to send server data stream", zap.Error(err))
+				return status.Errorf(status.Code(err), "error trying to send server data stream : %v", err)
 			}
 		}
 
@@ -126,7 +155,10 @@ func (*server) SquareRoot(ctx context.Context, req *calculatorpb.SquareRootReque
 	number := req.GetNumber()
 
 	if number < 0 {
-		return nil, status.Errorf(codes.InvalidArgument, fmt.Sprintf("Received negative number : %v", number))
+		return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("Received negative number : %v", number),
+			rpcerror.ErrorInfo("NEGATIVE_SQUARE_ROOT", "number", fmt.Sprint(number)),
+			rpcerror.BadRequest("number", "must not be negative"),
+		)
 
 	}
 
@@ -134,20 +166,179 @@ func (*server) SquareRoot(ctx context.Context, req *calculatorpb.SquareRootReque
 
 }
 
+// requestRules holds the checks that need nothing but the request. Errors
+// that carry more than a field violation, like a negative square root, are
+// left to the handlers.
+func requestRules() *validation.Validator {
+	return validation.New().
+		Add(&calculatorpb.BigArithmeticRequest{},
+			validation.Required("first_number.value"),
+			validation.Length("first_number.value", 1, decimal.MaxDigits),
+			validation.Pattern("first_number.value", bigNumberPattern),
+			validation.Required("second_number.value"),
+			validation.Length("second_number.value", 1, decimal.MaxDigits),
+			validation.Pattern("second_number.value", bigNumberPattern),
+			validation.Range("scale", 0, 1000),
+		).
+		Add(&calculatorpb.RootRequest{},
+			validation.Range("degree", 0, maxRootDegree),
+			validation.Length("decimal.value", 1, decimal.MaxDigits),
+			validation.Pattern("decimal.value", bigNumberPattern),
+			validation.Range("scale", 0, 1000),
+		).
+		Add(&calculatorpb.GCDRequest{}, validation.Required("numbers")).
+		Add(&calculatorpb.LCMRequest{}, validation.Required("numbers")).
+		Add(&calculatorpb.ModPowRequest{},
+			validation.Range("modulus", 1, math.MaxInt64),
+		).
+		Add(&calculatorpb.ModInverseRequest{},
+			validation.Range("modulus", 1, math.MaxInt64),
+		).
+		Add(&calculatorpb.PrimesInRangeRequest{},
+			validation.Range("start", 0, math.MaxInt64),
+			validation.Range("end", 0, math.MaxInt64),
+		).
+		Add(&calculatorpb.MatrixRequest{},
+			validation.Required("first"),
+			validation.Range("first.rows", 1, maxMatrixDimension),
+			validation.Range("first.columns", 1, maxMatrixDimension),
+			validation.Range("second.rows", 0, maxMatrixDimension),
+			validation.Range("second.columns", 0, maxMatrixDimension),
+		).
+		Add(&calculatorpb.EvaluateRequest{},
+			validation.Required("expression"),
+			validation.Length("expression", 1, maxExpressionLength),
+		).
+		Add(&calculatorpb.EvaluateInSessionRequest{},
+			validation.Required("session_id"),
+			validation.Pattern("session_id", sessionIDPattern),
+			validation.Required("expression"),
+			validation.Length("expression", 1, maxExpressionLength),
+		).
+		Add(&calculatorpb.GetHistoryRequest{},
+			validation.Required("session_id"),
+			validation.Pattern("session_id", sessionIDPattern),
+		).
+		Add(&calculatorpb.ClearSessionRequest{},
+			validation.Required("session_id"),
+			validation.Pattern("session_id", sessionIDPattern),
+		)
+}
+
 func main() {
-	lis, err := net.Listen("tcp", "0.0.0.0:50000")
+	metricsAddr := flag.String("metrics-addr", "0.0.0.0:9092", "address serving Prometheus metrics on /metrics")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP gRPC collector receiving traces, e.g. localhost:4317")
+	traceFile := flag.String("trace-file", "", "file receiving traces as JSON lines when no OTLP endpoint is set")
+	apiKeys := flag.String("api-keys", "", "JSON file of the API keys clients must present, empty disables API key authentication")
+	factorBudget := flag.Duration("factor-budget", 10*time.Second, "longest time spent factorizing one number or sieving one range of primes, 0 for no limit")
+	var listenFlags listener.Flags
+	listenFlags.Register(flag.CommandLine, "0.0.0.0:50000")
+	var tlsFlags tlsconfig.ServerFlags
+	tlsFlags.Register(flag.CommandLine, "", "")
+	flag.Parse()
+
+	logger, err := logging.New("calculator_server")
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName:  "calculator_server",
+		OTLPEndpoint: *otlpEndpoint,
+		File:         *traceFile,
+	})
 	if err != nil {
-		log.Fatalf("could not establish a listener : %v", err)
+		logger.Fatal("unable to set up tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("error flushing traces", zap.Error(err))
+		}
+	}()
+
+	listeners, err := listenFlags.Listen()
+
+	if err != nil {
+		logger.Fatal("could not establish a listener", zap.Error(err))
 
 	}
-	grpcServer := grpc.NewServer()
 
-	calculatorpb.RegisterCalculatorServiceServer(grpcServer, &server{})
+	validator := units.Rules(apikey.Rules(requestRules()))
+	limiter := ratelimit.New(ratelimit.Config{
+		Default: ratelimit.Limit{PerSecond: 50, Burst: 100},
+		Methods: map[string]ratelimit.Limit{
+			// each factorization may keep a core busy for up to -factor-budget
+			"/calculator.CalculatorService/PrimeNumberDecomposition": {PerSecond: 1, Burst: 5},
+			"/calculator.CalculatorService/PrimesInRange":            {PerSecond: 1, Burst: 5},
+			// so may a product or an inverse of the largest matrices
+			"/calculator.CalculatorService/ComputeMatrix": {PerSecond: 5, Burst: 10},
+			"/calculator.CalculatorService/StreamMatrix":  {PerSecond: 5, Burst: 10},
+			// every session holds memory until its TTL passes without use
+			"/calculator.CalculatorService/CreateSession": {PerSecond: 0.2, Burst: 10},
+		},
+		StreamMessages: map[string]ratelimit.Limit{
+			"/calculator.CalculatorService/FindMaximum":     {PerSecond: 100, Burst: 200},
+			"/calculator.CalculatorService/StreamAggregate": {PerSecond: 1000, Burst: 2000},
+		},
+	})
+
+	serverMetrics := metrics.New()
+	go func() {
+		logger.Fatal("metrics endpoint stopped", zap.Error(serverMetrics.Serve(*metricsAddr)))
+	}()
+
+	creds, err := tlsFlags.ServerOption(context.Background(), tlsconfig.ReloaderOptions{
+		Logger:   logger,
+		OnReload: serverMetrics.ObserveCertReload,
+	})
+	if err != nil {
+		logger.Fatal("error loading certificates", zap.Error(err))
+	}
+
+	var keyStore apikey.Store
+	if *apiKeys != "" {
+		keyStore, err = apikey.NewFileStore(*apiKeys)
+		if err != nil {
+			logger.Fatal("unable to load API keys", zap.Error(err))
+		}
+	}
+
+	serverOpts := interceptors.ServerOptions(interceptors.Config{
+		Logger:    logger,
+		Metrics:   serverMetrics,
+		KeyStore:  keyStore,
+		Limiter:   limiter,
+		Validator: validator,
+	})
+	grpcServer := grpc.NewServer(append(serverOpts, creds)...)
+
+	calculatorpb.RegisterCalculatorServiceServer(grpcServer, &server{
+		logger:       logger,
+		sessions:     newSessionStore(),
+		factorBudget: *factorBudget,
+	})
+	unitspb.RegisterUnitServiceServer(grpcServer, units.NewService())
+	if keyStore != nil {
+		apikeypb.RegisterApiKeyServiceServer(grpcServer, apikey.NewService(keyStore))
+	}
 
 	//Register reflection on GRPC server
 	reflection.Register(grpcServer)
 
-	log.Fatal(grpcServer.Serve(lis))
+	go func() {
+		logger.Info("starting gRPC server", zap.Strings("addresses", listener.Addrs(listeners)))
+		if err := listener.Serve(grpcServer, listeners); err != nil {
+			logger.Fatal("gRPC server stopped", zap.Error(err))
+		}
+	}()
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt)
+	<-ch
 
+	logger.Info("shutting down gRPC server")
+	grpcServer.Stop()
 }
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/grpc-go-new-course/rpcerror"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func startCalculator(t *testing.T) calculatorpb.CalculatorServiceClient {
+	return startCalculatorServer(t, &server{logger: zap.NewNop(), sessions: newSessionStore()})
+}
+
+func startCalculatorServer(t *testing.T, srv *server) calculatorpb.CalculatorServiceClient {
+	validator := requestRules()
+	conn := grpctest.Start(t, func(s *grpc.Server) {
+		calculatorpb.RegisterCalculatorServiceServer(s, srv)
+	},
+		grpc.ChainUnaryInterceptor(validator.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(validator.StreamServerInterceptor()),
+	)
+	return calculatorpb.NewCalculatorServiceClient(conn)
+}
+
+func TestSum(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		name          string
+		first, second int32
+		want          int32
+	}{
+		{name: "positive", first: 3, second: 10, want: 13},
+		{name: "negative", first: -3, second: -10, want: -13},
+		{name: "zero", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.Sum(context.Background(), &calculatorpb.SumRequest{FirstNumber: tt.first, SecondNumber: tt.second})
+			if err != nil {
+				t.Fatalf("Sum : %v", err)
+			}
+			if res.GetSumResult() != tt.want {
+				t.Errorf("got %d, want %d", res.GetSumResult(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrimeNumberDecomposition(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		name   string
+		number int64
+		want   string
+		code   codes.Code
+	}{
+		{name: "composite", number: 120, want: "2^3 3^1 5^1"},
+		{name: "prime", number: 97, want: "97^1"},
+		{name: "one", number: 1},
+		{name: "large prime", number: 9223372036854775783, want: "9223372036854775783^1"},
+		{name: "large semiprime", number: 2147483647 * 2147483629, want: "2147483629^1 2147483647^1"},
+		{name: "zero", number: 0, code: codes.InvalidArgument},
+		{name: "negative", number: -12, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factors, err := decompose(client, tt.number)
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+			sort.Strings(factors)
+			if got := strings.Join(factors, " "); got != tt.want {
+				t.Errorf("got factors %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// decompose returns the factors streamed for number as "prime^multiplicity".
+func decompose(client calculatorpb.CalculatorServiceClient, number int64) ([]string, error) {
+	stream, err := client.PrimeNumberDecomposition(context.Background(), &calculatorpb.PrimeNumberDecompositionRequest{Number: number})
+	if err != nil {
+		return nil, err
+	}
+	var factors []string
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return factors, nil
+		}
+		if err != nil {
+			return factors, err
+		}
+		factors = append(factors, fmt.Sprintf("%d^%d", res.GetPrimeFactor(), res.GetMultiplicity()))
+	}
+}
+
+func TestPrimeNumberDecompositionTimeBudget(t *testing.T) {
+	client := startCalculatorServer(t, &server{logger: zap.NewNop(), factorBudget: time.Nanosecond})
+
+	// trial division alone finds the factors of small numbers
+	if _, err := decompose(client, 120); err != nil {
+		t.Fatalf("PrimeNumberDecomposition(120) : %v", err)
+	}
+	_, err := decompose(client, 2147483647*2147483629)
+	if grpctest.AssertCode(t, err, codes.DeadlineExceeded) {
+		if reason := rpcerror.FromError(err).ErrorInfo.GetReason(); reason != "FACTORIZATION_TIME_BUDGET" {
+			t.Errorf("got reason %q, want FACTORIZATION_TIME_BUDGET", reason)
+		}
+	}
+}
+
+func TestComputeAverage(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		name    string
+		numbers []int64
+		want    float64
+		code    codes.Code
+	}{
+		{name: "integers", numbers: []int64{1, 2, 3, 4}, want: 2.5},
+		{name: "single", numbers: []int64{-7}, want: -7},
+		{name: "beyond int64 sum", numbers: []int64{math.MaxInt64, math.MaxInt64}, want: math.MaxInt64},
+		{name: "empty", code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.ComputeAverage(context.Background())
+			if err != nil {
+				t.Fatalf("ComputeAverage : %v", err)
+			}
+			for _, number := range tt.numbers {
+				if err := stream.Send(&calculatorpb.ComputeAverageRequest{Number: number}); err != nil {
+					t.Fatalf("Send : %v", err)
+				}
+			}
+			res, err := stream.CloseAndRecv()
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+			if res.GetAverage() != tt.want {
+				t.Errorf("got %v, want %v", res.GetAverage(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFindMaximum(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		name    string
+		numbers []int32
+		want    []int32
+	}{
+		{name: "new maximums only", numbers: []int32{1, 5, 3, 6, 2, 20}, want: []int32{1, 5, 6, 20}},
+		{name: "decreasing", numbers: []int32{9, 8, 7}, want: []int32{9}},
+		{name: "min int32 first", numbers: []int32{math.MinInt32, -5}, want: []int32{math.MinInt32, -5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.FindMaximum(context.Background())
+			if err != nil {
+				t.Fatalf("FindMaximum : %v", err)
+			}
+			for _, number := range tt.numbers {
+				if err := stream.Send(&calculatorpb.FindMaximumRequest{Number: number}); err != nil {
+					t.Fatalf("Send : %v", err)
+				}
+			}
+			if err := stream.CloseSend(); err != nil {
+				t.Fatalf("CloseSend : %v", err)
+			}
+
+			var maximums []int32
+			for {
+				res, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Recv : %v", err)
+				}
+				maximums = append(maximums, res.GetMaximum())
+			}
+			if len(maximums) != len(tt.want) {
+				t.Fatalf("got maximums %v, want %v", maximums, tt.want)
+			}
+			for i := range maximums {
+				if maximums[i] != tt.want[i] {
+					t.Fatalf("got maximums %v, want %v", maximums, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSquareRoot(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		name   string
+		number int32
+		want   float64
+		code   codes.Code
+	}{
+		{name: "perfect square", number: 16, want: 4},
+		{name: "irrational", number: 2, want: math.Sqrt2},
+		{name: "zero", number: 0, want: 0},
+		{name: "negative", number: -23, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.SquareRoot(context.Background(), &calculatorpb.SquareRootRequest{Number: tt.number})
+			if !grpctest.AssertCode(t, err, tt.code) {
+				return
+			}
+			if err != nil {
+				if reason := rpcerror.FromError(err).ErrorInfo.GetReason(); reason != "NEGATIVE_SQUARE_ROOT" {
+					t.Errorf("got reason %q, want NEGATIVE_SQUARE_ROOT", reason)
+				}
+				return
+			}
+			if res.GetSqrRoot() != tt.want {
+				t.Errorf("got %v, want %v", res.GetSqrRoot(), tt.want)
+			}
+		})
+	}
+}
+
+func TestBigArithmetic(t *testing.T) {
+	client := startCalculator(t)
+
+	scale := func(n int32) *int32 { return &n }
+	tests := []struct {
+		name          string
+		op            calculatorpb.BigOperation
+		first, second string
+		scale         *int32
+		rounding      calculatorpb.RoundingMode
+		want          string
+		code          codes.Code
+	}{
+		{name: "sum beyond int64", op: calculatorpb.BigOperation_BIG_ADD, first: "9223372036854775807", second: "1", want: "9223372036854775808"},
+		{name: "sum without float error", op: calculatorpb.BigOperation_BIG_ADD, first: "0.1", second: "0.2", want: "0.3"},
+		{name: "subtract", op: calculatorpb.BigOperation_BIG_SUBTRACT, first: "10.00", second: "0.01", want: "9.99"},
+		{name: "multiply", op: calculatorpb.BigOperation_BIG_MULTIPLY, first: "19.99", second: "3", want: "59.97"},
+		{name: "divide default scale", op: calculatorpb.BigOperation_BIG_DIVIDE, first: "1", second: "3", want: "0.33333333333333333333"},
+		{name: "divide exact", op: calculatorpb.BigOperation_BIG_DIVIDE, first: "10", second: "4", want: "2.5"},
+		{name: "divide rounded", op: calculatorpb.BigOperation_BIG_DIVIDE, first: "10", second: "3", scale: scale(2), rounding: calculatorpb.RoundingMode_ROUND_UP, want: "3.34"},
+		{name: "half even", op: calculatorpb.BigOperation_BIG_MULTIPLY, first: "0.125", second: "1", scale: scale(2), want: "0.12"},
+		{name: "half up", op: calculatorpb.BigOperation_BIG_MULTIPLY, first: "0.125", second: "1", scale: scale(2), rounding: calculatorpb.RoundingMode_ROUND_HALF_UP, want: "0.13"},
+		{name: "power", op: calculatorpb.BigOperation_BIG_POWER, first: "2", second: "64", want: "18446744073709551616"},
+		{name: "negative power", op: calculatorpb.BigOperation_BIG_POWER, first: "4", second: "-1", want: "0.25"},
+		{name: "modulo", op: calculatorpb.BigOperation_BIG_MODULO, first: "-7", second: "3", want: "-1"},
+		{name: "divide by zero", op: calculatorpb.BigOperation_BIG_DIVIDE, first: "1", second: "0.00", code: codes.InvalidArgument},
+		{name: "fractional exponent", op: calculatorpb.BigOperation_BIG_POWER, first: "2", second: "0.5", code: codes.InvalidArgument},
+		{name: "too large", op: calculatorpb.BigOperation_BIG_POWER, first: "10", second: "20000", code: codes.OutOfRange},
+		{name: "not a number", op: calculatorpb.BigOperation_BIG_ADD, first: "12abc", second: "1", code: codes.InvalidArgument},
+		{name: "missing number", op: calculatorpb.BigOperation_BIG_ADD, first: "1", code: codes.InvalidArgument},
+		{name: "negative scale", op: calculatorpb.BigOperation_BIG_ADD, first: "1", second: "1", scale: scale(-1), code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &calculatorpb.BigArithmeticRequest{
+				Operation:    tt.op,
+				FirstNumber:  &calculatorpb.BigNumber{Value: tt.first},
+				SecondNumber: &calculatorpb.BigNumber{Value: tt.second},
+				Scale:        tt.scale,
+				Rounding:     tt.rounding,
+			}
+			res, err := client.BigArithmetic(context.Background(), req)
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+			if got := res.GetResult().GetValue(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoot(t *testing.T) {
+	client := startCalculator(t)
+
+	number := func(x float64) *calculatorpb.RootRequest_Number { return &calculatorpb.RootRequest_Number{Number: x} }
+	dec := func(x string) *calculatorpb.RootRequest_Decimal {
+		return &calculatorpb.RootRequest_Decimal{Decimal: &calculatorpb.BigNumber{Value: x}}
+	}
+	scale := func(n int32) *int32 { return &n }
+	tests := []struct {
+		name string
+		req  *calculatorpb.RootRequest
+		// "re" or "re+imi"
+		want string
+		code codes.Code
+	}{
+		{name: "square root", req: &calculatorpb.RootRequest{Radicand: number(2.25)}, want: "1.5"},
+		{name: "cube root", req: &calculatorpb.RootRequest{Radicand: number(-27), Degree: 3}, want: "-3"},
+		{name: "negative square root", req: &calculatorpb.RootRequest{Radicand: number(-9)}, code: codes.InvalidArgument},
+		{name: "complex square root", req: &calculatorpb.RootRequest{Radicand: number(-9), Complex: true}, want: "0+3i"},
+		{name: "complex fourth root", req: &calculatorpb.RootRequest{Radicand: number(-4), Degree: 4, Complex: true}, want: "1+1i"},
+		{name: "decimal", req: &calculatorpb.RootRequest{Radicand: dec("2"), Scale: scale(30)}, want: "1.414213562373095048801688724210"},
+		{name: "decimal exact", req: &calculatorpb.RootRequest{Radicand: dec("0.0625"), Degree: 4}, want: "0.5"},
+		{name: "decimal default scale", req: &calculatorpb.RootRequest{Radicand: dec("3"), Degree: 3}, want: "1.44224957030740838232"},
+		{name: "decimal complex", req: &calculatorpb.RootRequest{Radicand: dec("-2"), Scale: scale(10), Complex: true}, want: "0.0000000000+1.4142135624i"},
+		{name: "decimal negative", req: &calculatorpb.RootRequest{Radicand: dec("-2")}, code: codes.InvalidArgument},
+		{name: "scale of number", req: &calculatorpb.RootRequest{Radicand: number(2), Scale: scale(3)}, code: codes.InvalidArgument},
+		{name: "degree too large", req: &calculatorpb.RootRequest{Radicand: number(2), Degree: maxRootDegree + 1}, code: codes.InvalidArgument},
+		{name: "not a decimal", req: &calculatorpb.RootRequest{Radicand: dec("1e")}, code: codes.InvalidArgument},
+		{name: "missing radicand", req: &calculatorpb.RootRequest{}, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.Root(context.Background(), tt.req)
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+
+			var got string
+			if res.GetRealDecimal() != nil {
+				got = res.GetRealDecimal().GetValue()
+				if im := res.GetImaginaryDecimal(); im != nil {
+					got += "+" + im.GetValue() + "i"
+				}
+			} else {
+				// round away floating point error
+				round := func(x float64) float64 { return math.Round(x*1e12) / 1e12 }
+				got = fmt.Sprint(round(res.GetReal()))
+				if res.GetImaginary() != 0 {
+					got += fmt.Sprintf("+%vi", round(res.GetImaginary()))
+				}
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		name       string
+		expression string
+		variables  map[string]float64
+		want       float64
+		code       codes.Code
+		reason     string
+		position   string
+	}{
+		{name: "precedence and functions", expression: "2 * (3 + sqrt(16)) / x", variables: map[string]float64{"x": 7}, want: 2},
+		{name: "min max pow", expression: "max(1, pow(2, 3), min(9, 10))", want: 9},
+		{name: "syntax error", expression: "2 * (3 + 4", code: codes.InvalidArgument, reason: "EXPRESSION_SYNTAX", position: "11"},
+		{name: "unbound variable", expression: "1 + x", code: codes.InvalidArgument, reason: "UNBOUND_VARIABLE", position: "5"},
+		{name: "division by zero", expression: "1 / 0", code: codes.InvalidArgument, reason: "EXPRESSION_EVALUATION", position: "3"},
+		{name: "empty", code: codes.InvalidArgument, reason: "INVALID_REQUEST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.Evaluate(context.Background(), &calculatorpb.EvaluateRequest{Expression: tt.expression, Variables: tt.variables})
+			if !grpctest.AssertCode(t, err, tt.code) {
+				return
+			}
+			if err != nil {
+				info := rpcerror.FromError(err).ErrorInfo
+				if info.GetReason() != tt.reason || info.GetMetadata()["position"] != tt.position {
+					t.Errorf("got reason %s at %q, want %s at %q", info.GetReason(), info.GetMetadata()["position"], tt.reason, tt.position)
+				}
+				return
+			}
+			if res.GetResult() != tt.want {
+				t.Errorf("got %v, want %v", res.GetResult(), tt.want)
+			}
+		})
+	}
+}
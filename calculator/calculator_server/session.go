@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/calculator/expr"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/ratelimit"
+	"github.com/grpc-go-new-course/rpcerror"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultSessionTTL = 30 * time.Minute
+	maxSessionTTL     = 24 * time.Hour
+	// sweepInterval is how often using a session also forgets the expired
+	// ones, which creating a session always does
+	sweepInterval = time.Minute
+	// ansVariable holds the result of the previous evaluation of a session
+	ansVariable      = "ans"
+	sessionIDPattern = "^[0-9a-f]{32}$"
+)
+
+var (
+	errSessionNotFound  = errors.New("session not found")
+	errTooManySessions  = errors.New("too many sessions")
+	errSessionQuota     = errors.New("too many sessions for this caller")
+	errTooManyVariables = errors.New("too many variables")
+)
+
+type historyEntry struct {
+	expression  string
+	result      float64
+	variable    string
+	evaluatedAt time.Time
+}
+
+func (e historyEntry) toProto() *calculatorpb.HistoryEntry {
+	return &calculatorpb.HistoryEntry{
+		Expression:  e.expression,
+		Result:      e.result,
+		Variable:    e.variable,
+		EvaluatedAt: timestamppb.New(e.evaluatedAt),
+	}
+}
+
+type session struct {
+	// owner is the caller that created the session, its quota is freed when
+	// the session expires
+	owner     string
+	ttl       time.Duration
+	expiresAt time.Time
+	variables map[string]float64
+	history   []historyEntry
+	// historyBytes is the length of the expressions in history
+	historyBytes int
+}
+
+// sessionStore keeps calculator sessions in memory. A session expires once
+// it has not been used for its TTL.
+type sessionStore struct {
+	maxSessions int
+	// maxSessionsPerOwner keeps a single caller from taking every session
+	maxSessionsPerOwner int
+	maxVariables        int
+	// maxHistory and maxHistoryBytes bound the entries kept per session and
+	// the length of their expressions, older entries are dropped
+	maxHistory      int
+	maxHistoryBytes int
+	now             func() time.Time
+
+	mu        sync.Mutex
+	lastSweep time.Time
+	sessions  map[string]*session
+	// owned counts the sessions of each owner
+	owned map[string]int
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{
+		maxSessions:         10000,
+		maxSessionsPerOwner: 100,
+		maxVariables:        1000,
+		maxHistory:          100,
+		maxHistoryBytes:     64 << 10,
+		now:                 time.Now,
+		sessions:            make(map[string]*session),
+		owned:               make(map[string]int),
+	}
+}
+
+// create starts an empty session of owner, returning its ID and expiry.
+func (st *sessionStore) create(owner string, ttl time.Duration) (string, time.Time, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", time.Time{}, fmt.Errorf("unable to generate a session ID : %v", err)
+	}
+	id := hex.EncodeToString(random)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := st.now()
+	st.sweep(now)
+	if st.owned[owner] >= st.maxSessionsPerOwner {
+		return "", time.Time{}, errSessionQuota
+	}
+	if len(st.sessions) >= st.maxSessions {
+		return "", time.Time{}, errTooManySessions
+	}
+	s := &session{owner: owner, ttl: ttl, expiresAt: now.Add(ttl), variables: make(map[string]float64)}
+	st.sessions[id] = s
+	st.owned[owner]++
+	return id, s.expiresAt, nil
+}
+
+// sweep forgets the sessions expired at now.
+func (st *sessionStore) sweep(now time.Time) {
+	st.lastSweep = now
+	for id, s := range st.sessions {
+		if !now.Before(s.expiresAt) {
+			st.remove(id, s)
+		}
+	}
+}
+
+// remove forgets the session id.
+func (st *sessionStore) remove(id string, s *session) {
+	delete(st.sessions, id)
+	if st.owned[s.owner]--; st.owned[s.owner] <= 0 {
+		delete(st.owned, s.owner)
+	}
+}
+
+// use calls fn with the session id, extending its life, while holding the
+// store lock.
+func (st *sessionStore) use(id string, fn func(s *session, now time.Time) error) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := st.now()
+	if now.Sub(st.lastSweep) >= sweepInterval {
+		st.sweep(now)
+	}
+	s, ok := st.sessions[id]
+	if !ok {
+		return errSessionNotFound
+	}
+	if !now.Before(s.expiresAt) {
+		st.remove(id, s)
+		return errSessionNotFound
+	}
+	s.expiresAt = now.Add(s.ttl)
+	return fn(s, now)
+}
+
+// evaluate runs statement, an expression or an assignment, in the session
+// and records it in the history.
+func (st *sessionStore) evaluate(s *session, statement string, now time.Time) (historyEntry, error) {
+	name, e, err := expr.ParseAssignment(statement)
+	if err != nil {
+		return historyEntry{}, err
+	}
+	result, err := e.Eval(s.variables)
+	if err != nil {
+		return historyEntry{}, err
+	}
+	if _, ok := s.variables[name]; name != "" && !ok && len(s.variables) >= st.maxVariables {
+		return historyEntry{}, errTooManyVariables
+	}
+
+	if name != "" {
+		s.variables[name] = result
+	}
+	s.variables[ansVariable] = result
+	entry := historyEntry{expression: statement, result: result, variable: name, evaluatedAt: now}
+	s.history = append(s.history, entry)
+	s.historyBytes += len(statement)
+	drop := 0
+	// the latest entry is kept whatever its length
+	for len(s.history)-drop > st.maxHistory || s.historyBytes > st.maxHistoryBytes && len(s.history)-drop > 1 {
+		s.historyBytes -= len(s.history[drop].expression)
+		drop++
+	}
+	if drop > 0 {
+		s.history = append(s.history[:0:0], s.history[drop:]...)
+	}
+	return entry, nil
+}
+
+func (s *server) CreateSession(ctx context.Context, req *calculatorpb.CreateSessionRequest) (*calculatorpb.CreateSessionResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("CreateSession request", zap.Stringer("request", req))
+
+	ttl := defaultSessionTTL
+	if req.GetTtl() != nil {
+		ttl = req.GetTtl().AsDuration()
+		if !req.GetTtl().IsValid() || ttl <= 0 || ttl > maxSessionTTL {
+			return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid session TTL : %v", ttl),
+				rpcerror.BadRequest("ttl", fmt.Sprintf("must be positive and at most %v", maxSessionTTL)),
+			)
+		}
+	}
+
+	// callers without any identity share a single quota
+	id, expiresAt, err := s.sessions.create(ratelimit.DefaultKey(ctx), ttl)
+	if err != nil {
+		return nil, sessionError("", err)
+	}
+	return &calculatorpb.CreateSessionResponse{SessionId: id, ExpiresAt: timestamppb.New(expiresAt)}, nil
+}
+
+func (s *server) EvaluateInSession(ctx context.Context, req *calculatorpb.EvaluateInSessionRequest) (*calculatorpb.EvaluateInSessionResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("EvaluateInSession request", zap.Stringer("request", req))
+
+	res := &calculatorpb.EvaluateInSessionResponse{}
+	err := s.sessions.use(req.GetSessionId(), func(sess *session, now time.Time) error {
+		entry, err := s.sessions.evaluate(sess, req.GetExpression(), now)
+		if err != nil {
+			return err
+		}
+		res.Entry = entry.toProto()
+		res.ExpiresAt = timestamppb.New(sess.expiresAt)
+		return nil
+	})
+	if err != nil {
+		return nil, sessionError(req.GetSessionId(), err)
+	}
+	return res, nil
+}
+
+func (s *server) GetHistory(ctx context.Context, req *calculatorpb.GetHistoryRequest) (*calculatorpb.GetHistoryResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("GetHistory request", zap.Stringer("request", req))
+
+	res := &calculatorpb.GetHistoryResponse{}
+	err := s.sessions.use(req.GetSessionId(), func(sess *session, now time.Time) error {
+		res.Entries = make([]*calculatorpb.HistoryEntry, len(sess.history))
+		for i, entry := range sess.history {
+			res.Entries[i] = entry.toProto()
+		}
+		res.Variables = make(map[string]float64, len(sess.variables))
+		for name, value := range sess.variables {
+			res.Variables[name] = value
+		}
+		res.ExpiresAt = timestamppb.New(sess.expiresAt)
+		return nil
+	})
+	if err != nil {
+		return nil, sessionError(req.GetSessionId(), err)
+	}
+	return res, nil
+}
+
+func (s *server) ClearSession(ctx context.Context, req *calculatorpb.ClearSessionRequest) (*calculatorpb.ClearSessionResponse, error) {
+
+	logging.ForRequest(ctx, s.logger).Info("ClearSession request", zap.Stringer("request", req))
+
+	res := &calculatorpb.ClearSessionResponse{}
+	err := s.sessions.use(req.GetSessionId(), func(sess *session, now time.Time) error {
+		sess.history = nil
+		sess.historyBytes = 0
+		sess.variables = make(map[string]float64)
+		res.ExpiresAt = timestamppb.New(sess.expiresAt)
+		return nil
+	})
+	if err != nil {
+		return nil, sessionError(req.GetSessionId(), err)
+	}
+	return res, nil
+}
+
+func sessionError(id string, err error) error {
+	switch {
+	case errors.Is(err, errSessionNotFound):
+		return rpcerror.New(codes.NotFound, fmt.Sprintf("session %s not found", id),
+			rpcerror.ErrorInfo("SESSION_NOT_FOUND", "session_id", id),
+			rpcerror.ResourceInfo("calculator.Session", id, "does not exist or has expired"),
+		)
+	case errors.Is(err, errTooManySessions):
+		return rpcerror.New(codes.ResourceExhausted, err.Error(),
+			rpcerror.ErrorInfo("TOO_MANY_SESSIONS"),
+		)
+	case errors.Is(err, errSessionQuota):
+		return rpcerror.New(codes.ResourceExhausted, err.Error(),
+			rpcerror.ErrorInfo("SESSION_QUOTA_EXCEEDED"),
+		)
+	case errors.Is(err, errTooManyVariables):
+		return rpcerror.New(codes.ResourceExhausted, err.Error(),
+			rpcerror.ErrorInfo("TOO_MANY_VARIABLES", "session_id", id),
+		)
+	}
+	return expressionError(err, "expression")
+}
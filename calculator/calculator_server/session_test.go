@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/grpc-go-new-course/rpcerror"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// fakeClock is a clock tests move by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func startSessions(t *testing.T) (calculatorpb.CalculatorServiceClient, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)}
+	sessions := newSessionStore()
+	sessions.now = clock.Now
+	sessions.maxHistory = 3
+	return startCalculatorServer(t, &server{logger: zap.NewNop(), sessions: sessions}), clock
+}
+
+func TestSession(t *testing.T) {
+	client, _ := startSessions(t)
+	ctx := context.Background()
+
+	created, err := client.CreateSession(ctx, &calculatorpb.CreateSessionRequest{})
+	if err != nil {
+		t.Fatalf("CreateSession : %v", err)
+	}
+	id := created.GetSessionId()
+
+	steps := []struct {
+		expression string
+		want       float64
+		variable   string
+		code       codes.Code
+	}{
+		{expression: "ans", code: codes.InvalidArgument},
+		{expression: "2 * 21", want: 42},
+		{expression: "rate = ans / 100", want: 0.42, variable: "rate"},
+		{expression: "rate * 2 + ans", want: 1.26},
+		{expression: "rate = rate + 1", want: 1.42, variable: "rate"},
+		{expression: "1 +", code: codes.InvalidArgument},
+	}
+	for _, step := range steps {
+		res, err := client.EvaluateInSession(ctx, &calculatorpb.EvaluateInSessionRequest{SessionId: id, Expression: step.expression})
+		if !grpctest.AssertCode(t, err, step.code) || err != nil {
+			continue
+		}
+		if entry := res.GetEntry(); entry.GetResult() != step.want || entry.GetVariable() != step.variable {
+			t.Errorf("%s : got %v assigned to %q, want %v assigned to %q", step.expression, entry.GetResult(), entry.GetVariable(), step.want, step.variable)
+		}
+	}
+
+	history, err := client.GetHistory(ctx, &calculatorpb.GetHistoryRequest{SessionId: id})
+	if err != nil {
+		t.Fatalf("GetHistory : %v", err)
+	}
+	// failed evaluations are not recorded and only the last 3 entries are kept
+	var expressions []string
+	for _, entry := range history.GetEntries() {
+		expressions = append(expressions, entry.GetExpression())
+	}
+	if len(expressions) != 3 || expressions[0] != "rate = ans / 100" || expressions[2] != "rate = rate + 1" {
+		t.Errorf("got history %q", expressions)
+	}
+	if vars := history.GetVariables(); len(vars) != 2 || vars["rate"] != 1.42 || vars["ans"] != 1.42 {
+		t.Errorf("got variables %v, want rate and ans of 1.42", vars)
+	}
+
+	if _, err := client.ClearSession(ctx, &calculatorpb.ClearSessionRequest{SessionId: id}); err != nil {
+		t.Fatalf("ClearSession : %v", err)
+	}
+	history, err = client.GetHistory(ctx, &calculatorpb.GetHistoryRequest{SessionId: id})
+	if err != nil {
+		t.Fatalf("GetHistory : %v", err)
+	}
+	if len(history.GetEntries()) != 0 || len(history.GetVariables()) != 0 {
+		t.Errorf("got %d entries and %d variables after ClearSession, want none", len(history.GetEntries()), len(history.GetVariables()))
+	}
+	_, err = client.EvaluateInSession(ctx, &calculatorpb.EvaluateInSessionRequest{SessionId: id, Expression: "rate"})
+	grpctest.AssertCode(t, err, codes.InvalidArgument)
+}
+
+func TestSessionExpiry(t *testing.T) {
+	client, clock := startSessions(t)
+	ctx := context.Background()
+
+	created, err := client.CreateSession(ctx, &calculatorpb.CreateSessionRequest{Ttl: durationpb.New(time.Minute)})
+	if err != nil {
+		t.Fatalf("CreateSession : %v", err)
+	}
+	id := created.GetSessionId()
+
+	// every use extends the session
+	for i := 0; i < 3; i++ {
+		clock.Advance(50 * time.Second)
+		if _, err := client.GetHistory(ctx, &calculatorpb.GetHistoryRequest{SessionId: id}); err != nil {
+			t.Fatalf("GetHistory after %d uses : %v", i, err)
+		}
+	}
+
+	clock.Advance(time.Minute)
+	_, err = client.GetHistory(ctx, &calculatorpb.GetHistoryRequest{SessionId: id})
+	grpctest.AssertCode(t, err, codes.NotFound)
+}
+
+func TestSessionErrors(t *testing.T) {
+	client, _ := startSessions(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{name: "unknown session", code: codes.NotFound, call: func() error {
+			_, err := client.GetHistory(ctx, &calculatorpb.GetHistoryRequest{SessionId: "0123456789abcdef0123456789abcdef"})
+			return err
+		}},
+		{name: "malformed session ID", code: codes.InvalidArgument, call: func() error {
+			_, err := client.ClearSession(ctx, &calculatorpb.ClearSessionRequest{SessionId: "nope"})
+			return err
+		}},
+		{name: "negative TTL", code: codes.InvalidArgument, call: func() error {
+			_, err := client.CreateSession(ctx, &calculatorpb.CreateSessionRequest{Ttl: durationpb.New(-time.Second)})
+			return err
+		}},
+		{name: "TTL too long", code: codes.InvalidArgument, call: func() error {
+			_, err := client.CreateSession(ctx, &calculatorpb.CreateSessionRequest{Ttl: durationpb.New(48 * time.Hour)})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grpctest.AssertCode(t, tt.call(), tt.code)
+		})
+	}
+}
+
+func TestSessionQuota(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)}
+	st := newSessionStore()
+	st.now = clock.Now
+	st.maxSessionsPerOwner = 2
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := st.create("ip:10.0.0.1", time.Minute); err != nil {
+			t.Fatalf("session %d : %v", i, err)
+		}
+	}
+	if _, _, err := st.create("ip:10.0.0.1", time.Minute); err != errSessionQuota {
+		t.Errorf("got %v over the quota, want %v", err, errSessionQuota)
+	}
+	// the quota of one caller leaves the others alone
+	if _, _, err := st.create("ip:10.0.0.2", time.Minute); err != nil {
+		t.Errorf("other caller : %v", err)
+	}
+
+	// expired sessions free their quota
+	clock.Advance(time.Minute)
+	if _, _, err := st.create("ip:10.0.0.1", time.Minute); err != nil {
+		t.Errorf("after expiry : %v", err)
+	}
+	if got := st.owned["ip:10.0.0.1"]; got != 1 {
+		t.Errorf("got %d sessions owned after expiry, want 1", got)
+	}
+}
+
+func TestSessionQuotaError(t *testing.T) {
+	sessions := newSessionStore()
+	sessions.maxSessionsPerOwner = 1
+	client := startCalculatorServer(t, &server{logger: zap.NewNop(), sessions: sessions})
+	ctx := context.Background()
+
+	if _, err := client.CreateSession(ctx, &calculatorpb.CreateSessionRequest{}); err != nil {
+		t.Fatalf("CreateSession : %v", err)
+	}
+	_, err := client.CreateSession(ctx, &calculatorpb.CreateSessionRequest{})
+	grpctest.AssertCode(t, err, codes.ResourceExhausted)
+	if reason := rpcerror.FromError(err).ErrorInfo.GetReason(); reason != "SESSION_QUOTA_EXCEEDED" {
+		t.Errorf("got reason %q, want SESSION_QUOTA_EXCEEDED", reason)
+	}
+}
+
+func TestSessionHistoryBytes(t *testing.T) {
+	st := newSessionStore()
+	st.maxHistoryBytes = 10
+	id, _, err := st.create("", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, statement := range []string{"1 + 2", "3 + 4", "x = 5 + 6", "40 + 2 + 0 + 0"} {
+		err := st.use(id, func(s *session, now time.Time) error {
+			_, err := st.evaluate(s, statement, now)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("%s : %v", statement, err)
+		}
+	}
+	st.use(id, func(s *session, now time.Time) error {
+		// the latest entry stays even beyond the limit
+		if len(s.history) != 1 || s.history[0].expression != "40 + 2 + 0 + 0" || s.historyBytes != 14 {
+			t.Errorf("got history %v of %d bytes, want only the latest entry", s.history, s.historyBytes)
+		}
+		return nil
+	})
+}
+
+func TestSessionSweptOnUse(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)}
+	st := newSessionStore()
+	st.now = clock.Now
+
+	stale, _, err := st.create("ip:10.0.0.1", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, _, err := st.create("ip:10.0.0.2", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(sweepInterval + time.Minute)
+	if err := st.use(live, func(*session, time.Time) error { return nil }); err != nil {
+		t.Fatalf("use : %v", err)
+	}
+	if _, ok := st.sessions[stale]; ok || st.owned["ip:10.0.0.1"] != 0 {
+		t.Errorf("expired session still held after using another one")
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/calculator/stats"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/rpcerror"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxPercentiles bounds the distinct percentiles one ComputeStatistics call
+// may request.
+const maxPercentiles = 100
+
+// emptyStream reports a client stream that sent no numbers in field.
+func emptyStream(field string) error {
+	return rpcerror.New(codes.InvalidArgument, "no numbers received",
+		rpcerror.ErrorInfo("EMPTY_STREAM"),
+		rpcerror.BadRequest(field, "must be set by at least one message of the stream"),
+	)
+}
+
+func (s *server) ComputeStatistics(stream calculatorpb.CalculatorService_ComputeStatisticsServer) error {
+
+	var dist stats.Distribution
+	percentiles := map[float64]bool{}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			logging.ForRequest(stream.Context(), s.logger).Warn("error trying to get client stream", zap.Error(err))
+			return status.Errorf(status.Code(err), "error trying to get client stream : %v", err)
+		}
+
+		for _, p := range req.GetPercentiles() {
+			if !(p >= 0 && p <= 100) {
+				return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid percentile : %v", p),
+					rpcerror.BadRequest("percentiles", "must be between 0 and 100"),
+				)
+			}
+			percentiles[p] = true
+		}
+		if len(percentiles) > maxPercentiles {
+			return rpcerror.New(codes.InvalidArgument, "too many percentiles requested",
+				rpcerror.BadRequest("percentiles", fmt.Sprintf("must hold at most %d distinct values across the stream", maxPercentiles)),
+			)
+		}
+		for _, n := range req.GetNumbers() {
+			if math.IsNaN(n) || math.IsInf(n, 0) {
+				return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid number : %v", n),
+					rpcerror.BadRequest("numbers", "must be finite"),
+				)
+			}
+			dist.Add(n)
+		}
+	}
+
+	if dist.Count() == 0 {
+		return emptyStream("numbers")
+	}
+
+	res := &calculatorpb.ComputeStatisticsResponse{
+		Count:          dist.Count(),
+		Sum:            dist.Sum(),
+		Mean:           dist.Mean(),
+		Variance:       dist.Variance(),
+		Stddev:         math.Sqrt(dist.Variance()),
+		SampleVariance: dist.SampleVariance(),
+		SampleStddev:   math.Sqrt(dist.SampleVariance()),
+		Min:            dist.Min(),
+		Max:            dist.Max(),
+		Median:         dist.Median(),
+		Approximate:    dist.Approximate(),
+	}
+	for p := range percentiles {
+		res.Percentiles = append(res.Percentiles, &calculatorpb.Percentile{Percentile: p, Value: dist.Quantile(p / 100)})
+	}
+	sort.Slice(res.Percentiles, func(i, j int) bool {
+		return res.Percentiles[i].GetPercentile() < res.Percentiles[j].GetPercentile()
+	})
+	return stream.SendAndClose(res)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/calculator/stats"
+	"github.com/grpc-go-new-course/grpctest"
+	"google.golang.org/grpc/codes"
+)
+
+func TestComputeStatistics(t *testing.T) {
+	client := startCalculator(t)
+
+	tests := []struct {
+		name     string
+		requests []*calculatorpb.ComputeStatisticsRequest
+		want     *calculatorpb.ComputeStatisticsResponse
+		code     codes.Code
+	}{
+		{
+			name: "batches",
+			requests: []*calculatorpb.ComputeStatisticsRequest{
+				{Numbers: []float64{2, 4, 4}, Percentiles: []float64{90}},
+				{Numbers: []float64{4, 5, 5, 7, 9}, Percentiles: []float64{25, 90}},
+			},
+			want: &calculatorpb.ComputeStatisticsResponse{
+				Count: 8, Sum: 40, Mean: 5, Variance: 4, Stddev: 2,
+				SampleVariance: 32.0 / 7, SampleStddev: math.Sqrt(32.0 / 7),
+				Min: 2, Max: 9, Median: 4.5,
+				Percentiles: []*calculatorpb.Percentile{{Percentile: 25, Value: 4}, {Percentile: 90, Value: 7.6}},
+			},
+		},
+		{
+			name:     "percentiles only",
+			requests: []*calculatorpb.ComputeStatisticsRequest{{Percentiles: []float64{50}}, {Numbers: []float64{-1.5}}},
+			want: &calculatorpb.ComputeStatisticsResponse{
+				Count: 1, Sum: -1.5, Mean: -1.5, Min: -1.5, Max: -1.5, Median: -1.5,
+				Percentiles: []*calculatorpb.Percentile{{Percentile: 50, Value: -1.5}},
+			},
+		},
+		{name: "empty", code: codes.InvalidArgument},
+		{name: "no numbers", requests: []*calculatorpb.ComputeStatisticsRequest{{Percentiles: []float64{50}}}, code: codes.InvalidArgument},
+		{name: "bad percentile", requests: []*calculatorpb.ComputeStatisticsRequest{{Numbers: []float64{1}, Percentiles: []float64{101}}}, code: codes.InvalidArgument},
+		{name: "not finite", requests: []*calculatorpb.ComputeStatisticsRequest{{Numbers: []float64{math.Inf(1)}}}, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.ComputeStatistics(context.Background())
+			if err != nil {
+				t.Fatalf("ComputeStatistics : %v", err)
+			}
+			for _, req := range tt.requests {
+				if err := stream.Send(req); err != nil {
+					t.Fatalf("Send : %v", err)
+				}
+			}
+			res, err := stream.CloseAndRecv()
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+
+			got := []float64{float64(res.GetCount()), res.GetSum(), res.GetMean(), res.GetVariance(), res.GetStddev(),
+				res.GetSampleVariance(), res.GetSampleStddev(), res.GetMin(), res.GetMax(), res.GetMedian()}
+			want := []float64{float64(tt.want.GetCount()), tt.want.GetSum(), tt.want.GetMean(), tt.want.GetVariance(), tt.want.GetStddev(),
+				tt.want.GetSampleVariance(), tt.want.GetSampleStddev(), tt.want.GetMin(), tt.want.GetMax(), tt.want.GetMedian()}
+			for i := range got {
+				if math.Abs(got[i]-want[i]) > 1e-9 {
+					t.Errorf("got %v, want %v", res, tt.want)
+					break
+				}
+			}
+			if len(res.GetPercentiles()) != len(tt.want.GetPercentiles()) {
+				t.Fatalf("got percentiles %v, want %v", res.GetPercentiles(), tt.want.GetPercentiles())
+			}
+			for i, p := range res.GetPercentiles() {
+				wantP := tt.want.GetPercentiles()[i]
+				if p.GetPercentile() != wantP.GetPercentile() || math.Abs(p.GetValue()-wantP.GetValue()) > 1e-9 {
+					t.Errorf("got percentile %v, want %v", p, wantP)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeStatisticsLargeStream(t *testing.T) {
+	client := startCalculator(t)
+
+	stream, err := client.ComputeStatistics(context.Background())
+	if err != nil {
+		t.Fatalf("ComputeStatistics : %v", err)
+	}
+	// 1..100000 in batches of 1000
+	for batch := 0; batch < 100; batch++ {
+		req := &calculatorpb.ComputeStatisticsRequest{Percentiles: []float64{99}}
+		for i := 1; i <= 1000; i++ {
+			req.Numbers = append(req.Numbers, float64(batch*1000+i))
+		}
+		if err := stream.Send(req); err != nil {
+			t.Fatalf("Send : %v", err)
+		}
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("CloseAndRecv : %v", err)
+	}
+	if !res.GetApproximate() || res.GetCount() != 100000 || res.GetSum() != 5000050000 || res.GetMean() != 50000.5 {
+		t.Errorf("got %v", res)
+	}
+	if median := res.GetMedian(); math.Abs(median-50000) > 50000*stats.RelativeAccuracy {
+		t.Errorf("got median %v, want about 50000", median)
+	}
+	if p99 := res.GetPercentiles()[0].GetValue(); math.Abs(p99-99000) > 99000*stats.RelativeAccuracy {
+		t.Errorf("got 99th percentile %v, want about 99000", p99)
+	}
+}
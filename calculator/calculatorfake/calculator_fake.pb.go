@@ -0,0 +1,502 @@
+// Code generated by protoc-gen-go-grpcfake. DO NOT EDIT.
+// source: calculator/calculatorpb/calculator.proto
+
+// Package calculatorfake provides a fake calculatorpb.CalculatorServiceClient, and fakes of its
+// streams, for unit testing code that calls the calculator service without
+// running a server.
+package calculatorfake
+
+import (
+	context "context"
+	calculatorpb "github.com/grpc-go-new-course/calculator/calculatorpb"
+	fakegrpc "github.com/grpc-go-new-course/fakegrpc"
+	grpc "google.golang.org/grpc"
+)
+
+// Client is a fake calculatorpb.CalculatorServiceClient. Every call is recorded,
+// then answered by the matching Func field, or fails with Unimplemented
+// when that field is nil. Call options are ignored.
+type Client struct {
+	fakegrpc.Recorder
+
+	SumFunc                      func(ctx context.Context, in *calculatorpb.SumRequest) (*calculatorpb.SumResponse, error)
+	PrimeNumberDecompositionFunc func(ctx context.Context, in *calculatorpb.PrimeNumberDecompositionRequest) (calculatorpb.CalculatorService_PrimeNumberDecompositionClient, error)
+	ComputeAverageFunc           func(ctx context.Context) (calculatorpb.CalculatorService_ComputeAverageClient, error)
+	ComputeStatisticsFunc        func(ctx context.Context) (calculatorpb.CalculatorService_ComputeStatisticsClient, error)
+	FindMaximumFunc              func(ctx context.Context) (calculatorpb.CalculatorService_FindMaximumClient, error)
+	StreamAggregateFunc          func(ctx context.Context) (calculatorpb.CalculatorService_StreamAggregateClient, error)
+	SquareRootFunc               func(ctx context.Context, in *calculatorpb.SquareRootRequest) (*calculatorpb.SquareRootResponse, error)
+	RootFunc                     func(ctx context.Context, in *calculatorpb.RootRequest) (*calculatorpb.RootResponse, error)
+	BigArithmeticFunc            func(ctx context.Context, in *calculatorpb.BigArithmeticRequest) (*calculatorpb.BigArithmeticResponse, error)
+	EvaluateFunc                 func(ctx context.Context, in *calculatorpb.EvaluateRequest) (*calculatorpb.EvaluateResponse, error)
+	IsPrimeFunc                  func(ctx context.Context, in *calculatorpb.IsPrimeRequest) (*calculatorpb.IsPrimeResponse, error)
+	GCDFunc                      func(ctx context.Context, in *calculatorpb.GCDRequest) (*calculatorpb.GCDResponse, error)
+	LCMFunc                      func(ctx context.Context, in *calculatorpb.LCMRequest) (*calculatorpb.LCMResponse, error)
+	ModPowFunc                   func(ctx context.Context, in *calculatorpb.ModPowRequest) (*calculatorpb.ModPowResponse, error)
+	ModInverseFunc               func(ctx context.Context, in *calculatorpb.ModInverseRequest) (*calculatorpb.ModInverseResponse, error)
+	PrimesInRangeFunc            func(ctx context.Context, in *calculatorpb.PrimesInRangeRequest) (calculatorpb.CalculatorService_PrimesInRangeClient, error)
+	ComputeMatrixFunc            func(ctx context.Context, in *calculatorpb.MatrixRequest) (*calculatorpb.MatrixResponse, error)
+	StreamMatrixFunc             func(ctx context.Context) (calculatorpb.CalculatorService_StreamMatrixClient, error)
+	CreateSessionFunc            func(ctx context.Context, in *calculatorpb.CreateSessionRequest) (*calculatorpb.CreateSessionResponse, error)
+	EvaluateInSessionFunc        func(ctx context.Context, in *calculatorpb.EvaluateInSessionRequest) (*calculatorpb.EvaluateInSessionResponse, error)
+	GetHistoryFunc               func(ctx context.Context, in *calculatorpb.GetHistoryRequest) (*calculatorpb.GetHistoryResponse, error)
+	ClearSessionFunc             func(ctx context.Context, in *calculatorpb.ClearSessionRequest) (*calculatorpb.ClearSessionResponse, error)
+}
+
+var _ calculatorpb.CalculatorServiceClient = (*Client)(nil)
+
+func (c *Client) Sum(ctx context.Context, in *calculatorpb.SumRequest, opts ...grpc.CallOption) (*calculatorpb.SumResponse, error) {
+	c.Record(ctx, "Sum", in)
+	if c.SumFunc == nil {
+		return nil, fakegrpc.Unimplemented("Sum")
+	}
+	return c.SumFunc(ctx, in)
+}
+
+func (c *Client) PrimeNumberDecomposition(ctx context.Context, in *calculatorpb.PrimeNumberDecompositionRequest, opts ...grpc.CallOption) (calculatorpb.CalculatorService_PrimeNumberDecompositionClient, error) {
+	c.Record(ctx, "PrimeNumberDecomposition", in)
+	if c.PrimeNumberDecompositionFunc == nil {
+		return nil, fakegrpc.Unimplemented("PrimeNumberDecomposition")
+	}
+	return c.PrimeNumberDecompositionFunc(ctx, in)
+}
+
+func (c *Client) ComputeAverage(ctx context.Context, opts ...grpc.CallOption) (calculatorpb.CalculatorService_ComputeAverageClient, error) {
+	c.Record(ctx, "ComputeAverage", nil)
+	if c.ComputeAverageFunc == nil {
+		return nil, fakegrpc.Unimplemented("ComputeAverage")
+	}
+	return c.ComputeAverageFunc(ctx)
+}
+
+func (c *Client) ComputeStatistics(ctx context.Context, opts ...grpc.CallOption) (calculatorpb.CalculatorService_ComputeStatisticsClient, error) {
+	c.Record(ctx, "ComputeStatistics", nil)
+	if c.ComputeStatisticsFunc == nil {
+		return nil, fakegrpc.Unimplemented("ComputeStatistics")
+	}
+	return c.ComputeStatisticsFunc(ctx)
+}
+
+func (c *Client) FindMaximum(ctx context.Context, opts ...grpc.CallOption) (calculatorpb.CalculatorService_FindMaximumClient, error) {
+	c.Record(ctx, "FindMaximum", nil)
+	if c.FindMaximumFunc == nil {
+		return nil, fakegrpc.Unimplemented("FindMaximum")
+	}
+	return c.FindMaximumFunc(ctx)
+}
+
+func (c *Client) StreamAggregate(ctx context.Context, opts ...grpc.CallOption) (calculatorpb.CalculatorService_StreamAggregateClient, error) {
+	c.Record(ctx, "StreamAggregate", nil)
+	if c.StreamAggregateFunc == nil {
+		return nil, fakegrpc.Unimplemented("StreamAggregate")
+	}
+	return c.StreamAggregateFunc(ctx)
+}
+
+func (c *Client) SquareRoot(ctx context.Context, in *calculatorpb.SquareRootRequest, opts ...grpc.CallOption) (*calculatorpb.SquareRootResponse, error) {
+	c.Record(ctx, "SquareRoot", in)
+	if c.SquareRootFunc == nil {
+		return nil, fakegrpc.Unimplemented("SquareRoot")
+	}
+	return c.SquareRootFunc(ctx, in)
+}
+
+func (c *Client) Root(ctx context.Context, in *calculatorpb.RootRequest, opts ...grpc.CallOption) (*calculatorpb.RootResponse, error) {
+	c.Record(ctx, "Root", in)
+	if c.RootFunc == nil {
+		return nil, fakegrpc.Unimplemented("Root")
+	}
+	return c.RootFunc(ctx, in)
+}
+
+func (c *Client) BigArithmetic(ctx context.Context, in *calculatorpb.BigArithmeticRequest, opts ...grpc.CallOption) (*calculatorpb.BigArithmeticResponse, error) {
+	c.Record(ctx, "BigArithmetic", in)
+	if c.BigArithmeticFunc == nil {
+		return nil, fakegrpc.Unimplemented("BigArithmetic")
+	}
+	return c.BigArithmeticFunc(ctx, in)
+}
+
+func (c *Client) Evaluate(ctx context.Context, in *calculatorpb.EvaluateRequest, opts ...grpc.CallOption) (*calculatorpb.EvaluateResponse, error) {
+	c.Record(ctx, "Evaluate", in)
+	if c.EvaluateFunc == nil {
+		return nil, fakegrpc.Unimplemented("Evaluate")
+	}
+	return c.EvaluateFunc(ctx, in)
+}
+
+func (c *Client) IsPrime(ctx context.Context, in *calculatorpb.IsPrimeRequest, opts ...grpc.CallOption) (*calculatorpb.IsPrimeResponse, error) {
+	c.Record(ctx, "IsPrime", in)
+	if c.IsPrimeFunc == nil {
+		return nil, fakegrpc.Unimplemented("IsPrime")
+	}
+	return c.IsPrimeFunc(ctx, in)
+}
+
+func (c *Client) GCD(ctx context.Context, in *calculatorpb.GCDRequest, opts ...grpc.CallOption) (*calculatorpb.GCDResponse, error) {
+	c.Record(ctx, "GCD", in)
+	if c.GCDFunc == nil {
+		return nil, fakegrpc.Unimplemented("GCD")
+	}
+	return c.GCDFunc(ctx, in)
+}
+
+func (c *Client) LCM(ctx context.Context, in *calculatorpb.LCMRequest, opts ...grpc.CallOption) (*calculatorpb.LCMResponse, error) {
+	c.Record(ctx, "LCM", in)
+	if c.LCMFunc == nil {
+		return nil, fakegrpc.Unimplemented("LCM")
+	}
+	return c.LCMFunc(ctx, in)
+}
+
+func (c *Client) ModPow(ctx context.Context, in *calculatorpb.ModPowRequest, opts ...grpc.CallOption) (*calculatorpb.ModPowResponse, error) {
+	c.Record(ctx, "ModPow", in)
+	if c.ModPowFunc == nil {
+		return nil, fakegrpc.Unimplemented("ModPow")
+	}
+	return c.ModPowFunc(ctx, in)
+}
+
+func (c *Client) ModInverse(ctx context.Context, in *calculatorpb.ModInverseRequest, opts ...grpc.CallOption) (*calculatorpb.ModInverseResponse, error) {
+	c.Record(ctx, "ModInverse", in)
+	if c.ModInverseFunc == nil {
+		return nil, fakegrpc.Unimplemented("ModInverse")
+	}
+	return c.ModInverseFunc(ctx, in)
+}
+
+func (c *Client) PrimesInRange(ctx context.Context, in *calculatorpb.PrimesInRangeRequest, opts ...grpc.CallOption) (calculatorpb.CalculatorService_PrimesInRangeClient, error) {
+	c.Record(ctx, "PrimesInRange", in)
+	if c.PrimesInRangeFunc == nil {
+		return nil, fakegrpc.Unimplemented("PrimesInRange")
+	}
+	return c.PrimesInRangeFunc(ctx, in)
+}
+
+func (c *Client) ComputeMatrix(ctx context.Context, in *calculatorpb.MatrixRequest, opts ...grpc.CallOption) (*calculatorpb.MatrixResponse, error) {
+	c.Record(ctx, "ComputeMatrix", in)
+	if c.ComputeMatrixFunc == nil {
+		return nil, fakegrpc.Unimplemented("ComputeMatrix")
+	}
+	return c.ComputeMatrixFunc(ctx, in)
+}
+
+func (c *Client) StreamMatrix(ctx context.Context, opts ...grpc.CallOption) (calculatorpb.CalculatorService_StreamMatrixClient, error) {
+	c.Record(ctx, "StreamMatrix", nil)
+	if c.StreamMatrixFunc == nil {
+		return nil, fakegrpc.Unimplemented("StreamMatrix")
+	}
+	return c.StreamMatrixFunc(ctx)
+}
+
+func (c *Client) CreateSession(ctx context.Context, in *calculatorpb.CreateSessionRequest, opts ...grpc.CallOption) (*calculatorpb.CreateSessionResponse, error) {
+	c.Record(ctx, "CreateSession", in)
+	if c.CreateSessionFunc == nil {
+		return nil, fakegrpc.Unimplemented("CreateSession")
+	}
+	return c.CreateSessionFunc(ctx, in)
+}
+
+func (c *Client) EvaluateInSession(ctx context.Context, in *calculatorpb.EvaluateInSessionRequest, opts ...grpc.CallOption) (*calculatorpb.EvaluateInSessionResponse, error) {
+	c.Record(ctx, "EvaluateInSession", in)
+	if c.EvaluateInSessionFunc == nil {
+		return nil, fakegrpc.Unimplemented("EvaluateInSession")
+	}
+	return c.EvaluateInSessionFunc(ctx, in)
+}
+
+func (c *Client) GetHistory(ctx context.Context, in *calculatorpb.GetHistoryRequest, opts ...grpc.CallOption) (*calculatorpb.GetHistoryResponse, error) {
+	c.Record(ctx, "GetHistory", in)
+	if c.GetHistoryFunc == nil {
+		return nil, fakegrpc.Unimplemented("GetHistory")
+	}
+	return c.GetHistoryFunc(ctx, in)
+}
+
+func (c *Client) ClearSession(ctx context.Context, in *calculatorpb.ClearSessionRequest, opts ...grpc.CallOption) (*calculatorpb.ClearSessionResponse, error) {
+	c.Record(ctx, "ClearSession", in)
+	if c.ClearSessionFunc == nil {
+		return nil, fakegrpc.Unimplemented("ClearSession")
+	}
+	return c.ClearSessionFunc(ctx, in)
+}
+
+// PrimeNumberDecompositionStream is a fake calculatorpb.CalculatorService_PrimeNumberDecompositionClient.
+// Recv returns Responses in order, then Err, or io.EOF when Err is nil.
+type PrimeNumberDecompositionStream struct {
+	fakegrpc.ClientStream
+	Responses []*calculatorpb.PrimeNumberDecompositionResponse
+	Err       error
+
+	cursor fakegrpc.Cursor
+}
+
+var _ calculatorpb.CalculatorService_PrimeNumberDecompositionClient = (*PrimeNumberDecompositionStream)(nil)
+
+func (s *PrimeNumberDecompositionStream) Recv() (*calculatorpb.PrimeNumberDecompositionResponse, error) {
+	i, err := s.cursor.Next(len(s.Responses), s.Err)
+	if err != nil {
+		return nil, err
+	}
+	return s.Responses[i], nil
+}
+
+// ComputeAverageStream is a fake calculatorpb.CalculatorService_ComputeAverageClient.
+// It keeps the requests sent on it and answers CloseAndRecv with Reply,
+// or with Response and Err when Reply is nil.
+type ComputeAverageStream struct {
+	fakegrpc.ClientStream
+	Reply    func(sent []*calculatorpb.ComputeAverageRequest) (*calculatorpb.ComputeAverageResponse, error)
+	Response *calculatorpb.ComputeAverageResponse
+	Err      error
+	// SendErr, when set, fails every Send.
+	SendErr error
+
+	sent fakegrpc.Messages
+}
+
+var _ calculatorpb.CalculatorService_ComputeAverageClient = (*ComputeAverageStream)(nil)
+
+func (s *ComputeAverageStream) Send(req *calculatorpb.ComputeAverageRequest) error {
+	if s.SendErr != nil {
+		return s.SendErr
+	}
+	s.sent.Append(req)
+	return nil
+}
+
+// Sent returns the requests sent so far.
+func (s *ComputeAverageStream) Sent() []*calculatorpb.ComputeAverageRequest {
+	msgs := s.sent.List()
+	sent := make([]*calculatorpb.ComputeAverageRequest, len(msgs))
+	for i, msg := range msgs {
+		sent[i] = msg.(*calculatorpb.ComputeAverageRequest)
+	}
+	return sent
+}
+
+func (s *ComputeAverageStream) CloseAndRecv() (*calculatorpb.ComputeAverageResponse, error) {
+	s.CloseSend()
+	if s.Reply != nil {
+		return s.Reply(s.Sent())
+	}
+	return s.Response, s.Err
+}
+
+// ComputeStatisticsStream is a fake calculatorpb.CalculatorService_ComputeStatisticsClient.
+// It keeps the requests sent on it and answers CloseAndRecv with Reply,
+// or with Response and Err when Reply is nil.
+type ComputeStatisticsStream struct {
+	fakegrpc.ClientStream
+	Reply    func(sent []*calculatorpb.ComputeStatisticsRequest) (*calculatorpb.ComputeStatisticsResponse, error)
+	Response *calculatorpb.ComputeStatisticsResponse
+	Err      error
+	// SendErr, when set, fails every Send.
+	SendErr error
+
+	sent fakegrpc.Messages
+}
+
+var _ calculatorpb.CalculatorService_ComputeStatisticsClient = (*ComputeStatisticsStream)(nil)
+
+func (s *ComputeStatisticsStream) Send(req *calculatorpb.ComputeStatisticsRequest) error {
+	if s.SendErr != nil {
+		return s.SendErr
+	}
+	s.sent.Append(req)
+	return nil
+}
+
+// Sent returns the requests sent so far.
+func (s *ComputeStatisticsStream) Sent() []*calculatorpb.ComputeStatisticsRequest {
+	msgs := s.sent.List()
+	sent := make([]*calculatorpb.ComputeStatisticsRequest, len(msgs))
+	for i, msg := range msgs {
+		sent[i] = msg.(*calculatorpb.ComputeStatisticsRequest)
+	}
+	return sent
+}
+
+func (s *ComputeStatisticsStream) CloseAndRecv() (*calculatorpb.ComputeStatisticsResponse, error) {
+	s.CloseSend()
+	if s.Reply != nil {
+		return s.Reply(s.Sent())
+	}
+	return s.Response, s.Err
+}
+
+// FindMaximumStream is a fake calculatorpb.CalculatorService_FindMaximumClient.
+// Every request sent is passed to Reply: a non-nil response is queued for
+// Recv and an error ends the stream with that error. Recv blocks like a
+// real stream, until a response is queued, the stream ends or its context
+// is done, and returns io.EOF once CloseSend was called and every response
+// received.
+type FindMaximumStream struct {
+	fakegrpc.ClientStream
+	Reply func(req *calculatorpb.FindMaximumRequest) (*calculatorpb.FindMaximumResponse, error)
+
+	exchange fakegrpc.Exchange
+}
+
+var _ calculatorpb.CalculatorService_FindMaximumClient = (*FindMaximumStream)(nil)
+
+func (s *FindMaximumStream) Send(req *calculatorpb.FindMaximumRequest) error {
+	if s.Reply == nil {
+		return s.exchange.Send(req, nil)
+	}
+	return s.exchange.Send(req, func() (interface{}, error) {
+		res, err := s.Reply(req)
+		if res == nil {
+			return nil, err
+		}
+		return res, err
+	})
+}
+
+// Sent returns the requests sent so far.
+func (s *FindMaximumStream) Sent() []*calculatorpb.FindMaximumRequest {
+	msgs := s.exchange.List()
+	sent := make([]*calculatorpb.FindMaximumRequest, len(msgs))
+	for i, msg := range msgs {
+		sent[i] = msg.(*calculatorpb.FindMaximumRequest)
+	}
+	return sent
+}
+
+func (s *FindMaximumStream) CloseSend() error {
+	s.ClientStream.CloseSend()
+	s.exchange.CloseSend()
+	return nil
+}
+
+func (s *FindMaximumStream) Recv() (*calculatorpb.FindMaximumResponse, error) {
+	res, err := s.exchange.Recv(s.Context())
+	if err != nil {
+		return nil, err
+	}
+	return res.(*calculatorpb.FindMaximumResponse), nil
+}
+
+// StreamAggregateStream is a fake calculatorpb.CalculatorService_StreamAggregateClient.
+// Every request sent is passed to Reply: a non-nil response is queued for
+// Recv and an error ends the stream with that error. Recv blocks like a
+// real stream, until a response is queued, the stream ends or its context
+// is done, and returns io.EOF once CloseSend was called and every response
+// received.
+type StreamAggregateStream struct {
+	fakegrpc.ClientStream
+	Reply func(req *calculatorpb.StreamAggregateRequest) (*calculatorpb.StreamAggregateResponse, error)
+
+	exchange fakegrpc.Exchange
+}
+
+var _ calculatorpb.CalculatorService_StreamAggregateClient = (*StreamAggregateStream)(nil)
+
+func (s *StreamAggregateStream) Send(req *calculatorpb.StreamAggregateRequest) error {
+	if s.Reply == nil {
+		return s.exchange.Send(req, nil)
+	}
+	return s.exchange.Send(req, func() (interface{}, error) {
+		res, err := s.Reply(req)
+		if res == nil {
+			return nil, err
+		}
+		return res, err
+	})
+}
+
+// Sent returns the requests sent so far.
+func (s *StreamAggregateStream) Sent() []*calculatorpb.StreamAggregateRequest {
+	msgs := s.exchange.List()
+	sent := make([]*calculatorpb.StreamAggregateRequest, len(msgs))
+	for i, msg := range msgs {
+		sent[i] = msg.(*calculatorpb.StreamAggregateRequest)
+	}
+	return sent
+}
+
+func (s *StreamAggregateStream) CloseSend() error {
+	s.ClientStream.CloseSend()
+	s.exchange.CloseSend()
+	return nil
+}
+
+func (s *StreamAggregateStream) Recv() (*calculatorpb.StreamAggregateResponse, error) {
+	res, err := s.exchange.Recv(s.Context())
+	if err != nil {
+		return nil, err
+	}
+	return res.(*calculatorpb.StreamAggregateResponse), nil
+}
+
+// PrimesInRangeStream is a fake calculatorpb.CalculatorService_PrimesInRangeClient.
+// Recv returns Responses in order, then Err, or io.EOF when Err is nil.
+type PrimesInRangeStream struct {
+	fakegrpc.ClientStream
+	Responses []*calculatorpb.PrimesInRangeResponse
+	Err       error
+
+	cursor fakegrpc.Cursor
+}
+
+var _ calculatorpb.CalculatorService_PrimesInRangeClient = (*PrimesInRangeStream)(nil)
+
+func (s *PrimesInRangeStream) Recv() (*calculatorpb.PrimesInRangeResponse, error) {
+	i, err := s.cursor.Next(len(s.Responses), s.Err)
+	if err != nil {
+		return nil, err
+	}
+	return s.Responses[i], nil
+}
+
+// StreamMatrixStream is a fake calculatorpb.CalculatorService_StreamMatrixClient.
+// Every request sent is passed to Reply: a non-nil response is queued for
+// Recv and an error ends the stream with that error. Recv blocks like a
+// real stream, until a response is queued, the stream ends or its context
+// is done, and returns io.EOF once CloseSend was called and every response
+// received.
+type StreamMatrixStream struct {
+	fakegrpc.ClientStream
+	Reply func(req *calculatorpb.StreamMatrixRequest) (*calculatorpb.StreamMatrixResponse, error)
+
+	exchange fakegrpc.Exchange
+}
+
+var _ calculatorpb.CalculatorService_StreamMatrixClient = (*StreamMatrixStream)(nil)
+
+func (s *StreamMatrixStream) Send(req *calculatorpb.StreamMatrixRequest) error {
+	if s.Reply == nil {
+		return s.exchange.Send(req, nil)
+	}
+	return s.exchange.Send(req, func() (interface{}, error) {
+		res, err := s.Reply(req)
+		if res == nil {
+			return nil, err
+		}
+		return res, err
+	})
+}
+
+// Sent returns the requests sent so far.
+func (s *StreamMatrixStream) Sent() []*calculatorpb.StreamMatrixRequest {
+	msgs := s.exchange.List()
+	sent := make([]*calculatorpb.StreamMatrixRequest, len(msgs))
+	for i, msg := range msgs {
+		sent[i] = msg.(*calculatorpb.StreamMatrixRequest)
+	}
+	return sent
+}
+
+func (s *StreamMatrixStream) CloseSend() error {
+	s.ClientStream.CloseSend()
+	s.exchange.CloseSend()
+	return nil
+}
+
+func (s *StreamMatrixStream) Recv() (*calculatorpb.StreamMatrixResponse, error) {
+	res, err := s.exchange.Recv(s.Context())
+	if err != nil {
+		return nil, err
+	}
+	return res.(*calculatorpb.StreamMatrixResponse), nil
+}
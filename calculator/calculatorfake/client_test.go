@@ -0,0 +1,100 @@
+package calculatorfake
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestComputeAverageStream(t *testing.T) {
+	stream := &ComputeAverageStream{
+		Reply: func(sent []*calculatorpb.ComputeAverageRequest) (*calculatorpb.ComputeAverageResponse, error) {
+			if len(sent) == 0 {
+				return nil, status.Error(codes.InvalidArgument, "no numbers")
+			}
+			sum := int64(0)
+			for _, req := range sent {
+				sum += req.GetNumber()
+			}
+			return &calculatorpb.ComputeAverageResponse{Average: float64(sum) / float64(len(sent))}, nil
+		},
+	}
+	client := &Client{
+		ComputeAverageFunc: func(ctx context.Context) (calculatorpb.CalculatorService_ComputeAverageClient, error) {
+			return stream, nil
+		},
+	}
+
+	got, err := client.ComputeAverage(context.Background())
+	if err != nil {
+		t.Fatalf("ComputeAverage : %v", err)
+	}
+	for _, n := range []int64{1, 2, 3, 4} {
+		if err := got.Send(&calculatorpb.ComputeAverageRequest{Number: n}); err != nil {
+			t.Fatalf("Send : %v", err)
+		}
+	}
+	res, err := got.CloseAndRecv()
+	if err != nil || res.GetAverage() != 2.5 {
+		t.Fatalf("got %v, %v", res, err)
+	}
+	if len(stream.Sent()) != 4 || !stream.SendClosed() {
+		t.Errorf("stream kept %d requests, closed %v", len(stream.Sent()), stream.SendClosed())
+	}
+	if calls := client.CallsTo("ComputeAverage"); len(calls) != 1 || calls[0].Request != nil {
+		t.Errorf("recorded calls %v", calls)
+	}
+}
+
+func TestPrimeNumberDecompositionStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  *PrimeNumberDecompositionStream
+		want    []int64
+		wantErr error
+	}{
+		{
+			name: "responses then EOF",
+			stream: &PrimeNumberDecompositionStream{Responses: []*calculatorpb.PrimeNumberDecompositionResponse{
+				{PrimeFactor: 2}, {PrimeFactor: 5},
+			}},
+			want:    []int64{2, 5},
+			wantErr: io.EOF,
+		},
+		{
+			name: "responses then error",
+			stream: &PrimeNumberDecompositionStream{
+				Responses: []*calculatorpb.PrimeNumberDecompositionResponse{{PrimeFactor: 3}},
+				Err:       status.Error(codes.Unavailable, "gone"),
+			},
+			want: []int64{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int64
+			var err error
+			for {
+				var res *calculatorpb.PrimeNumberDecompositionResponse
+				if res, err = tt.stream.Recv(); err != nil {
+					break
+				}
+				got = append(got, res.GetPrimeFactor())
+			}
+			if tt.wantErr != nil && err != tt.wantErr {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				grpctest.AssertCode(t, err, codes.Unavailable)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
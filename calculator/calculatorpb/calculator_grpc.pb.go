@@ -21,8 +21,27 @@ type CalculatorServiceClient interface {
 	Sum(ctx context.Context, in *SumRequest, opts ...grpc.CallOption) (*SumResponse, error)
 	PrimeNumberDecomposition(ctx context.Context, in *PrimeNumberDecompositionRequest, opts ...grpc.CallOption) (CalculatorService_PrimeNumberDecompositionClient, error)
 	ComputeAverage(ctx context.Context, opts ...grpc.CallOption) (CalculatorService_ComputeAverageClient, error)
+	ComputeStatistics(ctx context.Context, opts ...grpc.CallOption) (CalculatorService_ComputeStatisticsClient, error)
 	FindMaximum(ctx context.Context, opts ...grpc.CallOption) (CalculatorService_FindMaximumClient, error)
+	StreamAggregate(ctx context.Context, opts ...grpc.CallOption) (CalculatorService_StreamAggregateClient, error)
 	SquareRoot(ctx context.Context, in *SquareRootRequest, opts ...grpc.CallOption) (*SquareRootResponse, error)
+	Root(ctx context.Context, in *RootRequest, opts ...grpc.CallOption) (*RootResponse, error)
+	BigArithmetic(ctx context.Context, in *BigArithmeticRequest, opts ...grpc.CallOption) (*BigArithmeticResponse, error)
+	Evaluate(ctx context.Context, in *EvaluateRequest, opts ...grpc.CallOption) (*EvaluateResponse, error)
+	IsPrime(ctx context.Context, in *IsPrimeRequest, opts ...grpc.CallOption) (*IsPrimeResponse, error)
+	GCD(ctx context.Context, in *GCDRequest, opts ...grpc.CallOption) (*GCDResponse, error)
+	LCM(ctx context.Context, in *LCMRequest, opts ...grpc.CallOption) (*LCMResponse, error)
+	ModPow(ctx context.Context, in *ModPowRequest, opts ...grpc.CallOption) (*ModPowResponse, error)
+	ModInverse(ctx context.Context, in *ModInverseRequest, opts ...grpc.CallOption) (*ModInverseResponse, error)
+	PrimesInRange(ctx context.Context, in *PrimesInRangeRequest, opts ...grpc.CallOption) (CalculatorService_PrimesInRangeClient, error)
+	ComputeMatrix(ctx context.Context, in *MatrixRequest, opts ...grpc.CallOption) (*MatrixResponse, error)
+	// sends matrices too large for a single message row by row
+	StreamMatrix(ctx context.Context, opts ...grpc.CallOption) (CalculatorService_StreamMatrixClient, error)
+	CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*CreateSessionResponse, error)
+	EvaluateInSession(ctx context.Context, in *EvaluateInSessionRequest, opts ...grpc.CallOption) (*EvaluateInSessionResponse, error)
+	GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error)
+	// ClearSession forgets the history and variables of a session
+	ClearSession(ctx context.Context, in *ClearSessionRequest, opts ...grpc.CallOption) (*ClearSessionResponse, error)
 }
 
 type calculatorServiceClient struct {
@@ -108,8 +127,42 @@ func (x *calculatorServiceComputeAverageClient) CloseAndRecv() (*ComputeAverageR
 	return m, nil
 }
 
+func (c *calculatorServiceClient) ComputeStatistics(ctx context.Context, opts ...grpc.CallOption) (CalculatorService_ComputeStatisticsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CalculatorService_ServiceDesc.Streams[2], "/calculator.CalculatorService/ComputeStatistics", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &calculatorServiceComputeStatisticsClient{stream}
+	return x, nil
+}
+
+type CalculatorService_ComputeStatisticsClient interface {
+	Send(*ComputeStatisticsRequest) error
+	CloseAndRecv() (*ComputeStatisticsResponse, error)
+	grpc.ClientStream
+}
+
+type calculatorServiceComputeStatisticsClient struct {
+	grpc.ClientStream
+}
+
+func (x *calculatorServiceComputeStatisticsClient) Send(m *ComputeStatisticsRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *calculatorServiceComputeStatisticsClient) CloseAndRecv() (*ComputeStatisticsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ComputeStatisticsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *calculatorServiceClient) FindMaximum(ctx context.Context, opts ...grpc.CallOption) (CalculatorService_FindMaximumClient, error) {
-	stream, err := c.cc.NewStream(ctx, &CalculatorService_ServiceDesc.Streams[2], "/calculator.CalculatorService/FindMaximum", opts...)
+	stream, err := c.cc.NewStream(ctx, &CalculatorService_ServiceDesc.Streams[3], "/calculator.CalculatorService/FindMaximum", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -139,6 +192,37 @@ func (x *calculatorServiceFindMaximumClient) Recv() (*FindMaximumResponse, error
 	return m, nil
 }
 
+func (c *calculatorServiceClient) StreamAggregate(ctx context.Context, opts ...grpc.CallOption) (CalculatorService_StreamAggregateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CalculatorService_ServiceDesc.Streams[4], "/calculator.CalculatorService/StreamAggregate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &calculatorServiceStreamAggregateClient{stream}
+	return x, nil
+}
+
+type CalculatorService_StreamAggregateClient interface {
+	Send(*StreamAggregateRequest) error
+	Recv() (*StreamAggregateResponse, error)
+	grpc.ClientStream
+}
+
+type calculatorServiceStreamAggregateClient struct {
+	grpc.ClientStream
+}
+
+func (x *calculatorServiceStreamAggregateClient) Send(m *StreamAggregateRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *calculatorServiceStreamAggregateClient) Recv() (*StreamAggregateResponse, error) {
+	m := new(StreamAggregateResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *calculatorServiceClient) SquareRoot(ctx context.Context, in *SquareRootRequest, opts ...grpc.CallOption) (*SquareRootResponse, error) {
 	out := new(SquareRootResponse)
 	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/SquareRoot", in, out, opts...)
@@ -148,6 +232,186 @@ func (c *calculatorServiceClient) SquareRoot(ctx context.Context, in *SquareRoot
 	return out, nil
 }
 
+func (c *calculatorServiceClient) Root(ctx context.Context, in *RootRequest, opts ...grpc.CallOption) (*RootResponse, error) {
+	out := new(RootResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/Root", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) BigArithmetic(ctx context.Context, in *BigArithmeticRequest, opts ...grpc.CallOption) (*BigArithmeticResponse, error) {
+	out := new(BigArithmeticResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/BigArithmetic", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) Evaluate(ctx context.Context, in *EvaluateRequest, opts ...grpc.CallOption) (*EvaluateResponse, error) {
+	out := new(EvaluateResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/Evaluate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) IsPrime(ctx context.Context, in *IsPrimeRequest, opts ...grpc.CallOption) (*IsPrimeResponse, error) {
+	out := new(IsPrimeResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/IsPrime", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) GCD(ctx context.Context, in *GCDRequest, opts ...grpc.CallOption) (*GCDResponse, error) {
+	out := new(GCDResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/GCD", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) LCM(ctx context.Context, in *LCMRequest, opts ...grpc.CallOption) (*LCMResponse, error) {
+	out := new(LCMResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/LCM", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) ModPow(ctx context.Context, in *ModPowRequest, opts ...grpc.CallOption) (*ModPowResponse, error) {
+	out := new(ModPowResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/ModPow", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) ModInverse(ctx context.Context, in *ModInverseRequest, opts ...grpc.CallOption) (*ModInverseResponse, error) {
+	out := new(ModInverseResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/ModInverse", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) PrimesInRange(ctx context.Context, in *PrimesInRangeRequest, opts ...grpc.CallOption) (CalculatorService_PrimesInRangeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CalculatorService_ServiceDesc.Streams[5], "/calculator.CalculatorService/PrimesInRange", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &calculatorServicePrimesInRangeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CalculatorService_PrimesInRangeClient interface {
+	Recv() (*PrimesInRangeResponse, error)
+	grpc.ClientStream
+}
+
+type calculatorServicePrimesInRangeClient struct {
+	grpc.ClientStream
+}
+
+func (x *calculatorServicePrimesInRangeClient) Recv() (*PrimesInRangeResponse, error) {
+	m := new(PrimesInRangeResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *calculatorServiceClient) ComputeMatrix(ctx context.Context, in *MatrixRequest, opts ...grpc.CallOption) (*MatrixResponse, error) {
+	out := new(MatrixResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/ComputeMatrix", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) StreamMatrix(ctx context.Context, opts ...grpc.CallOption) (CalculatorService_StreamMatrixClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CalculatorService_ServiceDesc.Streams[6], "/calculator.CalculatorService/StreamMatrix", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &calculatorServiceStreamMatrixClient{stream}
+	return x, nil
+}
+
+type CalculatorService_StreamMatrixClient interface {
+	Send(*StreamMatrixRequest) error
+	Recv() (*StreamMatrixResponse, error)
+	grpc.ClientStream
+}
+
+type calculatorServiceStreamMatrixClient struct {
+	grpc.ClientStream
+}
+
+func (x *calculatorServiceStreamMatrixClient) Send(m *StreamMatrixRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *calculatorServiceStreamMatrixClient) Recv() (*StreamMatrixResponse, error) {
+	m := new(StreamMatrixResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *calculatorServiceClient) CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*CreateSessionResponse, error) {
+	out := new(CreateSessionResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/CreateSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) EvaluateInSession(ctx context.Context, in *EvaluateInSessionRequest, opts ...grpc.CallOption) (*EvaluateInSessionResponse, error) {
+	out := new(EvaluateInSessionResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/EvaluateInSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error) {
+	out := new(GetHistoryResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/GetHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorServiceClient) ClearSession(ctx context.Context, in *ClearSessionRequest, opts ...grpc.CallOption) (*ClearSessionResponse, error) {
+	out := new(ClearSessionResponse)
+	err := c.cc.Invoke(ctx, "/calculator.CalculatorService/ClearSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CalculatorServiceServer is the server API for CalculatorService service.
 // All implementations must embed UnimplementedCalculatorServiceServer
 // for forward compatibility
@@ -155,8 +419,27 @@ type CalculatorServiceServer interface {
 	Sum(context.Context, *SumRequest) (*SumResponse, error)
 	PrimeNumberDecomposition(*PrimeNumberDecompositionRequest, CalculatorService_PrimeNumberDecompositionServer) error
 	ComputeAverage(CalculatorService_ComputeAverageServer) error
+	ComputeStatistics(CalculatorService_ComputeStatisticsServer) error
 	FindMaximum(CalculatorService_FindMaximumServer) error
+	StreamAggregate(CalculatorService_StreamAggregateServer) error
 	SquareRoot(context.Context, *SquareRootRequest) (*SquareRootResponse, error)
+	Root(context.Context, *RootRequest) (*RootResponse, error)
+	BigArithmetic(context.Context, *BigArithmeticRequest) (*BigArithmeticResponse, error)
+	Evaluate(context.Context, *EvaluateRequest) (*EvaluateResponse, error)
+	IsPrime(context.Context, *IsPrimeRequest) (*IsPrimeResponse, error)
+	GCD(context.Context, *GCDRequest) (*GCDResponse, error)
+	LCM(context.Context, *LCMRequest) (*LCMResponse, error)
+	ModPow(context.Context, *ModPowRequest) (*ModPowResponse, error)
+	ModInverse(context.Context, *ModInverseRequest) (*ModInverseResponse, error)
+	PrimesInRange(*PrimesInRangeRequest, CalculatorService_PrimesInRangeServer) error
+	ComputeMatrix(context.Context, *MatrixRequest) (*MatrixResponse, error)
+	// sends matrices too large for a single message row by row
+	StreamMatrix(CalculatorService_StreamMatrixServer) error
+	CreateSession(context.Context, *CreateSessionRequest) (*CreateSessionResponse, error)
+	EvaluateInSession(context.Context, *EvaluateInSessionRequest) (*EvaluateInSessionResponse, error)
+	GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error)
+	// ClearSession forgets the history and variables of a session
+	ClearSession(context.Context, *ClearSessionRequest) (*ClearSessionResponse, error)
 	mustEmbedUnimplementedCalculatorServiceServer()
 }
 
@@ -173,12 +456,63 @@ func (UnimplementedCalculatorServiceServer) PrimeNumberDecomposition(*PrimeNumbe
 func (UnimplementedCalculatorServiceServer) ComputeAverage(CalculatorService_ComputeAverageServer) error {
 	return status.Errorf(codes.Unimplemented, "method ComputeAverage not implemented")
 }
+func (UnimplementedCalculatorServiceServer) ComputeStatistics(CalculatorService_ComputeStatisticsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ComputeStatistics not implemented")
+}
 func (UnimplementedCalculatorServiceServer) FindMaximum(CalculatorService_FindMaximumServer) error {
 	return status.Errorf(codes.Unimplemented, "method FindMaximum not implemented")
 }
+func (UnimplementedCalculatorServiceServer) StreamAggregate(CalculatorService_StreamAggregateServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamAggregate not implemented")
+}
 func (UnimplementedCalculatorServiceServer) SquareRoot(context.Context, *SquareRootRequest) (*SquareRootResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SquareRoot not implemented")
 }
+func (UnimplementedCalculatorServiceServer) Root(context.Context, *RootRequest) (*RootResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Root not implemented")
+}
+func (UnimplementedCalculatorServiceServer) BigArithmetic(context.Context, *BigArithmeticRequest) (*BigArithmeticResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BigArithmetic not implemented")
+}
+func (UnimplementedCalculatorServiceServer) Evaluate(context.Context, *EvaluateRequest) (*EvaluateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Evaluate not implemented")
+}
+func (UnimplementedCalculatorServiceServer) IsPrime(context.Context, *IsPrimeRequest) (*IsPrimeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsPrime not implemented")
+}
+func (UnimplementedCalculatorServiceServer) GCD(context.Context, *GCDRequest) (*GCDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GCD not implemented")
+}
+func (UnimplementedCalculatorServiceServer) LCM(context.Context, *LCMRequest) (*LCMResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LCM not implemented")
+}
+func (UnimplementedCalculatorServiceServer) ModPow(context.Context, *ModPowRequest) (*ModPowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ModPow not implemented")
+}
+func (UnimplementedCalculatorServiceServer) ModInverse(context.Context, *ModInverseRequest) (*ModInverseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ModInverse not implemented")
+}
+func (UnimplementedCalculatorServiceServer) PrimesInRange(*PrimesInRangeRequest, CalculatorService_PrimesInRangeServer) error {
+	return status.Errorf(codes.Unimplemented, "method PrimesInRange not implemented")
+}
+func (UnimplementedCalculatorServiceServer) ComputeMatrix(context.Context, *MatrixRequest) (*MatrixResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ComputeMatrix not implemented")
+}
+func (UnimplementedCalculatorServiceServer) StreamMatrix(CalculatorService_StreamMatrixServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamMatrix not implemented")
+}
+func (UnimplementedCalculatorServiceServer) CreateSession(context.Context, *CreateSessionRequest) (*CreateSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSession not implemented")
+}
+func (UnimplementedCalculatorServiceServer) EvaluateInSession(context.Context, *EvaluateInSessionRequest) (*EvaluateInSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EvaluateInSession not implemented")
+}
+func (UnimplementedCalculatorServiceServer) GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistory not implemented")
+}
+func (UnimplementedCalculatorServiceServer) ClearSession(context.Context, *ClearSessionRequest) (*ClearSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearSession not implemented")
+}
 func (UnimplementedCalculatorServiceServer) mustEmbedUnimplementedCalculatorServiceServer() {}
 
 // UnsafeCalculatorServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -257,6 +591,32 @@ func (x *calculatorServiceComputeAverageServer) Recv() (*ComputeAverageRequest,
 	return m, nil
 }
 
+func _CalculatorService_ComputeStatistics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CalculatorServiceServer).ComputeStatistics(&calculatorServiceComputeStatisticsServer{stream})
+}
+
+type CalculatorService_ComputeStatisticsServer interface {
+	SendAndClose(*ComputeStatisticsResponse) error
+	Recv() (*ComputeStatisticsRequest, error)
+	grpc.ServerStream
+}
+
+type calculatorServiceComputeStatisticsServer struct {
+	grpc.ServerStream
+}
+
+func (x *calculatorServiceComputeStatisticsServer) SendAndClose(m *ComputeStatisticsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *calculatorServiceComputeStatisticsServer) Recv() (*ComputeStatisticsRequest, error) {
+	m := new(ComputeStatisticsRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _CalculatorService_FindMaximum_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(CalculatorServiceServer).FindMaximum(&calculatorServiceFindMaximumServer{stream})
 }
@@ -283,6 +643,32 @@ func (x *calculatorServiceFindMaximumServer) Recv() (*FindMaximumRequest, error)
 	return m, nil
 }
 
+func _CalculatorService_StreamAggregate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CalculatorServiceServer).StreamAggregate(&calculatorServiceStreamAggregateServer{stream})
+}
+
+type CalculatorService_StreamAggregateServer interface {
+	Send(*StreamAggregateResponse) error
+	Recv() (*StreamAggregateRequest, error)
+	grpc.ServerStream
+}
+
+type calculatorServiceStreamAggregateServer struct {
+	grpc.ServerStream
+}
+
+func (x *calculatorServiceStreamAggregateServer) Send(m *StreamAggregateResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *calculatorServiceStreamAggregateServer) Recv() (*StreamAggregateRequest, error) {
+	m := new(StreamAggregateRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _CalculatorService_SquareRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SquareRootRequest)
 	if err := dec(in); err != nil {
@@ -301,6 +687,287 @@ func _CalculatorService_SquareRoot_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CalculatorService_Root_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).Root(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/Root",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).Root(ctx, req.(*RootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_BigArithmetic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BigArithmeticRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).BigArithmetic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/BigArithmetic",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).BigArithmetic(ctx, req.(*BigArithmeticRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_Evaluate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvaluateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).Evaluate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/Evaluate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).Evaluate(ctx, req.(*EvaluateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_IsPrime_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IsPrimeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).IsPrime(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/IsPrime",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).IsPrime(ctx, req.(*IsPrimeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_GCD_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GCDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).GCD(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/GCD",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).GCD(ctx, req.(*GCDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_LCM_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LCMRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).LCM(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/LCM",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).LCM(ctx, req.(*LCMRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_ModPow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModPowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).ModPow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/ModPow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).ModPow(ctx, req.(*ModPowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_ModInverse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModInverseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).ModInverse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/ModInverse",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).ModInverse(ctx, req.(*ModInverseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_PrimesInRange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PrimesInRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CalculatorServiceServer).PrimesInRange(m, &calculatorServicePrimesInRangeServer{stream})
+}
+
+type CalculatorService_PrimesInRangeServer interface {
+	Send(*PrimesInRangeResponse) error
+	grpc.ServerStream
+}
+
+type calculatorServicePrimesInRangeServer struct {
+	grpc.ServerStream
+}
+
+func (x *calculatorServicePrimesInRangeServer) Send(m *PrimesInRangeResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CalculatorService_ComputeMatrix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MatrixRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).ComputeMatrix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/ComputeMatrix",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).ComputeMatrix(ctx, req.(*MatrixRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_StreamMatrix_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CalculatorServiceServer).StreamMatrix(&calculatorServiceStreamMatrixServer{stream})
+}
+
+type CalculatorService_StreamMatrixServer interface {
+	Send(*StreamMatrixResponse) error
+	Recv() (*StreamMatrixRequest, error)
+	grpc.ServerStream
+}
+
+type calculatorServiceStreamMatrixServer struct {
+	grpc.ServerStream
+}
+
+func (x *calculatorServiceStreamMatrixServer) Send(m *StreamMatrixResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *calculatorServiceStreamMatrixServer) Recv() (*StreamMatrixRequest, error) {
+	m := new(StreamMatrixRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _CalculatorService_CreateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).CreateSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/CreateSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).CreateSession(ctx, req.(*CreateSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_EvaluateInSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvaluateInSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).EvaluateInSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/EvaluateInSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).EvaluateInSession(ctx, req.(*EvaluateInSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_GetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).GetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/GetHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).GetHistory(ctx, req.(*GetHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalculatorService_ClearSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServiceServer).ClearSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator.CalculatorService/ClearSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServiceServer).ClearSession(ctx, req.(*ClearSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CalculatorService_ServiceDesc is the grpc.ServiceDesc for CalculatorService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -316,6 +983,58 @@ var CalculatorService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SquareRoot",
 			Handler:    _CalculatorService_SquareRoot_Handler,
 		},
+		{
+			MethodName: "Root",
+			Handler:    _CalculatorService_Root_Handler,
+		},
+		{
+			MethodName: "BigArithmetic",
+			Handler:    _CalculatorService_BigArithmetic_Handler,
+		},
+		{
+			MethodName: "Evaluate",
+			Handler:    _CalculatorService_Evaluate_Handler,
+		},
+		{
+			MethodName: "IsPrime",
+			Handler:    _CalculatorService_IsPrime_Handler,
+		},
+		{
+			MethodName: "GCD",
+			Handler:    _CalculatorService_GCD_Handler,
+		},
+		{
+			MethodName: "LCM",
+			Handler:    _CalculatorService_LCM_Handler,
+		},
+		{
+			MethodName: "ModPow",
+			Handler:    _CalculatorService_ModPow_Handler,
+		},
+		{
+			MethodName: "ModInverse",
+			Handler:    _CalculatorService_ModInverse_Handler,
+		},
+		{
+			MethodName: "ComputeMatrix",
+			Handler:    _CalculatorService_ComputeMatrix_Handler,
+		},
+		{
+			MethodName: "CreateSession",
+			Handler:    _CalculatorService_CreateSession_Handler,
+		},
+		{
+			MethodName: "EvaluateInSession",
+			Handler:    _CalculatorService_EvaluateInSession_Handler,
+		},
+		{
+			MethodName: "GetHistory",
+			Handler:    _CalculatorService_GetHistory_Handler,
+		},
+		{
+			MethodName: "ClearSession",
+			Handler:    _CalculatorService_ClearSession_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -328,12 +1047,34 @@ var CalculatorService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _CalculatorService_ComputeAverage_Handler,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "ComputeStatistics",
+			Handler:       _CalculatorService_ComputeStatistics_Handler,
+			ClientStreams: true,
+		},
 		{
 			StreamName:    "FindMaximum",
 			Handler:       _CalculatorService_FindMaximum_Handler,
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "StreamAggregate",
+			Handler:       _CalculatorService_StreamAggregate_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "PrimesInRange",
+			Handler:       _CalculatorService_PrimesInRange_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamMatrix",
+			Handler:       _CalculatorService_StreamMatrix_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "calculator/calculatorpb/calculator.proto",
 }
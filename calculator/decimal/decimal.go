@@ -0,0 +1,348 @@
+// Package decimal implements arbitrary-precision decimal numbers, so the
+// calculator can add, divide or raise numbers of any size without integer
+// overflow or binary floating point error.
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode decides how a result is rounded to the requested scale.
+type RoundingMode int
+
+const (
+	// HalfEven rounds to the nearest neighbour, ties to the even one
+	// (banker's rounding).
+	HalfEven RoundingMode = iota
+	// HalfUp rounds to the nearest neighbour, ties away from zero.
+	HalfUp
+	// HalfDown rounds to the nearest neighbour, ties towards zero.
+	HalfDown
+	// Up rounds away from zero.
+	Up
+	// Down rounds towards zero (truncation).
+	Down
+	// Ceiling rounds towards positive infinity.
+	Ceiling
+	// Floor rounds towards negative infinity.
+	Floor
+)
+
+// Errors returned by the operations.
+var (
+	ErrSyntax         = errors.New("invalid decimal number")
+	ErrDivisionByZero = errors.New("division by zero")
+	ErrNotInteger     = errors.New("exponent must be an integer")
+	ErrTooLarge       = errors.New("result too large")
+)
+
+// MaxDigits bounds the number of digits of any value, to keep operations
+// like Pow from exhausting memory.
+const MaxDigits = 10000
+
+var (
+	bigOne = big.NewInt(1)
+	bigTen = big.NewInt(10)
+)
+
+// Decimal is the number unscaled * 10^-scale. The zero value is 0.
+type Decimal struct {
+	unscaled big.Int
+	scale    int
+}
+
+// Parse reads numbers like "-12", "3.1400" or "6.02e23".
+func Parse(s string) (*Decimal, error) {
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(s[i+1:])
+		if err != nil || exp > MaxDigits || exp < -MaxDigits {
+			return nil, fmt.Errorf("%w %q", ErrSyntax, s)
+		}
+		mantissa, exponent = s[:i], exp
+	}
+
+	sign := ""
+	if strings.HasPrefix(mantissa, "-") || strings.HasPrefix(mantissa, "+") {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	intPart, fracPart := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		intPart, fracPart = mantissa[:i], mantissa[i+1:]
+	}
+	if intPart == "" && fracPart == "" || !digitsOnly(intPart) || !digitsOnly(fracPart) {
+		return nil, fmt.Errorf("%w %q", ErrSyntax, s)
+	}
+
+	d := &Decimal{scale: len(fracPart) - exponent}
+	if _, ok := d.unscaled.SetString(sign+intPart+fracPart, 10); !ok {
+		return nil, fmt.Errorf("%w %q", ErrSyntax, s)
+	}
+	if d.scale < 0 {
+		d.unscaled.Mul(&d.unscaled, pow10(-d.scale))
+		d.scale = 0
+	}
+	if d.digits() > MaxDigits {
+		return nil, ErrTooLarge
+	}
+	return d, nil
+}
+
+func digitsOnly(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// New returns unscaled * 10^-scale.
+func New(unscaled int64, scale int) *Decimal {
+	d := &Decimal{scale: scale}
+	d.unscaled.SetInt64(unscaled)
+	if scale < 0 {
+		// keep the scale non-negative, as String and the operations expect
+		d.unscaled.Mul(&d.unscaled, pow10(-scale))
+		d.scale = 0
+	}
+	return d
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// digits returns the number of digits of the unscaled value.
+func (d *Decimal) digits() int {
+	if d.unscaled.Sign() == 0 {
+		return 1
+	}
+	return len(new(big.Int).Abs(&d.unscaled).String())
+}
+
+func (d *Decimal) checkSize() (*Decimal, error) {
+	if d.digits() > MaxDigits || d.scale > MaxDigits {
+		return nil, ErrTooLarge
+	}
+	return d, nil
+}
+
+// String formats d without exponent, e.g. "-0.0125".
+func (d *Decimal) String() string {
+	s := new(big.Int).Abs(&d.unscaled).String()
+	if d.scale > 0 {
+		if len(s) <= d.scale {
+			s = strings.Repeat("0", d.scale-len(s)+1) + s
+		}
+		s = s[:len(s)-d.scale] + "." + s[len(s)-d.scale:]
+	}
+	if d.unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Sign returns -1, 0 or +1.
+func (d *Decimal) Sign() int {
+	return d.unscaled.Sign()
+}
+
+// IsInteger reports whether d has no fractional part.
+func (d *Decimal) IsInteger() bool {
+	return new(big.Int).Rem(&d.unscaled, pow10(d.scale)).Sign() == 0
+}
+
+// rescaled returns the unscaled value of d at a scale not below its own.
+func (d *Decimal) rescaled(scale int) *big.Int {
+	return new(big.Int).Mul(&d.unscaled, pow10(scale-d.scale))
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Add returns a + b exactly.
+func Add(a, b *Decimal) (*Decimal, error) {
+	scale := maxInt(a.scale, b.scale)
+	d := &Decimal{scale: scale}
+	d.unscaled.Add(a.rescaled(scale), b.rescaled(scale))
+	return d.checkSize()
+}
+
+// Sub returns a - b exactly.
+func Sub(a, b *Decimal) (*Decimal, error) {
+	scale := maxInt(a.scale, b.scale)
+	d := &Decimal{scale: scale}
+	d.unscaled.Sub(a.rescaled(scale), b.rescaled(scale))
+	return d.checkSize()
+}
+
+// Mul returns a * b exactly.
+func Mul(a, b *Decimal) (*Decimal, error) {
+	if a.digits()+b.digits() > MaxDigits+1 {
+		return nil, ErrTooLarge
+	}
+	d := &Decimal{scale: a.scale + b.scale}
+	d.unscaled.Mul(&a.unscaled, &b.unscaled)
+	return d.checkSize()
+}
+
+// Quo returns a / b rounded to scale digits after the decimal point.
+func Quo(a, b *Decimal, scale int, mode RoundingMode) (*Decimal, error) {
+	if b.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	// a/b * 10^scale = a.u * 10^(scale - a.scale + b.scale) / b.u
+	num := new(big.Int).Set(&a.unscaled)
+	den := new(big.Int).Set(&b.unscaled)
+	if shift := scale - a.scale + b.scale; shift >= 0 {
+		if shift > 2*MaxDigits {
+			return nil, ErrTooLarge
+		}
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	d := &Decimal{scale: scale}
+	d.unscaled.Set(roundQuo(num, den, mode))
+	return d.checkSize()
+}
+
+// Mod returns the remainder of a / b truncated towards zero, which has the
+// sign of a, like Go's % operator.
+func Mod(a, b *Decimal) (*Decimal, error) {
+	if b.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	scale := maxInt(a.scale, b.scale)
+	d := &Decimal{scale: scale}
+	d.unscaled.Rem(a.rescaled(scale), b.rescaled(scale))
+	return d.checkSize()
+}
+
+// Pow returns a raised to the integer exponent. Negative exponents divide,
+// rounding to scale digits with mode; positive ones are exact.
+func Pow(a, exponent *Decimal, scale int, mode RoundingMode) (*Decimal, error) {
+	if !exponent.IsInteger() {
+		return nil, ErrNotInteger
+	}
+	n := new(big.Int).Quo(&exponent.unscaled, pow10(exponent.scale))
+	negative := n.Sign() < 0
+	n.Abs(n)
+
+	d := &Decimal{}
+	switch {
+	case a.Sign() == 0:
+		if negative {
+			return nil, ErrDivisionByZero
+		}
+		if n.Sign() == 0 {
+			d.unscaled.SetInt64(1)
+		}
+		return d, nil
+	case isUnit(a):
+		// 1 and -1 keep their size whatever the exponent
+		d.unscaled.SetInt64(1)
+		if a.Sign() < 0 && n.Bit(0) == 1 {
+			d.unscaled.Neg(&d.unscaled)
+		}
+		return d, nil
+	}
+
+	// the result has at least (digits(a)-1)*n+1 digits
+	if !n.IsInt64() || n.Int64() > MaxDigits || int64(a.digits()-1)*n.Int64() > MaxDigits {
+		return nil, ErrTooLarge
+	}
+	e := int(n.Int64())
+	d.scale = a.scale * e
+	d.unscaled.Exp(&a.unscaled, big.NewInt(int64(e)), nil)
+	if _, err := d.checkSize(); err != nil {
+		return nil, err
+	}
+	if negative {
+		return Quo(New(1, 0), d, scale, mode)
+	}
+	return d, nil
+}
+
+// isUnit reports whether a is 1 or -1.
+func isUnit(a *Decimal) bool {
+	return new(big.Int).Abs(&a.unscaled).Cmp(pow10(a.scale)) == 0
+}
+
+// Round returns d rounded to scale digits after the decimal point. Rounding
+// to a larger scale pads with zeros.
+func (d *Decimal) Round(scale int, mode RoundingMode) *Decimal {
+	r := &Decimal{scale: scale}
+	if scale >= d.scale {
+		r.unscaled.Set(d.rescaled(scale))
+		return r
+	}
+	r.unscaled.Set(roundQuo(new(big.Int).Set(&d.unscaled), pow10(d.scale-scale), mode))
+	return r
+}
+
+// Trim removes trailing zeros after the decimal point, e.g. 2.500 is 2.5.
+func (d *Decimal) Trim() *Decimal {
+	r := &Decimal{scale: d.scale}
+	r.unscaled.Set(&d.unscaled)
+	q, m := new(big.Int), new(big.Int)
+	for r.scale > 0 {
+		q.QuoRem(&r.unscaled, bigTen, m)
+		if m.Sign() != 0 {
+			break
+		}
+		r.unscaled.Set(q)
+		r.scale--
+	}
+	return r
+}
+
+// roundQuo returns num / den rounded to an integer with mode.
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// sign of the exact quotient, q alone may be zero
+	sign := num.Sign() * den.Sign()
+
+	// compare twice the remainder with the divisor to locate the half
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	half := twice.Cmp(new(big.Int).Abs(den))
+
+	var away bool
+	switch mode {
+	case HalfEven:
+		away = half > 0 || half == 0 && q.Bit(0) == 1
+	case HalfUp:
+		away = half >= 0
+	case HalfDown:
+		away = half > 0
+	case Up:
+		away = true
+	case Down:
+		away = false
+	case Ceiling:
+		away = sign > 0
+	case Floor:
+		away = sign < 0
+	}
+	if away {
+		if sign > 0 {
+			q.Add(q, bigOne)
+		} else {
+			q.Sub(q, bigOne)
+		}
+	}
+	return q
+}
@@ -0,0 +1,234 @@
+package decimal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, s string) *Decimal {
+	t.Helper()
+	d, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q) : %v", s, err)
+	}
+	return d
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in, want string
+		err      error
+	}{
+		{in: "0", want: "0"},
+		{in: "-12", want: "-12"},
+		{in: "+3.1400", want: "3.1400"},
+		{in: ".5", want: "0.5"},
+		{in: "5.", want: "5"},
+		{in: "-0.0125", want: "-0.0125"},
+		{in: "6.02e3", want: "6020"},
+		{in: "1.5E-3", want: "0.0015"},
+		{in: "99999999999999999999999999999999", want: "99999999999999999999999999999999"},
+		{in: "", err: ErrSyntax},
+		{in: ".", err: ErrSyntax},
+		{in: "1.2.3", err: ErrSyntax},
+		{in: "12a", err: ErrSyntax},
+		{in: "1e", err: ErrSyntax},
+		{in: "--1", err: ErrSyntax},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse(%q) error = %v, want %v", tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("Parse(%q) = %v, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func(a, b *Decimal) (*Decimal, error)
+		a, b string
+		want string
+		err  error
+	}{
+		{name: "add", op: Add, a: "0.1", b: "0.2", want: "0.3"},
+		{name: "add carry", op: Add, a: "99999999999999999999", b: "1", want: "100000000000000000000"},
+		{name: "sub", op: Sub, a: "1.5", b: "2.25", want: "-0.75"},
+		{name: "mul", op: Mul, a: "123456789012345678901234567890", b: "-2", want: "-246913578024691357802469135780"},
+		{name: "mul scale", op: Mul, a: "1.5", b: "0.02", want: "0.030"},
+		{name: "mod", op: Mod, a: "10", b: "3", want: "1"},
+		{name: "mod negative", op: Mod, a: "-10", b: "3", want: "-1"},
+		{name: "mod fraction", op: Mod, a: "5.5", b: "2", want: "1.5"},
+		{name: "mod zero", op: Mod, a: "1", b: "0", err: ErrDivisionByZero},
+	}
+	for _, tt := range tests {
+		got, err := tt.op(mustParse(t, tt.a), mustParse(t, tt.b))
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("%s: got %v, %v, want %s", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestQuo(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		scale int
+		mode  RoundingMode
+		want  string
+	}{
+		{a: "1", b: "3", scale: 5, mode: HalfEven, want: "0.33333"},
+		{a: "2", b: "3", scale: 5, mode: HalfEven, want: "0.66667"},
+		{a: "2", b: "3", scale: 5, mode: Down, want: "0.66666"},
+		{a: "-2", b: "3", scale: 2, mode: Floor, want: "-0.67"},
+		{a: "-2", b: "3", scale: 2, mode: Ceiling, want: "-0.66"},
+		{a: "1", b: "8", scale: 2, mode: HalfEven, want: "0.12"},
+		{a: "3", b: "8", scale: 2, mode: HalfEven, want: "0.38"},
+		{a: "1", b: "8", scale: 2, mode: HalfUp, want: "0.13"},
+		{a: "1", b: "8", scale: 2, mode: HalfDown, want: "0.12"},
+		{a: "-1", b: "8", scale: 2, mode: HalfUp, want: "-0.13"},
+		{a: "1", b: "1000", scale: 2, mode: Up, want: "0.01"},
+		{a: "10", b: "4", scale: 0, mode: HalfEven, want: "2"},
+		{a: "0.001", b: "0.1", scale: 3, mode: HalfEven, want: "0.010"},
+	}
+	for _, tt := range tests {
+		got, err := Quo(mustParse(t, tt.a), mustParse(t, tt.b), tt.scale, tt.mode)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("Quo(%s, %s, %d, %d) = %v, %v, want %s", tt.a, tt.b, tt.scale, tt.mode, got, err, tt.want)
+		}
+	}
+	if _, err := Quo(New(1, 0), New(0, 0), 2, HalfEven); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Quo by zero error = %v, want %v", err, ErrDivisionByZero)
+	}
+}
+
+func TestPow(t *testing.T) {
+	tests := []struct {
+		a, n string
+		want string
+		err  error
+	}{
+		{a: "2", n: "100", want: "1267650600228229401496703205376"},
+		{a: "1.1", n: "2", want: "1.21"},
+		{a: "-3", n: "3", want: "-27"},
+		{a: "5", n: "0", want: "1"},
+		{a: "0", n: "0", want: "1"},
+		{a: "2", n: "-2", want: "0.2500"},
+		{a: "-1", n: "1000000001", want: "-1"},
+		{a: "0", n: "-1", err: ErrDivisionByZero},
+		{a: "2", n: "0.5", err: ErrNotInteger},
+		{a: "10", n: "100000", err: ErrTooLarge},
+	}
+	for _, tt := range tests {
+		got, err := Pow(mustParse(t, tt.a), mustParse(t, tt.n), 4, HalfEven)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Pow(%s, %s) error = %v, want %v", tt.a, tt.n, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("Pow(%s, %s) = %v, %v, want %s", tt.a, tt.n, got, err, tt.want)
+		}
+	}
+}
+
+func TestRoundAndTrim(t *testing.T) {
+	d := mustParse(t, "2.5")
+	if got := d.Round(0, HalfEven).String(); got != "2" {
+		t.Errorf("Round(2.5, 0, HalfEven) = %s, want 2", got)
+	}
+	if got := d.Round(3, HalfEven).String(); got != "2.500" {
+		t.Errorf("Round(2.5, 3) = %s, want 2.500", got)
+	}
+	if got := mustParse(t, "1.2300").Trim().String(); got != "1.23" {
+		t.Errorf("Trim(1.2300) = %s, want 1.23", got)
+	}
+	if got := mustParse(t, "100").Trim().String(); got != "100" {
+		t.Errorf("Trim(100) = %s, want 100", got)
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	if _, err := Parse(strings.Repeat("9", MaxDigits+1)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Parse of %d digits error = %v, want %v", MaxDigits+1, err, ErrTooLarge)
+	}
+}
+
+func TestRoot(t *testing.T) {
+	tests := []struct {
+		x     string
+		n     int
+		scale int
+		mode  RoundingMode
+		want  string
+		err   error
+	}{
+		{x: "2", n: 2, scale: 10, want: "1.4142135624"},
+		{x: "2", n: 2, scale: 10, mode: Down, want: "1.4142135623"},
+		{x: "2", n: 2, scale: 50, want: "1.41421356237309504880168872420969807856967187537695"},
+		{x: "-27", n: 3, scale: 2, want: "-3.00"},
+		{x: "-2", n: 3, scale: 5, mode: Floor, want: "-1.25993"},
+		{x: "0.001", n: 3, scale: 5, want: "0.10000"},
+		{x: "1e100", n: 100, scale: 0, want: "10"},
+		{x: "7", n: 1, scale: 1, want: "7.0"},
+		{x: "0", n: 5, scale: 0, want: "0"},
+		// √1.5625 = 1.25 is a tie, √1.5626 just above it
+		{x: "1.5625", n: 2, scale: 1, want: "1.2"},
+		{x: "1.5625", n: 2, scale: 1, mode: HalfUp, want: "1.3"},
+		{x: "1.5626", n: 2, scale: 1, want: "1.3"},
+		{x: "-4", n: 2, err: ErrNegativeRoot},
+		{x: "4", n: 0, err: ErrDegree},
+	}
+	for _, tt := range tests {
+		got, err := Root(mustParse(t, tt.x), tt.n, tt.scale, tt.mode)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Root(%s, %d) error = %v, want %v", tt.x, tt.n, err, tt.err)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("Root(%s, %d) = %s, want %s", tt.x, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestComplexRoot(t *testing.T) {
+	tests := []struct {
+		x              string
+		n, scale       int
+		mode           RoundingMode
+		wantRe, wantIm string
+	}{
+		{x: "9", n: 2, scale: 0, wantRe: "3", wantIm: "0"},
+		{x: "-4", n: 2, scale: 3, wantRe: "0.000", wantIm: "2.000"},
+		{x: "-5", n: 1, scale: 0, wantRe: "-5", wantIm: "0"},
+		// 1+i exactly, whatever the rounding
+		{x: "-4", n: 4, scale: 5, mode: Up, wantRe: "1.00000", wantIm: "1.00000"},
+		{x: "-4", n: 4, scale: 5, mode: Down, wantRe: "1.00000", wantIm: "1.00000"},
+		{x: "-8", n: 3, scale: 10, wantRe: "1.0000000000", wantIm: "1.7320508076"},
+		{x: "-1", n: 6, scale: 20, wantRe: "0.86602540378443864676", wantIm: "0.50000000000000000000"},
+		{x: "-1e60", n: 4, scale: 3, wantRe: "707106781186547.524", wantIm: "707106781186547.524"},
+	}
+	for _, tt := range tests {
+		re, im, err := ComplexRoot(mustParse(t, tt.x), tt.n, tt.scale, tt.mode)
+		if err != nil {
+			t.Errorf("ComplexRoot(%s, %d) : %v", tt.x, tt.n, err)
+			continue
+		}
+		if re.String() != tt.wantRe || im.String() != tt.wantIm {
+			t.Errorf("ComplexRoot(%s, %d) = %s + %si, want %s + %si", tt.x, tt.n, re, im, tt.wantRe, tt.wantIm)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package decimal
+
+import (
+	"errors"
+	"math"
+	"math/big"
+)
+
+// Errors returned by Root and ComplexRoot.
+var (
+	ErrDegree       = errors.New("root degree must be positive")
+	ErrNegativeRoot = errors.New("even root of a negative number")
+)
+
+// complexGuardDigits are the digits computed beyond the requested scale for
+// the parts of complex roots, which are not exact.
+const complexGuardDigits = 10
+
+// Root returns the nth root of x rounded to scale digits after the decimal
+// point. Negative numbers only have real roots of odd degree, even degrees
+// fail with ErrNegativeRoot.
+func Root(x *Decimal, n, scale int, mode RoundingMode) (*Decimal, error) {
+	if n < 1 {
+		return nil, ErrDegree
+	}
+	if x.Sign() < 0 && n%2 == 0 {
+		return nil, ErrNegativeRoot
+	}
+	return rootFloor(x, n, scale+1).Round(scale, mode), nil
+}
+
+// ComplexRoot returns the real and imaginary parts of the principal nth root
+// of x, rounded to scale digits after the decimal point. That is the real
+// root of numbers that are not negative, and |x|^(1/n) (cos(π/n) + i sin(π/n))
+// for negative numbers, even of odd degree.
+//
+// Square roots are exact before rounding. Other roots of negative numbers
+// are computed to a few more digits than scale and snapped to the nearest
+// value with scale digits when that close to it, so exact results like the
+// fourth root of -4, 1+i, round as expected.
+func ComplexRoot(x *Decimal, n, scale int, mode RoundingMode) (re, im *Decimal, err error) {
+	if n < 1 {
+		return nil, nil, ErrDegree
+	}
+	zero := &Decimal{scale: scale}
+	switch {
+	case x.Sign() >= 0:
+		return rootFloor(x, n, scale+1).Round(scale, mode), zero, nil
+	case n == 1:
+		return x.Round(scale, mode), zero, nil
+	case n == 2:
+		abs := &Decimal{scale: x.scale}
+		abs.unscaled.Abs(&x.unscaled)
+		return zero, rootFloor(abs, 2, scale+1).Round(scale, mode), nil
+	}
+
+	abs := &Decimal{scale: x.scale}
+	abs.unscaled.Abs(&x.unscaled)
+	prec := scale + complexGuardDigits
+	r := rootFloor(abs, n, prec)
+	// |r| < 10^intDigits scales the error of the cosine and sine
+	intDigits := maxInt(r.digits()-r.scale, 0)
+	cos, sin := cosSinPiOver(n, prec+intDigits)
+
+	re = &Decimal{scale: r.scale + prec + intDigits}
+	re.unscaled.Mul(&r.unscaled, cos)
+	im = &Decimal{scale: r.scale + prec + intDigits}
+	im.unscaled.Mul(&r.unscaled, sin)
+	return snap(re, scale, mode), snap(im, scale, mode), nil
+}
+
+// snap rounds d to scale digits, or to the nearest value with scale digits
+// when d is within the error of the computation from it.
+func snap(d *Decimal, scale int, mode RoundingMode) *Decimal {
+	nearest := d.Round(scale, HalfEven)
+	diff := new(big.Int).Sub(&d.unscaled, nearest.rescaled(d.scale))
+	diff.Abs(diff)
+	if diff.Cmp(pow10(d.scale-scale-complexGuardDigits+3)) <= 0 {
+		return nearest
+	}
+	return d.Round(scale, mode)
+}
+
+// rootFloor returns the nth root of x, of the sign of x, truncated to scale
+// digits after the decimal point. When it is not exact, a digit 1 is
+// appended, so rounding it to fewer digits rounds the exact root.
+func rootFloor(x *Decimal, n, scale int) *Decimal {
+	// keep every digit of x in the integer x * 10^(n*scale)
+	if n*scale < x.scale {
+		scale = (x.scale + n - 1) / n
+	}
+	a := new(big.Int).Abs(&x.unscaled)
+	a.Mul(a, pow10(n*scale-x.scale))
+
+	r := &Decimal{scale: scale}
+	r.unscaled.Set(iroot(a, n))
+	if new(big.Int).Exp(&r.unscaled, big.NewInt(int64(n)), nil).Cmp(a) != 0 {
+		r.unscaled.Mul(&r.unscaled, bigTen)
+		r.unscaled.Add(&r.unscaled, bigOne)
+		r.scale++
+	}
+	if x.Sign() < 0 {
+		r.unscaled.Neg(&r.unscaled)
+	}
+	return r
+}
+
+// iroot returns the largest integer whose nth power is at most a, for a not
+// negative, with Newton's method.
+func iroot(a *big.Int, n int) *big.Int {
+	switch {
+	case a.Sign() == 0 || n == 1:
+		return new(big.Int).Set(a)
+	case n == 2:
+		return new(big.Int).Sqrt(a)
+	}
+
+	bigN, bigN1 := big.NewInt(int64(n)), big.NewInt(int64(n-1))
+	// next returns ((n-1) r + a / r^(n-1)) / n, which is never below the
+	// root, so the iteration decreases from the first step on
+	next := func(r *big.Int) *big.Int {
+		t := new(big.Int).Exp(r, bigN1, nil)
+		t.Quo(a, t)
+		t.Add(t, new(big.Int).Mul(r, bigN1))
+		return t.Quo(t, bigN)
+	}
+	r := next(rootEstimate(a, n))
+	for {
+		t := next(r)
+		if t.Cmp(r) >= 0 {
+			return r
+		}
+		r = t
+	}
+}
+
+// rootEstimate returns the nth root of a to about 15 digits, so Newton's
+// method converges quadratically from the start.
+func rootEstimate(a *big.Int, n int) *big.Int {
+	mant := new(big.Float)
+	exp := new(big.Float).SetInt(a).MantExp(mant)
+	m, _ := mant.Float64()
+	e := (math.Log2(m) + float64(exp)) / float64(n)
+	k := math.Floor(e)
+	r, _ := new(big.Float).SetMantExp(big.NewFloat(math.Exp2(e-k)), int(k)).Int(nil)
+	return r.Add(r, bigOne)
+}
+
+// cosSinPiOver returns cos(π/n) and sin(π/n) times 10^prec, to a few units,
+// for n of at least 3.
+func cosSinPiOver(n, prec int) (cos, sin *big.Int) {
+	// a few more digits absorb the truncation of every term
+	p := prec + 5
+	one := pow10(p)
+	theta := new(big.Int).Quo(machinPi(one), big.NewInt(int64(n)))
+
+	// Taylor series, θ^k / k! goes to the cosine for even k and to the
+	// sine for odd k, with alternating signs
+	cos, sin = new(big.Int).Set(one), new(big.Int)
+	term := new(big.Int).Set(one)
+	for k := int64(1); ; k++ {
+		term.Mul(term, theta)
+		term.Quo(term, one)
+		term.Quo(term, big.NewInt(k))
+		if term.Sign() == 0 {
+			break
+		}
+		switch k % 4 {
+		case 0:
+			cos.Add(cos, term)
+		case 1:
+			sin.Add(sin, term)
+		case 2:
+			cos.Sub(cos, term)
+		case 3:
+			sin.Sub(sin, term)
+		}
+	}
+	scale := pow10(p - prec)
+	return cos.Quo(cos, scale), sin.Quo(sin, scale)
+}
+
+// machinPi returns π times one, a power of ten, from Machin's formula
+// π = 16 atan(1/5) - 4 atan(1/239).
+func machinPi(one *big.Int) *big.Int {
+	pi := new(big.Int).Mul(arctanInverse(5, one), big.NewInt(16))
+	return pi.Sub(pi, new(big.Int).Mul(arctanInverse(239, one), big.NewInt(4)))
+}
+
+// arctanInverse returns atan(1/x) times one, summing
+// 1/x - 1/(3x^3) + 1/(5x^5) - ...
+func arctanInverse(x int64, one *big.Int) *big.Int {
+	power := new(big.Int).Quo(one, big.NewInt(x))
+	sum := new(big.Int).Set(power)
+	x2 := big.NewInt(x * x)
+	for k := int64(1); ; k++ {
+		power.Quo(power, x2)
+		if power.Sign() == 0 {
+			return sum
+		}
+		term := new(big.Int).Quo(power, big.NewInt(2*k+1))
+		if k%2 == 1 {
+			sum.Sub(sum, term)
+		} else {
+			sum.Add(sum, term)
+		}
+	}
+}
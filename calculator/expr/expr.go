@@ -0,0 +1,270 @@
+// Package expr parses and evaluates arithmetic expressions such as
+// "2 * (3 + sqrt(16)) / x".
+//
+// Expressions combine numbers, variables, the operators + - * / % and ^
+// (power, right associative), parentheses and the functions sqrt, pow, log,
+// min and max. The constants pi and e are predefined.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MaxDepth bounds the nesting of parentheses, operators and function calls.
+const MaxDepth = 200
+
+// SyntaxError reports an expression that cannot be parsed.
+type SyntaxError struct {
+	// Pos is the 1-based character position of the error
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d : %s", e.Pos, e.Msg)
+}
+
+// EvalError reports an expression that parses but has no value, e.g. a
+// division by zero.
+type EvalError struct {
+	// Pos is the 1-based character position of the failing operation
+	Pos int
+	Msg string
+	// Variable is the name of the unbound variable, if that is the cause
+	Variable string
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("evaluation error at position %d : %s", e.Pos, e.Msg)
+}
+
+// Constants are the names bound in every evaluation. Variables of the same
+// name take precedence.
+var Constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+type function struct {
+	minArgs, maxArgs int // maxArgs < 0 means any number
+	call             func(args []float64) (float64, string)
+}
+
+var functions = map[string]function{
+	"sqrt": {1, 1, func(args []float64) (float64, string) {
+		if args[0] < 0 {
+			return 0, "square root of a negative number"
+		}
+		return math.Sqrt(args[0]), ""
+	}},
+	"pow": {2, 2, func(args []float64) (float64, string) {
+		return math.Pow(args[0], args[1]), ""
+	}},
+	// log(x) is the natural logarithm, log(x, b) the logarithm in base b
+	"log": {1, 2, func(args []float64) (float64, string) {
+		if args[0] <= 0 {
+			return 0, "logarithm of a non-positive number"
+		}
+		if len(args) == 1 {
+			return math.Log(args[0]), ""
+		}
+		if args[1] <= 0 || args[1] == 1 {
+			return 0, "logarithm base must be positive and not 1"
+		}
+		return math.Log(args[0]) / math.Log(args[1]), ""
+	}},
+	"min": {1, -1, func(args []float64) (float64, string) {
+		m := args[0]
+		for _, a := range args[1:] {
+			m = math.Min(m, a)
+		}
+		return m, ""
+	}},
+	"max": {1, -1, func(args []float64) (float64, string) {
+		m := args[0]
+		for _, a := range args[1:] {
+			m = math.Max(m, a)
+		}
+		return m, ""
+	}},
+}
+
+// Functions returns the names of the built-in functions.
+func Functions() []string {
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Expr is a parsed expression, safe for concurrent evaluation.
+type Expr struct {
+	src  string
+	root node
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Variables returns the names the expression refers to, constants
+// included, sorted.
+func (e *Expr) Variables() []string {
+	seen := map[string]bool{}
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case *variable:
+			seen[n.name] = true
+		case *unary:
+			walk(n.operand)
+		case *binary:
+			walk(n.left)
+			walk(n.right)
+		case *call:
+			for _, arg := range n.args {
+				walk(arg)
+			}
+		}
+	}
+	walk(e.root)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Eval computes the value of the expression with vars bound. Results that
+// are not finite, like an overflowing power, are errors.
+func (e *Expr) Eval(vars map[string]float64) (float64, error) {
+	return e.root.eval(vars)
+}
+
+// Eval parses and evaluates src.
+func Eval(src string, vars map[string]float64) (float64, error) {
+	e, err := Parse(src)
+	if err != nil {
+		return 0, err
+	}
+	return e.Eval(vars)
+}
+
+type node interface {
+	eval(vars map[string]float64) (float64, error)
+}
+
+type number struct {
+	value float64
+}
+
+func (n *number) eval(map[string]float64) (float64, error) {
+	return n.value, nil
+}
+
+type variable struct {
+	name string
+	pos  int
+}
+
+func (n *variable) eval(vars map[string]float64) (float64, error) {
+	if v, ok := vars[n.name]; ok {
+		return v, nil
+	}
+	if v, ok := Constants[n.name]; ok {
+		return v, nil
+	}
+	return 0, &EvalError{Pos: n.pos, Msg: fmt.Sprintf("variable %q has no value", n.name), Variable: n.name}
+}
+
+type unary struct {
+	op      string
+	operand node
+}
+
+func (n *unary) eval(vars map[string]float64) (float64, error) {
+	v, err := n.operand.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	if n.op == "-" {
+		return -v, nil
+	}
+	return v, nil
+}
+
+type binary struct {
+	op          string
+	pos         int
+	left, right node
+}
+
+func (n *binary) eval(vars map[string]float64) (float64, error) {
+	l, err := n.left.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+
+	var v float64
+	switch n.op {
+	case "+":
+		v = l + r
+	case "-":
+		v = l - r
+	case "*":
+		v = l * r
+	case "/":
+		if r == 0 {
+			return 0, &EvalError{Pos: n.pos, Msg: "division by zero"}
+		}
+		v = l / r
+	case "%":
+		if r == 0 {
+			return 0, &EvalError{Pos: n.pos, Msg: "modulo by zero"}
+		}
+		v = math.Mod(l, r)
+	case "^":
+		v = math.Pow(l, r)
+	}
+	return finite(v, n.pos, n.op)
+}
+
+type call struct {
+	name string
+	pos  int
+	fn   function
+	args []node
+}
+
+func (n *call) eval(vars map[string]float64) (float64, error) {
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(vars)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	v, msg := n.fn.call(args)
+	if msg != "" {
+		return 0, &EvalError{Pos: n.pos, Msg: fmt.Sprintf("%s : %s", n.name, msg)}
+	}
+	return finite(v, n.pos, n.name)
+}
+
+func finite(v float64, pos int, op string) (float64, error) {
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, &EvalError{Pos: pos, Msg: fmt.Sprintf("%s has no finite result", op)}
+	}
+	return v, nil
+}
@@ -0,0 +1,149 @@
+package expr
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	vars := map[string]float64{"x": 2, "rate": 0.5, "e": 10}
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{src: "2 * (3 + sqrt(16)) / x", want: 7},
+		{src: "1 + 2 * 3", want: 7},
+		{src: "(1 + 2) * 3", want: 9},
+		{src: "10 - 4 - 3", want: 3},
+		{src: "2 ^ 3 ^ 2", want: 512},
+		{src: "-2 ^ 2", want: -4},
+		{src: "2 ^ -1", want: 0.5},
+		{src: "7 % 3", want: 1},
+		{src: "--3", want: 3},
+		{src: "1.5e2 + .5", want: 150.5},
+		{src: "pow(2, 10)", want: 1024},
+		{src: "log(e)", want: math.Log(10)},
+		{src: "log(8, 2)", want: 3},
+		{src: "min(3, x, 7)", want: 2},
+		{src: "max(rate)", want: 0.5},
+		{src: "pi", want: math.Pi},
+		// variables hide constants
+		{src: "e", want: 10},
+	}
+	for _, tt := range tests {
+		got, err := Eval(tt.src, vars)
+		if err != nil {
+			t.Errorf("Eval(%q) : %v", tt.src, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("Eval(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		src string
+		pos int
+		msg string
+	}{
+		{src: "", pos: 1, msg: "unexpected end of expression"},
+		{src: "1 +", pos: 4, msg: "unexpected end of expression"},
+		{src: "2 * (3 + 4", pos: 11, msg: `expected ")" to close "(" at position 5`},
+		{src: "1 + 2)", pos: 6, msg: `unexpected ")"`},
+		{src: "3 $ 4", pos: 3, msg: `unexpected character '$'`},
+		{src: "1..2", pos: 3, msg: `unexpected ".2"`},
+		{src: "1 + .", pos: 5, msg: `invalid number "."`},
+		{src: "foo(1)", pos: 1, msg: `unknown function "foo"`},
+		{src: "sqrt(1, 2)", pos: 1, msg: "sqrt takes 1 argument, got 2"},
+		{src: "min()", pos: 1, msg: "min takes at least 1 argument, got 0"},
+		{src: "max(1 2)", pos: 7, msg: `expected "," or ")" in call to max`},
+		{src: "2 3", pos: 3, msg: `unexpected "3"`},
+		{src: strings.Repeat("(", MaxDepth+1) + "1" + strings.Repeat(")", MaxDepth+1), msg: "nested deeper"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q) error = %v, want a syntax error", tt.src, err)
+			continue
+		}
+		if tt.pos != 0 && syntaxErr.Pos != tt.pos || !strings.Contains(syntaxErr.Msg, tt.msg) {
+			t.Errorf("Parse(%q) error = %v, want %q at position %d", tt.src, err, tt.msg, tt.pos)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		pos      int
+		variable string
+	}{
+		{src: "1 / (x - x)", pos: 3},
+		{src: "5 % 0", pos: 3},
+		{src: "1 + sqrt(-4)", pos: 5},
+		{src: "log(0)", pos: 1},
+		{src: "10 ^ 400", pos: 4},
+		{src: "2 * y", pos: 5, variable: "y"},
+	}
+	for _, tt := range tests {
+		_, err := Eval(tt.src, map[string]float64{"x": 1})
+		var evalErr *EvalError
+		if !errors.As(err, &evalErr) {
+			t.Errorf("Eval(%q) error = %v, want an evaluation error", tt.src, err)
+			continue
+		}
+		if evalErr.Pos != tt.pos || evalErr.Variable != tt.variable {
+			t.Errorf("Eval(%q) error = %+v, want position %d and variable %q", tt.src, evalErr, tt.pos, tt.variable)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	e, err := Parse("a * max(b, a) + -c ^ pi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(e.Variables(), ","); got != "a,b,c,pi" {
+		t.Errorf("Variables() = %s, want a,b,c,pi", got)
+	}
+}
+
+func TestParseAssignment(t *testing.T) {
+	tests := []struct {
+		src  string
+		name string
+		want float64
+		pos  int
+	}{
+		{src: "x = 2 * 3", name: "x", want: 6},
+		{src: "2 * 3", want: 6},
+		{src: "total=ans+1", name: "total", want: 2},
+		{src: "x =", pos: 4},
+		{src: "1 = 2", pos: 3},
+		{src: "x = y = 2", pos: 7},
+		{src: "sqrt = 2", pos: 1},
+	}
+	for _, tt := range tests {
+		name, e, err := ParseAssignment(tt.src)
+		if tt.pos != 0 {
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) || syntaxErr.Pos != tt.pos {
+				t.Errorf("ParseAssignment(%q) error = %v, want a syntax error at position %d", tt.src, err, tt.pos)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAssignment(%q) : %v", tt.src, err)
+			continue
+		}
+		got, err := e.Eval(map[string]float64{"ans": 1})
+		if name != tt.name || err != nil || got != tt.want {
+			t.Errorf("ParseAssignment(%q) = %q, %v, %v, want %q, %v", tt.src, name, got, err, tt.name, tt.want)
+		}
+	}
+}
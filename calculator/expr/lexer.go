@@ -0,0 +1,111 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+	tokAssign
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	// pos is the 1-based character position of the token
+	pos int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// lex splits src into tokens, ending with a tokEOF.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	pos := 1
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		start, startPos := i, pos
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r >= '0' && r <= '9' || r == '.':
+			i = scanNumber(src, i)
+			text := src[start:i]
+			num, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, &SyntaxError{Pos: startPos, Msg: fmt.Sprintf("invalid number %q", text)}
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: text, num: num, pos: startPos})
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(src) {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: startPos})
+		default:
+			kind := tokOperator
+			switch r {
+			case '+', '-', '*', '/', '%', '^':
+			case '(':
+				kind = tokLParen
+			case ')':
+				kind = tokRParen
+			case ',':
+				kind = tokComma
+			case '=':
+				kind = tokAssign
+			default:
+				return nil, &SyntaxError{Pos: startPos, Msg: fmt.Sprintf("unexpected character %q", r)}
+			}
+			i += size
+			tokens = append(tokens, token{kind: kind, text: src[start:i], pos: startPos})
+		}
+		pos += utf8.RuneCountInString(src[start:i])
+	}
+	return append(tokens, token{kind: tokEOF, pos: pos}), nil
+}
+
+// scanNumber returns the end of the number starting at i, e.g. "1.5e-3".
+func scanNumber(src string, i int) int {
+	digits := func() {
+		for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+			i++
+		}
+	}
+	digits()
+	if i < len(src) && src[i] == '.' {
+		i++
+		digits()
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && src[j] >= '0' && src[j] <= '9' {
+			i = j
+			digits()
+		}
+	}
+	return i
+}
@@ -0,0 +1,235 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parse parses src. It returns a *SyntaxError locating the first mistake.
+//
+// The grammar, from the lowest precedence:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/" | "%") unary }
+//	unary   = ("+" | "-") unary | power
+//	power   = primary [ "^" unary ]
+//	primary = number | name | name "(" [ expr { "," expr } ] ")" | "(" expr ")"
+func Parse(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	return parse(src, &parser{tokens: tokens})
+}
+
+// ParseAssignment parses statements like "x = 2 * y", returning the
+// assigned name and its expression. Without a leading "name =", src is
+// parsed as a plain expression and name is empty.
+func ParseAssignment(src string) (name string, e *Expr, err error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return "", nil, err
+	}
+	p := &parser{tokens: tokens}
+	if len(tokens) > 2 && tokens[0].kind == tokIdent && tokens[1].kind == tokAssign {
+		if _, ok := functions[tokens[0].text]; ok {
+			return "", nil, &SyntaxError{Pos: tokens[0].pos, Msg: fmt.Sprintf("cannot assign to function %s", tokens[0].text)}
+		}
+		name = tokens[0].text
+		p.next = 2
+	}
+	e, err = parse(src, p)
+	return name, e, err
+}
+
+func parse(src string, p *parser) (*Expr, error) {
+	root, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.unexpected(t)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *parser) isOperator(ops string) bool {
+	t := p.peek()
+	return t.kind == tokOperator && strings.Contains(ops, t.text)
+}
+
+func (p *parser) unexpected(t token) error {
+	return &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %v", t)}
+}
+
+// enter guards against expressions nested deep enough to exhaust the stack.
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return &SyntaxError{Pos: p.peek().pos, Msg: fmt.Sprintf("expression nested deeper than %d levels", MaxDepth)}
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) expr() (node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("+-") {
+		op := p.advance()
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op.text, pos: op.pos, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) term() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("*/%") {
+		op := p.advance()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op.text, pos: op.pos, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	if p.isOperator("+-") {
+		op := p.advance()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: op.text, operand: operand}, nil
+	}
+	return p.power()
+}
+
+func (p *parser) power() (node, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator("^") {
+		return base, nil
+	}
+	op := p.advance()
+	// the exponent may be signed and is itself a power: 2^-3^2 = 2^(-(3^2))
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &binary{op: op.text, pos: op.pos, left: base, right: exponent}, nil
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.advance()
+	switch t.kind {
+	case tokNumber:
+		return &number{value: t.num}, nil
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			return p.call(t)
+		}
+		return &variable{name: t.text, pos: t.pos}, nil
+	case tokLParen:
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.advance(); closing.kind != tokRParen {
+			return nil, &SyntaxError{Pos: closing.pos, Msg: fmt.Sprintf("expected \")\" to close \"(\" at position %d, got %v", t.pos, closing)}
+		}
+		return inner, nil
+	}
+	return nil, p.unexpected(t)
+}
+
+func (p *parser) call(name token) (node, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return nil, &SyntaxError{Pos: name.pos, Msg: fmt.Sprintf("unknown function %q, expected one of %s", name.text, strings.Join(Functions(), ", "))}
+	}
+	open := p.advance()
+
+	var args []node
+	if p.peek().kind != tokRParen {
+		for {
+			arg, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.advance()
+		}
+	}
+	if closing := p.advance(); closing.kind != tokRParen {
+		return nil, &SyntaxError{Pos: closing.pos, Msg: fmt.Sprintf("expected \",\" or \")\" in call to %s at position %d, got %v", name.text, open.pos, closing)}
+	}
+
+	if len(args) < fn.minArgs || fn.maxArgs >= 0 && len(args) > fn.maxArgs {
+		return nil, &SyntaxError{Pos: name.pos, Msg: fmt.Sprintf("%s takes %s, got %d", name.text, arity(fn), len(args))}
+	}
+	return &call{name: name.text, pos: name.pos, fn: fn, args: args}, nil
+}
+
+func arity(fn function) string {
+	plural := func(n int) string {
+		if n == 1 {
+			return "1 argument"
+		}
+		return fmt.Sprintf("%d arguments", n)
+	}
+	switch {
+	case fn.maxArgs < 0:
+		return "at least " + plural(fn.minArgs)
+	case fn.minArgs == fn.maxArgs:
+		return plural(fn.minArgs)
+	}
+	return fmt.Sprintf("%d to %d arguments", fn.minArgs, fn.maxArgs)
+}
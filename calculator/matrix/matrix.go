@@ -0,0 +1,261 @@
+// Package matrix implements dense matrices of float64 and the linear algebra
+// the calculator offers on them. Vectors are matrices with a single column.
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Errors returned by the operations, wrapped with the dimensions involved.
+var (
+	ErrShape             = errors.New("invalid matrix shape")
+	ErrDimensionMismatch = errors.New("matrix dimensions do not match")
+	ErrNotSquare         = errors.New("matrix is not square")
+	ErrSingular          = errors.New("matrix is singular")
+)
+
+// Matrix is a rows x cols matrix stored row by row.
+type Matrix struct {
+	rows, cols int
+	data       []float64
+}
+
+// New returns a rows x cols matrix holding data, given row by row. The
+// matrix keeps data without copying it.
+func New(rows, cols int, data []float64) (*Matrix, error) {
+	if rows < 1 || cols < 1 {
+		return nil, fmt.Errorf("%w : %dx%d", ErrShape, rows, cols)
+	}
+	if len(data) != rows*cols {
+		return nil, fmt.Errorf("%w : %d values for %dx%d", ErrShape, len(data), rows, cols)
+	}
+	return &Matrix{rows: rows, cols: cols, data: data}, nil
+}
+
+// Zeros returns a rows x cols matrix of zeros.
+func Zeros(rows, cols int) *Matrix {
+	return &Matrix{rows: rows, cols: cols, data: make([]float64, rows*cols)}
+}
+
+// Identity returns the n x n identity matrix.
+func Identity(n int) *Matrix {
+	m := Zeros(n, n)
+	for i := 0; i < n; i++ {
+		m.data[i*n+i] = 1
+	}
+	return m
+}
+
+// Rows returns the number of rows of m.
+func (m *Matrix) Rows() int { return m.rows }
+
+// Cols returns the number of columns of m.
+func (m *Matrix) Cols() int { return m.cols }
+
+// At returns the value at row i and column j, counted from 0.
+func (m *Matrix) At(i, j int) float64 { return m.data[i*m.cols+j] }
+
+// Row returns row i of m, sharing its storage.
+func (m *Matrix) Row(i int) []float64 { return m.data[i*m.cols : (i+1)*m.cols] }
+
+// Data returns the values of m row by row, sharing its storage.
+func (m *Matrix) Data() []float64 { return m.data }
+
+func (m *Matrix) String() string { return fmt.Sprintf("%dx%d", m.rows, m.cols) }
+
+func (m *Matrix) clone() *Matrix {
+	return &Matrix{rows: m.rows, cols: m.cols, data: append([]float64(nil), m.data...)}
+}
+
+// Add returns a + b.
+func Add(a, b *Matrix) (*Matrix, error) {
+	if a.rows != b.rows || a.cols != b.cols {
+		return nil, fmt.Errorf("%w : cannot add %v and %v", ErrDimensionMismatch, a, b)
+	}
+	sum := Zeros(a.rows, a.cols)
+	for i := range sum.data {
+		sum.data[i] = a.data[i] + b.data[i]
+	}
+	return sum, nil
+}
+
+// Mul returns the product a b.
+func Mul(a, b *Matrix) (*Matrix, error) {
+	if a.cols != b.rows {
+		return nil, fmt.Errorf("%w : cannot multiply %v by %v", ErrDimensionMismatch, a, b)
+	}
+	product := Zeros(a.rows, b.cols)
+	for i := 0; i < a.rows; i++ {
+		row := product.Row(i)
+		// walk b row by row rather than column by column, for the cache
+		for k, aik := range a.Row(i) {
+			if aik == 0 {
+				continue
+			}
+			for j, bkj := range b.Row(k) {
+				row[j] += aik * bkj
+			}
+		}
+	}
+	return product, nil
+}
+
+// Transpose returns the transpose of m.
+func Transpose(m *Matrix) *Matrix {
+	t := Zeros(m.cols, m.rows)
+	for i := 0; i < m.rows; i++ {
+		for j, v := range m.Row(i) {
+			t.data[j*m.rows+i] = v
+		}
+	}
+	return t
+}
+
+// Det returns the determinant of the square matrix m.
+func Det(m *Matrix) (float64, error) {
+	f, err := factorize(m)
+	if err != nil {
+		return 0, err
+	}
+	det := f.sign
+	for i := 0; i < m.rows; i++ {
+		det *= f.lu.At(i, i)
+	}
+	return det, nil
+}
+
+// Inverse returns the inverse of the square matrix m, or ErrSingular when m
+// has none, or none that float64 can represent with any accuracy.
+func Inverse(m *Matrix) (*Matrix, error) {
+	f, err := factorize(m)
+	if err != nil {
+		return nil, err
+	}
+	return f.solve(Identity(m.rows))
+}
+
+// Solve returns x such that a x = b, for a square and invertible. Each column
+// of b is a right-hand side, so b is usually a vector.
+func Solve(a, b *Matrix) (*Matrix, error) {
+	if a.rows != b.rows {
+		return nil, fmt.Errorf("%w : cannot solve %v x = %v", ErrDimensionMismatch, a, b)
+	}
+	f, err := factorize(a)
+	if err != nil {
+		return nil, err
+	}
+	return f.solve(b)
+}
+
+// lu is the LU decomposition with partial pivoting of a square matrix: the
+// rows of the matrix permuted by pivot are the product of the unit lower
+// triangle and the upper triangle of lu.
+type lu struct {
+	lu    *Matrix
+	pivot []int
+	// sign is the parity of the permutation, 1 or -1
+	sign float64
+	// tolerance is the smallest magnitude of a pivot of an invertible
+	// matrix, below which it is indistinguishable from rounding error
+	tolerance float64
+}
+
+func factorize(m *Matrix) (*lu, error) {
+	if m.rows != m.cols {
+		return nil, fmt.Errorf("%w : %v", ErrNotSquare, m)
+	}
+	n := m.rows
+	f := &lu{lu: m.clone(), pivot: make([]int, n), sign: 1}
+	largest := 0.0
+	for _, v := range m.data {
+		largest = math.Max(largest, math.Abs(v))
+	}
+	f.tolerance = float64(n) * largest * 0x1p-52
+
+	a := f.lu
+	for i := range f.pivot {
+		f.pivot[i] = i
+	}
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(a.At(i, k)) > math.Abs(a.At(p, k)) {
+				p = i
+			}
+		}
+		if p != k {
+			rowP, rowK := a.Row(p), a.Row(k)
+			for j := range rowK {
+				rowP[j], rowK[j] = rowK[j], rowP[j]
+			}
+			f.pivot[p], f.pivot[k] = f.pivot[k], f.pivot[p]
+			f.sign = -f.sign
+		}
+		pivot := a.At(k, k)
+		if pivot == 0 {
+			// the rest of the column is zero already
+			continue
+		}
+		rowK := a.Row(k)
+		for i := k + 1; i < n; i++ {
+			row := a.Row(i)
+			l := row[k] / pivot
+			row[k] = l
+			if l == 0 {
+				continue
+			}
+			for j := k + 1; j < n; j++ {
+				row[j] -= l * rowK[j]
+			}
+		}
+	}
+	return f, nil
+}
+
+// solve returns x such that m x = b, for the matrix m f decomposes.
+func (f *lu) solve(b *Matrix) (*Matrix, error) {
+	n := f.lu.rows
+	for i := 0; i < n; i++ {
+		if math.Abs(f.lu.At(i, i)) <= f.tolerance {
+			return nil, fmt.Errorf("%w : %v", ErrSingular, f.lu)
+		}
+	}
+
+	x := Zeros(n, b.cols)
+	for i, p := range f.pivot {
+		copy(x.Row(i), b.Row(p))
+	}
+	// forward substitution through the unit lower triangle
+	for i := 1; i < n; i++ {
+		row := x.Row(i)
+		for k := 0; k < i; k++ {
+			l := f.lu.At(i, k)
+			if l == 0 {
+				continue
+			}
+			for j, v := range x.Row(k) {
+				row[j] -= l * v
+			}
+		}
+	}
+	// back substitution through the upper triangle
+	for i := n - 1; i >= 0; i-- {
+		row := x.Row(i)
+		for k := i + 1; k < n; k++ {
+			u := f.lu.At(i, k)
+			if u == 0 {
+				continue
+			}
+			for j, v := range x.Row(k) {
+				row[j] -= u * v
+			}
+		}
+		d := f.lu.At(i, i)
+		for j := range row {
+			row[j] /= d
+		}
+	}
+	return x, nil
+}
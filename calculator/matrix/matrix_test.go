@@ -0,0 +1,143 @@
+package matrix
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func mustNew(t *testing.T, rows, cols int, data ...float64) *Matrix {
+	t.Helper()
+	m, err := New(rows, cols, data)
+	if err != nil {
+		t.Fatalf("New(%d, %d) : %v", rows, cols, err)
+	}
+	return m
+}
+
+func assertClose(t *testing.T, name string, got, want *Matrix, tolerance float64) {
+	t.Helper()
+	if got.Rows() != want.Rows() || got.Cols() != want.Cols() {
+		t.Fatalf("%s is %v, want %v", name, got, want)
+	}
+	for i, v := range got.Data() {
+		if math.Abs(v-want.Data()[i]) > tolerance {
+			t.Fatalf("%s = %v, want %v", name, got.Data(), want.Data())
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(2, 2, []float64{1, 2, 3}); !errors.Is(err, ErrShape) {
+		t.Errorf("New with 3 values for 2x2 : %v, want ErrShape", err)
+	}
+	if _, err := New(0, 3, nil); !errors.Is(err, ErrShape) {
+		t.Errorf("New(0, 3) : %v, want ErrShape", err)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a := mustNew(t, 2, 3, 1, 2, 3, 4, 5, 6)
+	b := mustNew(t, 2, 3, 6, 5, 4, 3, 2, 1)
+
+	sum, err := Add(a, b)
+	if err != nil {
+		t.Fatalf("Add : %v", err)
+	}
+	assertClose(t, "a + b", sum, mustNew(t, 2, 3, 7, 7, 7, 7, 7, 7), 0)
+
+	product, err := Mul(a, Transpose(b))
+	if err != nil {
+		t.Fatalf("Mul : %v", err)
+	}
+	assertClose(t, "a bᵀ", product, mustNew(t, 2, 2, 28, 10, 73, 28), 0)
+	assertClose(t, "aᵀ", Transpose(a), mustNew(t, 3, 2, 1, 4, 2, 5, 3, 6), 0)
+
+	if _, err := Add(a, Transpose(b)); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Add 2x3 and 3x2 : %v, want ErrDimensionMismatch", err)
+	}
+	if _, err := Mul(a, b); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Mul 2x3 by 2x3 : %v, want ErrDimensionMismatch", err)
+	}
+}
+
+func TestDet(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *Matrix
+		want float64
+	}{
+		{"1x1", mustNew(t, 1, 1, -3), -3},
+		{"2x2", mustNew(t, 2, 2, 1, 2, 3, 4), -2},
+		{"needs pivoting", mustNew(t, 3, 3, 0, 1, 2, 1, 0, 3, 4, -3, 8), -2},
+		{"singular", mustNew(t, 3, 3, 1, 2, 3, 4, 5, 6, 7, 8, 9), 0},
+		{"zero column", mustNew(t, 2, 2, 0, 1, 0, 2), 0},
+	}
+	for _, tt := range tests {
+		got, err := Det(tt.m)
+		if err != nil {
+			t.Errorf("%s : Det : %v", tt.name, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%s : Det = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if _, err := Det(mustNew(t, 1, 2, 1, 2)); !errors.Is(err, ErrNotSquare) {
+		t.Errorf("Det of 1x2 : %v, want ErrNotSquare", err)
+	}
+}
+
+func TestInverse(t *testing.T) {
+	m := mustNew(t, 3, 3, 0, 1, 2, 1, 0, 3, 4, -3, 8)
+	inv, err := Inverse(m)
+	if err != nil {
+		t.Fatalf("Inverse : %v", err)
+	}
+	assertClose(t, "inverse", inv, mustNew(t, 3, 3, -4.5, 7, -1.5, -2, 4, -1, 1.5, -2, 0.5), 1e-12)
+
+	for _, singular := range []*Matrix{
+		mustNew(t, 2, 2, 1, 2, 2, 4),
+		mustNew(t, 3, 3, 1, 2, 3, 4, 5, 6, 7, 8, 9),
+	} {
+		if _, err := Inverse(singular); !errors.Is(err, ErrSingular) {
+			t.Errorf("Inverse(%v) : %v, want ErrSingular", singular.Data(), err)
+		}
+	}
+}
+
+func TestSolve(t *testing.T) {
+	// 2x + y = 5, x - 3y = -1
+	a := mustNew(t, 2, 2, 2, 1, 1, -3)
+	x, err := Solve(a, mustNew(t, 2, 1, 5, -1))
+	if err != nil {
+		t.Fatalf("Solve : %v", err)
+	}
+	assertClose(t, "x", x, mustNew(t, 2, 1, 2, 1), 1e-12)
+
+	if _, err := Solve(a, mustNew(t, 3, 1, 1, 2, 3)); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Solve with 3 right-hand values : %v, want ErrDimensionMismatch", err)
+	}
+}
+
+func TestSolveRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	const n = 50
+	a, want := Zeros(n, n), Zeros(n, 2)
+	for i := range a.Data() {
+		a.Data()[i] = r.NormFloat64()
+	}
+	for i := range want.Data() {
+		want.Data()[i] = r.NormFloat64()
+	}
+	b, err := Mul(a, want)
+	if err != nil {
+		t.Fatalf("Mul : %v", err)
+	}
+	x, err := Solve(a, b)
+	if err != nil {
+		t.Fatalf("Solve : %v", err)
+	}
+	assertClose(t, "x", x, want, 1e-9)
+}
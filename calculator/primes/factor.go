@@ -0,0 +1,150 @@
+package primes
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// Factor is a prime factor and the number of times it divides a number.
+type Factor struct {
+	Prime        uint64
+	Multiplicity int
+}
+
+// trialDivisionLimit bounds the primes removed by trial division before
+// falling back to Pollard's rho.
+const trialDivisionLimit = 1000
+
+// Factorize finds the prime factors of n, calling fn with each distinct
+// prime as soon as it is found. Small primes come first in increasing order,
+// larger ones in no particular order. It stops early with the error of fn,
+// or ctx.Err() once ctx is done.
+func Factorize(ctx context.Context, n uint64, fn func(Factor) error) error {
+	if n < 2 {
+		return nil
+	}
+	rest := n
+	emit := func(p uint64) error {
+		f := Factor{Prime: p}
+		for rest%p == 0 {
+			rest /= p
+			f.Multiplicity++
+		}
+		return fn(f)
+	}
+
+	for p := uint64(2); p < trialDivisionLimit && p*p <= rest; p++ {
+		if rest%p == 0 {
+			if err := emit(p); err != nil {
+				return err
+			}
+		}
+	}
+
+	// split the remaining cofactor until every part is prime
+	pending := []uint64{rest}
+	for len(pending) > 0 {
+		c := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		// drop the primes emitted since c was split off
+		c = GCD(c, rest)
+		if c == 1 {
+			continue
+		}
+		if IsPrime(c) {
+			if err := emit(c); err != nil {
+				return err
+			}
+			continue
+		}
+		d, err := pollardRho(ctx, c)
+		if err != nil {
+			return err
+		}
+		pending = append(pending, d, c/d)
+	}
+	return nil
+}
+
+// Factors returns the prime factors of n in increasing order.
+func Factors(ctx context.Context, n uint64) ([]Factor, error) {
+	var factors []Factor
+	err := Factorize(ctx, n, func(f Factor) error {
+		factors = append(factors, f)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(factors, func(i, j int) bool { return factors[i].Prime < factors[j].Prime })
+	return factors, nil
+}
+
+var errNoFactor = errors.New("no factor found")
+
+// pollardRho returns a non-trivial factor of the composite n, using Brent's
+// variant of Pollard's rho. It checks ctx between batches of steps.
+func pollardRho(ctx context.Context, n uint64) (uint64, error) {
+	if n%2 == 0 {
+		return 2, nil
+	}
+	for c := uint64(1); c < n; c++ {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		d, err := brent(ctx, n, c)
+		if err == errNoFactor {
+			// the sequence cycled without a factor, try another polynomial
+			continue
+		}
+		return d, err
+	}
+	return 0, errNoFactor
+}
+
+// brent follows x -> x^2 + c mod n looking for a factor of n.
+func brent(ctx context.Context, n, c uint64) (uint64, error) {
+	const batch = 128
+	f := func(x uint64) uint64 {
+		return (mulMod(x, x, n) + c) % n
+	}
+	absDiff := func(a, b uint64) uint64 {
+		if a > b {
+			return a - b
+		}
+		return b - a
+	}
+
+	y, x, ys := uint64(2), uint64(0), uint64(0)
+	q, g := uint64(1), uint64(1)
+	for r := uint64(1); g == 1; r *= 2 {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		x = y
+		for i := uint64(0); i < r; i++ {
+			y = f(y)
+		}
+		for k := uint64(0); k < r && g == 1; k += batch {
+			ys = y
+			for i := uint64(0); i < batch && i < r-k; i++ {
+				y = f(y)
+				q = mulMod(q, absDiff(x, y), n)
+			}
+			g = GCD(q, n)
+		}
+	}
+	if g == n {
+		// the batch overshot, retrace it one step at a time
+		for g = 1; g == 1; {
+			ys = f(ys)
+			g = GCD(absDiff(x, ys), n)
+		}
+	}
+	if g == n {
+		return 0, errNoFactor
+	}
+	return g, nil
+}
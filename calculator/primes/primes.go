@@ -0,0 +1,108 @@
+// Package primes implements the number theory behind the calculator:
+// primality testing, factorization, sieving and modular arithmetic on 64-bit
+// integers.
+package primes
+
+import "math/bits"
+
+// mulMod returns a * b mod m without overflowing.
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
+
+// ModPow returns base^exp mod m, for m > 0.
+func ModPow(base, exp, m uint64) uint64 {
+	if m == 1 {
+		return 0
+	}
+	result := uint64(1)
+	base %= m
+	for exp > 0 {
+		if exp&1 == 1 {
+			result = mulMod(result, base, m)
+		}
+		base = mulMod(base, base, m)
+		exp >>= 1
+	}
+	return result
+}
+
+// millerRabinBases are enough witnesses for a deterministic test of every
+// 64-bit integer.
+var millerRabinBases = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+// IsPrime reports whether n is prime, using a deterministic Miller-Rabin
+// test.
+func IsPrime(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	for _, p := range millerRabinBases {
+		if n%p == 0 {
+			return n == p
+		}
+	}
+
+	// n-1 = d * 2^s with d odd
+	d := n - 1
+	s := bits.TrailingZeros64(d)
+	d >>= uint(s)
+
+	for _, a := range millerRabinBases {
+		x := ModPow(a, d, n)
+		if x == 1 || x == n-1 {
+			continue
+		}
+		composite := true
+		for r := 1; r < s; r++ {
+			x = mulMod(x, x, n)
+			if x == n-1 {
+				composite = false
+				break
+			}
+		}
+		if composite {
+			return false
+		}
+	}
+	return true
+}
+
+// GCD returns the greatest common divisor of a and b, with GCD(0, 0) = 0.
+func GCD(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// LCM returns the least common multiple of a and b, or false when it
+// overflows. LCM(a, 0) is 0.
+func LCM(a, b uint64) (uint64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	hi, lcm := bits.Mul64(a/GCD(a, b), b)
+	return lcm, hi == 0
+}
+
+// ModInverse returns x in [0, m) with a*x = 1 mod m, or false when a and m
+// are not coprime. m must be positive and below 2^63.
+func ModInverse(a, m uint64) (uint64, bool) {
+	// extended Euclid, the coefficients stay within (-m, m)
+	oldR, r := int64(a%m), int64(m)
+	oldS, s := int64(1), int64(0)
+	for r != 0 {
+		q := oldR / r
+		oldR, r = r, oldR-q*r
+		oldS, s = s, oldS-q*s
+	}
+	if oldR != 1 {
+		return 0, false
+	}
+	if oldS < 0 {
+		oldS += int64(m)
+	}
+	return uint64(oldS) % m, true
+}
@@ -0,0 +1,223 @@
+package primes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestIsPrime(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want bool
+	}{
+		{0, false}, {1, false}, {2, true}, {3, true}, {4, false},
+		{97, true}, {561, false}, // 561 is a Carmichael number
+		{3215031751, false}, // strong pseudoprime to bases 2, 3, 5 and 7
+		{1000000007, true},
+		{9223372036854775783, true}, // largest prime below 2^63
+		{9223372036854775807, false},
+		{18446744073709551557, true}, // largest 64-bit prime
+		{4611686014132420609, false}, // (2^31-1)^2
+	}
+	for _, tt := range tests {
+		if got := IsPrime(tt.n); got != tt.want {
+			t.Errorf("IsPrime(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+// A sieve of Eratosthenes cross-checks IsPrime on small numbers.
+func TestIsPrimeAgreesWithSieve(t *testing.T) {
+	const limit = 100000
+	composite := make([]bool, limit)
+	for i := 2; i < limit; i++ {
+		if !composite[i] {
+			for j := i * i; j < limit; j += i {
+				composite[j] = true
+			}
+		}
+		if got := IsPrime(uint64(i)); got == composite[i] {
+			t.Fatalf("IsPrime(%d) = %v", i, got)
+		}
+	}
+}
+
+func TestFactors(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{n: 1, want: "[]"},
+		{n: 120, want: "[{2 3} {3 1} {5 1}]"},
+		{n: 97, want: "[{97 1}]"},
+		{n: 9223372036854775783, want: "[{9223372036854775783 1}]"},
+		{n: math.MaxInt64, want: "[{7 2} {73 1} {127 1} {337 1} {92737 1} {649657 1}]"},
+		// two primes near 2^31, out of reach of trial division
+		{n: 2147483647 * 2147483629, want: "[{2147483629 1} {2147483647 1}]"},
+		{n: 4611686014132420609, want: "[{2147483647 2}]"},
+		{n: 1 << 62, want: "[{2 62}]"},
+		{n: 1000003 * 1000003 * 1000033, want: "[{1000003 2} {1000033 1}]"},
+	}
+	for _, tt := range tests {
+		factors, err := Factors(context.Background(), tt.n)
+		if err != nil {
+			t.Errorf("Factors(%d) : %v", tt.n, err)
+			continue
+		}
+		if got := fmt.Sprint(factors); got != tt.want {
+			t.Errorf("Factors(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFactorizeStops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Factorize(ctx, 2147483647*2147483629, func(Factor) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Factorize with a cancelled context error = %v, want %v", err, context.Canceled)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err := Factorize(context.Background(), 30, func(Factor) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Factorize error = %v after %d calls, want %v after 1", err, calls, stop)
+	}
+}
+
+func BenchmarkFactorsSemiprime(b *testing.B) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for i := 0; i < b.N; i++ {
+		if _, err := Factors(ctx, 2147483647*2147483629); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGCDAndLCM(t *testing.T) {
+	tests := []struct {
+		a, b     uint64
+		gcd, lcm uint64
+		overflow bool
+	}{
+		{a: 12, b: 18, gcd: 6, lcm: 36},
+		{a: 17, b: 5, gcd: 1, lcm: 85},
+		{a: 0, b: 9, gcd: 9, lcm: 0},
+		{a: 0, b: 0, gcd: 0, lcm: 0},
+		{a: 1 << 40, b: 1 << 50, gcd: 1 << 40, lcm: 1 << 50},
+		{a: 9223372036854775783, b: 1000000007, gcd: 1, overflow: true},
+	}
+	for _, tt := range tests {
+		if got := GCD(tt.a, tt.b); got != tt.gcd {
+			t.Errorf("GCD(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.gcd)
+		}
+		lcm, ok := LCM(tt.a, tt.b)
+		if ok == tt.overflow || ok && lcm != tt.lcm {
+			t.Errorf("LCM(%d, %d) = %d, %v, want %d, %v", tt.a, tt.b, lcm, ok, tt.lcm, !tt.overflow)
+		}
+	}
+}
+
+func TestModPow(t *testing.T) {
+	tests := []struct {
+		base, exp, m, want uint64
+	}{
+		{base: 4, exp: 13, m: 497, want: 445},
+		{base: 2, exp: 0, m: 7, want: 1},
+		{base: 5, exp: 3, m: 1, want: 0},
+		{base: 9223372036854775782, exp: 9223372036854775782, m: 9223372036854775783, want: 1},
+	}
+	for _, tt := range tests {
+		if got := ModPow(tt.base, tt.exp, tt.m); got != tt.want {
+			t.Errorf("ModPow(%d, %d, %d) = %d, want %d", tt.base, tt.exp, tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestModInverse(t *testing.T) {
+	tests := []struct {
+		a, m uint64
+		want uint64
+		ok   bool
+	}{
+		{a: 3, m: 11, want: 4, ok: true},
+		{a: 10, m: 17, want: 12, ok: true},
+		{a: 25, m: 11, want: 4, ok: true},
+		{a: 1, m: 1, want: 0, ok: true},
+		{a: 6, m: 9},
+		{a: 0, m: 7},
+		{a: 2, m: 9223372036854775783, want: 4611686018427387892, ok: true},
+	}
+	for _, tt := range tests {
+		got, ok := ModInverse(tt.a, tt.m)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ModInverse(%d, %d) = %d, %v, want %d, %v", tt.a, tt.m, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func collectPrimes(t *testing.T, lo, hi uint64) []uint64 {
+	t.Helper()
+	var got []uint64
+	if err := PrimesInRange(context.Background(), lo, hi, func(p uint64) error {
+		got = append(got, p)
+		return nil
+	}); err != nil {
+		t.Fatalf("PrimesInRange(%d, %d) : %v", lo, hi, err)
+	}
+	return got
+}
+
+func TestPrimesInRange(t *testing.T) {
+	if got := fmt.Sprint(collectPrimes(t, 0, 30)); got != "[2 3 5 7 11 13 17 19 23 29]" {
+		t.Errorf("PrimesInRange(0, 30) = %s", got)
+	}
+	if got := collectPrimes(t, 24, 28); len(got) != 0 {
+		t.Errorf("PrimesInRange(24, 28) = %v, want none", got)
+	}
+	if got := collectPrimes(t, 30, 20); len(got) != 0 {
+		t.Errorf("PrimesInRange(30, 20) = %v, want none", got)
+	}
+
+	// several segments, checked against IsPrime, below and beyond the
+	// reach of the sieve alone
+	for _, lo := range []uint64{1000000, 1<<40 - 100000, math.MaxInt64 - 3*segmentSize} {
+		hi := lo + 3*segmentSize
+		got := collectPrimes(t, lo, hi)
+		i := 0
+		for n := lo; n <= hi; n++ {
+			if !IsPrime(n) {
+				continue
+			}
+			if i >= len(got) || got[i] != n {
+				t.Fatalf("PrimesInRange(%d, %d) misses %d", lo, hi, n)
+			}
+			i++
+		}
+		if i != len(got) {
+			t.Errorf("PrimesInRange(%d, %d) returned %d primes, want %d", lo, hi, len(got), i)
+		}
+	}
+}
+
+func TestPrimesInRangeCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	err := PrimesInRange(ctx, 0, math.MaxInt64, func(uint64) error {
+		if count++; count == 10 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,100 @@
+package primes
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+const (
+	// segmentSize is the count of numbers sieved at once
+	segmentSize = 1 << 16
+	// sieveLimit bounds the primes used for sieving. Survivors of ranges
+	// beyond sieveLimit^2 are confirmed with IsPrime.
+	sieveLimit = 1 << 20
+)
+
+var (
+	basePrimesOnce sync.Once
+	basePrimes     []uint64
+)
+
+// smallPrimes returns the primes below sieveLimit, computed once.
+func smallPrimes() []uint64 {
+	basePrimesOnce.Do(func() {
+		composite := make([]bool, sieveLimit)
+		for i := 2; i < sieveLimit; i++ {
+			if composite[i] {
+				continue
+			}
+			basePrimes = append(basePrimes, uint64(i))
+			for j := i * i; j < sieveLimit; j += i {
+				composite[j] = true
+			}
+		}
+	})
+	return basePrimes
+}
+
+// PrimesInRange calls fn with the primes between lo and hi inclusive, in
+// increasing order, with hi below 2^63. It sieves one segment at a time, so
+// memory stays bounded whatever the range. It stops early with the error of
+// fn, or ctx.Err() once ctx is done.
+func PrimesInRange(ctx context.Context, lo, hi uint64, fn func(p uint64) error) error {
+	if lo < 2 {
+		lo = 2
+	}
+	base := smallPrimes()
+	composite := make([]bool, segmentSize)
+
+	for segLo := lo; segLo <= hi; segLo += segmentSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		segHi := hi
+		if hi-segLo >= segmentSize {
+			segHi = segLo + segmentSize - 1
+		}
+		segment := composite[:segHi-segLo+1]
+		for i := range segment {
+			segment[i] = false
+		}
+
+		for _, p := range base {
+			if p*p > segHi {
+				break
+			}
+			start := (segLo + p - 1) / p * p
+			if start < p*p {
+				start = p * p
+			}
+			for m := start; m <= segHi; m += p {
+				segment[m-segLo] = true
+			}
+		}
+
+		// the sieve alone is exact when its primes reach the square root
+		exact := isqrt(segHi) < sieveLimit
+		for i, c := range segment {
+			n := segLo + uint64(i)
+			if c || !exact && !IsPrime(n) {
+				continue
+			}
+			if err := fn(n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isqrt(n uint64) uint64 {
+	r := uint64(math.Sqrt(float64(n)))
+	for r*r > n {
+		r--
+	}
+	for (r+1)*(r+1) <= n {
+		r++
+	}
+	return r
+}
@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// ExactLimit is the count of numbers up to which quantiles are exact.
+	ExactLimit = 1024
+	// RelativeAccuracy bounds the relative error of the quantiles of
+	// larger streams.
+	RelativeAccuracy = 0.01
+	maxBuckets       = 2048
+)
+
+// Distribution is a Summary that also answers quantiles. It keeps the first
+// ExactLimit numbers to compute exact quantiles, then switches to a Sketch,
+// so its memory stays bounded.
+type Distribution struct {
+	Summary
+	exact  []float64
+	sorted bool
+	sketch *Sketch
+}
+
+// Add records x.
+func (d *Distribution) Add(x float64) {
+	d.Summary.Add(x)
+	if d.sketch != nil {
+		d.sketch.Add(x)
+		return
+	}
+	d.exact = append(d.exact, x)
+	d.sorted = false
+	if len(d.exact) > ExactLimit {
+		d.sketch = NewSketch(RelativeAccuracy, maxBuckets)
+		for _, v := range d.exact {
+			d.sketch.Add(v)
+		}
+		d.exact = nil
+	}
+}
+
+// Approximate reports whether quantiles are estimated by the sketch.
+func (d *Distribution) Approximate() bool {
+	return d.sketch != nil
+}
+
+// Quantile returns the q-quantile, q between 0 and 1, or 0 when empty.
+// Exact quantiles interpolate linearly between the closest ranks;
+// approximate ones are kept between the minimum and the maximum.
+func (d *Distribution) Quantile(q float64) float64 {
+	if d.sketch != nil {
+		return math.Max(d.Min(), math.Min(d.Max(), d.sketch.Quantile(q)))
+	}
+	if len(d.exact) == 0 {
+		return 0
+	}
+	if !d.sorted {
+		sort.Float64s(d.exact)
+		d.sorted = true
+	}
+	pos := q * float64(len(d.exact)-1)
+	lower := int(math.Floor(pos))
+	if lower+1 >= len(d.exact) {
+		return d.exact[len(d.exact)-1]
+	}
+	frac := pos - float64(lower)
+	return d.exact[lower] + frac*(d.exact[lower+1]-d.exact[lower])
+}
+
+// Median returns the 0.5-quantile.
+func (d *Distribution) Median() float64 {
+	return d.Quantile(0.5)
+}
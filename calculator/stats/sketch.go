@@ -0,0 +1,120 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Sketch estimates quantiles with a bounded relative error, following
+// DDSketch: numbers fall in logarithmic buckets, so the estimate of any
+// quantile is within RelativeAccuracy of a number of that rank. When more
+// than maxBuckets are used, the buckets closest to zero are merged, which
+// only degrades the accuracy of the lowest quantiles by magnitude.
+type Sketch struct {
+	gamma, logGamma float64
+	maxBuckets      int
+	count           int64
+	zeros           int64
+	// positive and negative count the numbers by bucket index of |x|
+	positive, negative map[int]int64
+}
+
+// minIndexable is the smallest magnitude not counted as zero.
+const minIndexable = 1e-300
+
+// NewSketch returns an empty sketch with the given relative accuracy,
+// e.g. 0.01, using at most maxBuckets buckets.
+func NewSketch(relativeAccuracy float64, maxBuckets int) *Sketch {
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &Sketch{
+		gamma:      gamma,
+		logGamma:   math.Log(gamma),
+		maxBuckets: maxBuckets,
+		positive:   make(map[int]int64),
+		negative:   make(map[int]int64),
+	}
+}
+
+// Count returns the number of numbers added.
+func (s *Sketch) Count() int64 { return s.count }
+
+func (s *Sketch) index(x float64) int {
+	return int(math.Ceil(math.Log(x) / s.logGamma))
+}
+
+// value returns the estimate of the numbers in bucket i.
+func (s *Sketch) value(i int) float64 {
+	return 2 * math.Pow(s.gamma, float64(i)) / (s.gamma + 1)
+}
+
+// Add records x.
+func (s *Sketch) Add(x float64) {
+	s.count++
+	switch {
+	case x > minIndexable:
+		s.positive[s.index(x)]++
+	case x < -minIndexable:
+		s.negative[s.index(-x)]++
+	default:
+		s.zeros++
+	}
+	if len(s.positive)+len(s.negative) > s.maxBuckets {
+		s.collapse()
+	}
+}
+
+// collapse merges the two buckets closest to zero of the larger store.
+func (s *Sketch) collapse() {
+	store := s.positive
+	if len(s.negative) > len(s.positive) {
+		store = s.negative
+	}
+	lowest, next := math.MaxInt64, math.MaxInt64
+	for i := range store {
+		if i < lowest {
+			lowest, next = i, lowest
+		} else if i < next {
+			next = i
+		}
+	}
+	store[next] += store[lowest]
+	delete(store, lowest)
+}
+
+// Quantile returns an estimate of the q-quantile, q between 0 and 1, or 0
+// when the sketch is empty.
+func (s *Sketch) Quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+	rank := int64(q * float64(s.count-1))
+
+	// walk the numbers in increasing order: negatives by decreasing
+	// magnitude, zeros, then positives by increasing magnitude
+	var seen int64
+	negative := sortedIndexes(s.negative)
+	for i := len(negative) - 1; i >= 0; i-- {
+		if seen += s.negative[negative[i]]; seen > rank {
+			return -s.value(negative[i])
+		}
+	}
+	if seen += s.zeros; seen > rank {
+		return 0
+	}
+	positive := sortedIndexes(s.positive)
+	for _, i := range positive {
+		if seen += s.positive[i]; seen > rank {
+			return s.value(i)
+		}
+	}
+	return s.value(positive[len(positive)-1])
+}
+
+func sortedIndexes(store map[int]int64) []int {
+	indexes := make([]int, 0, len(store))
+	for i := range store {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
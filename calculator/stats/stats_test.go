@@ -0,0 +1,107 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	var s Summary
+	for _, x := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		s.Add(x)
+	}
+	checks := []struct {
+		name      string
+		got, want float64
+	}{
+		{"count", float64(s.Count()), 8},
+		{"sum", s.Sum(), 40},
+		{"mean", s.Mean(), 5},
+		{"variance", s.Variance(), 4},
+		{"sample variance", s.SampleVariance(), 32.0 / 7},
+		{"min", s.Min(), 2},
+		{"max", s.Max(), 9},
+	}
+	for _, c := range checks {
+		if math.Abs(c.got-c.want) > 1e-12 {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestSummaryIsStable(t *testing.T) {
+	// a large offset ruins the naive sum of squares formula and the naive sum
+	var s Summary
+	for i := 0; i < 1000000; i++ {
+		s.Add(1e9 + float64(i%2))
+		s.Add(0.1)
+	}
+	if got, want := s.Sum(), 1e9*1e6+0.5e6+0.1e6; math.Abs(got-want) > 1e-3 {
+		t.Errorf("sum = %f, want %f", got, want)
+	}
+
+	var v Summary
+	for i := 0; i < 100000; i++ {
+		v.Add(1e9 + float64(i%2))
+	}
+	if got := v.Variance(); math.Abs(got-0.25) > 1e-6 {
+		t.Errorf("variance = %v, want 0.25", got)
+	}
+}
+
+func TestExactQuantiles(t *testing.T) {
+	var d Distribution
+	for _, x := range []float64{4, 1, 3, 2} {
+		d.Add(x)
+	}
+	tests := []struct{ q, want float64 }{
+		{0, 1}, {0.25, 1.75}, {0.5, 2.5}, {0.9, 3.7}, {1, 4},
+	}
+	for _, tt := range tests {
+		if got := d.Quantile(tt.q); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("Quantile(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+	if d.Approximate() {
+		t.Error("Approximate() = true for 4 numbers")
+	}
+}
+
+func TestApproximateQuantiles(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var d Distribution
+	var all []float64
+	for i := 0; i < 200000; i++ {
+		// a mix of signs and magnitudes
+		x := rng.NormFloat64() * 1000
+		if i%10 == 0 {
+			x = rng.ExpFloat64() * 1e6
+		}
+		d.Add(x)
+		all = append(all, x)
+	}
+	sort.Float64s(all)
+	if !d.Approximate() {
+		t.Fatal("Approximate() = false for 200000 numbers")
+	}
+	if len(d.sketch.positive)+len(d.sketch.negative) > maxBuckets {
+		t.Errorf("sketch uses %d buckets", len(d.sketch.positive)+len(d.sketch.negative))
+	}
+
+	for _, q := range []float64{0, 0.01, 0.25, 0.5, 0.75, 0.99, 1} {
+		want := all[int(q*float64(len(all)-1))]
+		got := d.Quantile(q)
+		if math.Abs(got-want) > RelativeAccuracy*math.Abs(want)+1e-9 {
+			t.Errorf("Quantile(%v) = %v, want %v within %v%%", q, got, want, RelativeAccuracy*100)
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	var d Distribution
+	if d.Count() != 0 || d.Mean() != 0 || d.Variance() != 0 || d.Median() != 0 {
+		t.Errorf("empty distribution has count %d, mean %v, variance %v, median %v", d.Count(), d.Mean(), d.Variance(), d.Median())
+	}
+}
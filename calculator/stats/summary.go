@@ -0,0 +1,70 @@
+// Package stats computes descriptive statistics of streams of numbers in
+// bounded memory.
+package stats
+
+import "math"
+
+// Summary tracks the count, sum, mean, variance and extremes of numbers
+// without keeping them. The mean and variance use Welford's algorithm and
+// the sum Neumaier's compensated summation, so neither drifts on long
+// streams. The zero value is empty.
+type Summary struct {
+	count      int64
+	sum, carry float64
+	mean, m2   float64
+	min, max   float64
+}
+
+// Add records x.
+func (s *Summary) Add(x float64) {
+	s.count++
+	if s.count == 1 {
+		s.min, s.max = x, x
+	}
+	s.min = math.Min(s.min, x)
+	s.max = math.Max(s.max, x)
+
+	t := s.sum + x
+	if math.Abs(s.sum) >= math.Abs(x) {
+		s.carry += (s.sum - t) + x
+	} else {
+		s.carry += (x - t) + s.sum
+	}
+	s.sum = t
+
+	delta := x - s.mean
+	s.mean += delta / float64(s.count)
+	s.m2 += delta * (x - s.mean)
+}
+
+// Count returns the number of numbers added.
+func (s *Summary) Count() int64 { return s.count }
+
+// Sum returns the sum of the numbers.
+func (s *Summary) Sum() float64 { return s.sum + s.carry }
+
+// Mean returns the arithmetic mean, 0 when empty.
+func (s *Summary) Mean() float64 { return s.mean }
+
+// Min returns the smallest number, 0 when empty.
+func (s *Summary) Min() float64 { return s.min }
+
+// Max returns the largest number, 0 when empty.
+func (s *Summary) Max() float64 { return s.max }
+
+// Variance returns the population variance, 0 when empty.
+func (s *Summary) Variance() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.m2 / float64(s.count)
+}
+
+// SampleVariance returns the unbiased sample variance, 0 with fewer than
+// two numbers.
+func (s *Summary) SampleVariance() float64 {
+	if s.count < 2 {
+		return 0
+	}
+	return s.m2 / float64(s.count-1)
+}
@@ -0,0 +1,83 @@
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxExponent bounds the powers of the units of a compound, like s^2.
+const maxExponent = 9
+
+// Parse returns the unit written expr: a catalog unit known to Lookup, or
+// catalog units raised to integer powers, multiplied with "*" and divided
+// with "/", e.g. "km/h", "m/s^2" or "1/s". Every unit after a "/" divides,
+// so "kg/m/s^2" is kg/(m*s^2).
+func Parse(expr string) (*Unit, error) {
+	expr = strings.TrimSpace(expr)
+	if u, ok := Lookup(expr); ok {
+		return u, nil
+	}
+
+	u := &Unit{Symbol: expr, factor: big.NewRat(1, 1), offset: new(big.Rat)}
+	rest := expr
+	sign := 1
+	for i := 0; ; i++ {
+		end := strings.IndexAny(rest, "*/")
+		if end < 0 {
+			end = len(rest)
+		}
+		if err := u.multiply(strings.TrimSpace(rest[:end]), sign, i == 0); err != nil {
+			return nil, fmt.Errorf("%w in %q", err, expr)
+		}
+		if end == len(rest) {
+			return u, nil
+		}
+		if rest[end] == '/' {
+			sign = -1
+		}
+		rest = rest[end+1:]
+	}
+}
+
+// multiply multiplies u by term, a unit and an optional power, or by term
+// to the negative power when sign is -1. Only the first term may be "1".
+func (u *Unit) multiply(term string, sign int, first bool) error {
+	if first && term == "1" {
+		return nil
+	}
+	name, power := term, 1
+	if i := strings.IndexByte(term, '^'); i >= 0 {
+		name = strings.TrimSpace(term[:i])
+		p, err := strconv.Atoi(strings.TrimSpace(term[i+1:]))
+		if err != nil || p == 0 || p < -maxExponent || p > maxExponent {
+			return fmt.Errorf("%w : the power of %q must be an integer between -%d and %d, not zero", ErrSyntax, name, maxExponent, maxExponent)
+		}
+		power = p
+	}
+	if name == "" || strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("%w : %q is not a unit", ErrSyntax, term)
+	}
+	base, ok := Lookup(name)
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownUnit, name)
+	}
+	if base.offset.Sign() != 0 {
+		return fmt.Errorf("%w : %s", ErrOffsetInCompound, base)
+	}
+
+	power *= sign
+	for q, exp := range base.Dimension {
+		u.Dimension[q] += exp * power
+	}
+	f := new(big.Rat).Set(base.factor)
+	if power < 0 {
+		f.Inv(f)
+	}
+	for i := 0; i < abs(power); i++ {
+		u.factor.Mul(u.factor, f)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package units
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/grpc-go-new-course/calculator/units/unitspb"
+	"github.com/grpc-go-new-course/rpcerror"
+	"github.com/grpc-go-new-course/validation"
+	"google.golang.org/grpc/codes"
+)
+
+// Service implements UnitService over the catalog.
+type Service struct {
+	unitspb.UnimplementedUnitServiceServer
+}
+
+// NewService returns a UnitService.
+func NewService() *Service {
+	return &Service{}
+}
+
+// Rules adds the request rules of UnitService to v.
+func Rules(v *validation.Validator) *validation.Validator {
+	return v.
+		Add(&unitspb.ConvertUnitsRequest{},
+			validation.Required("from"),
+			validation.Length("from", 1, 100),
+			validation.Required("to"),
+			validation.Length("to", 1, 100),
+		)
+}
+
+func (s *Service) ConvertUnits(ctx context.Context, req *unitspb.ConvertUnitsRequest) (*unitspb.ConvertUnitsResponse, error) {
+	if math.IsNaN(req.GetValue()) || math.IsInf(req.GetValue(), 0) {
+		return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid value : %v", req.GetValue()),
+			rpcerror.BadRequest("value", "must be finite"),
+		)
+	}
+	from, err := Parse(req.GetFrom())
+	if err != nil {
+		return nil, unitError("from", err)
+	}
+	to, err := Parse(req.GetTo())
+	if err != nil {
+		return nil, unitError("to", err)
+	}
+
+	value, err := Convert(req.GetValue(), from, to)
+	switch {
+	case errors.Is(err, ErrIncompatible):
+		return nil, rpcerror.New(codes.InvalidArgument, err.Error(),
+			rpcerror.ErrorInfo("INCOMPATIBLE_UNITS",
+				"from", from.Symbol, "from_dimension", from.Dimension.String(),
+				"to", to.Symbol, "to_dimension", to.Dimension.String(),
+			),
+			rpcerror.BadRequest("to", fmt.Sprintf("must measure %v like %s", from.Dimension, from)),
+		)
+	case errors.Is(err, ErrBelowAbsoluteZero):
+		return nil, rpcerror.New(codes.InvalidArgument, err.Error(),
+			rpcerror.ErrorInfo("BELOW_ABSOLUTE_ZERO", "from", from.Symbol),
+			rpcerror.BadRequest("value", "must not be below absolute zero"),
+		)
+	case errors.Is(err, ErrOutOfRange):
+		return nil, rpcerror.New(codes.OutOfRange, err.Error(),
+			rpcerror.ErrorInfo("CONVERSION_OUT_OF_RANGE", "from", from.Symbol, "to", to.Symbol),
+		)
+	case err != nil:
+		return nil, rpcerror.New(codes.Internal, err.Error())
+	}
+	return &unitspb.ConvertUnitsResponse{Value: value, Dimension: from.Dimension.String()}, nil
+}
+
+func unitError(field string, err error) error {
+	reason := "INVALID_UNIT"
+	if errors.Is(err, ErrUnknownUnit) {
+		reason = "UNKNOWN_UNIT"
+	}
+	return rpcerror.New(codes.InvalidArgument, err.Error(),
+		rpcerror.ErrorInfo(reason, "field", field),
+		rpcerror.BadRequest(field, err.Error()),
+	)
+}
+
+func (s *Service) ListUnits(ctx context.Context, req *unitspb.ListUnitsRequest) (*unitspb.ListUnitsResponse, error) {
+	quantity := req.GetQuantity()
+	known := quantity == ""
+	for _, q := range Quantities {
+		known = known || q == quantity
+	}
+	if !known {
+		return nil, rpcerror.New(codes.InvalidArgument, fmt.Sprintf("unknown quantity %q", quantity),
+			rpcerror.BadRequest("quantity", fmt.Sprintf("must be empty or one of %s", strings.Join(Quantities, ", "))),
+		)
+	}
+
+	res := &unitspb.ListUnitsResponse{}
+	for _, u := range units {
+		if quantity != "" && u.Quantity != quantity {
+			continue
+		}
+		res.Units = append(res.Units, &unitspb.Unit{
+			Symbol:   u.Symbol,
+			Name:     u.Name,
+			Quantity: u.Quantity,
+			Aliases:  u.Aliases,
+		})
+	}
+	return res, nil
+}
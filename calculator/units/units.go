@@ -0,0 +1,253 @@
+// Package units converts quantities between units of length, mass, time,
+// temperature and data size, and the products and quotients of those units,
+// checking that both units measure the same dimension.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Errors returned by Parse and Convert.
+var (
+	ErrSyntax            = errors.New("invalid unit")
+	ErrUnknownUnit       = errors.New("unknown unit")
+	ErrOffsetInCompound  = errors.New("temperature scales with an offset cannot be combined with other units")
+	ErrIncompatible      = errors.New("incompatible units")
+	ErrBelowAbsoluteZero = errors.New("temperature below absolute zero")
+	ErrOutOfRange        = errors.New("converted value out of range")
+)
+
+// Base quantities, the axes of a Dimension.
+const (
+	Length = iota
+	Mass
+	Time
+	Temperature
+	Information
+	baseCount
+)
+
+var baseNames = [baseCount]string{"length", "mass", "time", "temperature", "information"}
+
+// Dimension holds the exponent of each base quantity in a unit, e.g.
+// length 1 and time -2 for accelerations.
+type Dimension [baseCount]int
+
+func base(q int) Dimension {
+	var d Dimension
+	d[q] = 1
+	return d
+}
+
+// String formats d like "length/time^2", or "dimensionless".
+func (d Dimension) String() string {
+	var num, den []string
+	for q, exp := range d {
+		term := baseNames[q]
+		if exp > 1 || exp < -1 {
+			term += fmt.Sprintf("^%d", abs(exp))
+		}
+		switch {
+		case exp > 0:
+			num = append(num, term)
+		case exp < 0:
+			den = append(den, term)
+		}
+	}
+	if len(num) == 0 && len(den) == 0 {
+		return "dimensionless"
+	}
+	s := strings.Join(num, "*")
+	if len(num) == 0 {
+		s = "1"
+	}
+	if len(den) > 0 {
+		s += "/" + strings.Join(den, "/")
+	}
+	return s
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Unit is a unit of the catalog or a product and quotient of such units.
+// A value v in the unit is v * factor + offset in the SI unit of its
+// dimension, and the bit for data sizes.
+type Unit struct {
+	Symbol string
+	Name   string
+	// Quantity is what a catalog unit measures, e.g. "length" or "data size"
+	Quantity  string
+	Aliases   []string
+	Dimension Dimension
+	factor    *big.Rat
+	offset    *big.Rat
+}
+
+func (u *Unit) String() string { return u.Symbol }
+
+// absolute reports whether u measures a temperature from absolute zero or
+// from an offset, rather than a difference of temperatures inside another
+// unit.
+func (u *Unit) absolute() bool {
+	return u.Dimension == base(Temperature) && u.Quantity == "temperature"
+}
+
+// Convert returns value, in from, converted to to.
+func Convert(value float64, from, to *Unit) (float64, error) {
+	if from.Dimension != to.Dimension {
+		return 0, fmt.Errorf("%w : %s measures %v, %s measures %v", ErrIncompatible, from, from.Dimension, to, to.Dimension)
+	}
+	v := new(big.Rat).SetFloat64(value)
+	if v == nil {
+		return 0, fmt.Errorf("%w : %v", ErrOutOfRange, value)
+	}
+	v.Mul(v, from.factor)
+	v.Add(v, from.offset)
+	if from.absolute() && v.Sign() < 0 {
+		return 0, fmt.Errorf("%w : %v %s", ErrBelowAbsoluteZero, value, from)
+	}
+	v.Sub(v, to.offset)
+	v.Quo(v, to.factor)
+	result, _ := v.Float64()
+	if math.IsInf(result, 0) {
+		return 0, fmt.Errorf("%w : %v %s in %s", ErrOutOfRange, value, from, to)
+	}
+	return result, nil
+}
+
+// Quantities lists the quantities of the catalog units, in the order of
+// Units.
+var Quantities = []string{"length", "mass", "time", "temperature", "data size"}
+
+// catalog is the source of every unit: symbol, name, factor and offset as
+// exact decimals or fractions, and aliases.
+var catalog = []struct {
+	quantity  string
+	dimension Dimension
+	units     [][]string
+}{
+	{"length", base(Length), [][]string{
+		{"m", "metre", "1", "0", "meter"},
+		{"km", "kilometre", "1000", "0", "kilometer"},
+		{"cm", "centimetre", "0.01", "0", "centimeter"},
+		{"mm", "millimetre", "0.001", "0", "millimeter"},
+		{"µm", "micrometre", "0.000001", "0", "um", "micrometer", "micron"},
+		{"nm", "nanometre", "0.000000001", "0", "nanometer"},
+		{"in", "inch", "0.0254", "0", "inches"},
+		{"ft", "foot", "0.3048", "0", "feet"},
+		{"yd", "yard", "0.9144", "0"},
+		{"mi", "mile", "1609.344", "0"},
+		{"nmi", "nautical mile", "1852", "0"},
+	}},
+	{"mass", base(Mass), [][]string{
+		{"kg", "kilogram", "1", "0"},
+		{"g", "gram", "0.001", "0"},
+		{"mg", "milligram", "0.000001", "0"},
+		{"µg", "microgram", "0.000000001", "0", "ug"},
+		{"t", "tonne", "1000", "0", "metric ton"},
+		{"lb", "pound", "0.45359237", "0", "lbs"},
+		{"oz", "ounce", "0.028349523125", "0"},
+		{"st", "stone", "6.35029318", "0"},
+	}},
+	{"time", base(Time), [][]string{
+		{"s", "second", "1", "0", "sec"},
+		{"ms", "millisecond", "0.001", "0"},
+		{"µs", "microsecond", "0.000001", "0", "us"},
+		{"ns", "nanosecond", "0.000000001", "0"},
+		{"min", "minute", "60", "0"},
+		{"h", "hour", "3600", "0", "hr"},
+		{"d", "day", "86400", "0"},
+		{"wk", "week", "604800", "0"},
+		{"yr", "Julian year", "31557600", "0", "year", "years"},
+	}},
+	{"temperature", base(Temperature), [][]string{
+		{"K", "kelvin", "1", "0"},
+		{"°C", "degree Celsius", "1", "273.15", "degC", "celsius"},
+		{"°F", "degree Fahrenheit", "5/9", "45967/180", "degF", "fahrenheit"},
+		{"°R", "degree Rankine", "5/9", "0", "degR", "rankine"},
+	}},
+	{"data size", base(Information), [][]string{
+		{"bit", "bit", "1", "0", "b"},
+		{"B", "byte", "8", "0"},
+		{"kbit", "kilobit", "1000", "0", "kb"},
+		{"Mbit", "megabit", "1000000", "0", "Mb"},
+		{"Gbit", "gigabit", "1000000000", "0", "Gb"},
+		{"kB", "kilobyte", "8000", "0", "KB"},
+		{"MB", "megabyte", "8000000", "0"},
+		{"GB", "gigabyte", "8000000000", "0"},
+		{"TB", "terabyte", "8000000000000", "0"},
+		{"PB", "petabyte", "8000000000000000", "0"},
+		{"KiB", "kibibyte", "8192", "0"},
+		{"MiB", "mebibyte", "8388608", "0"},
+		{"GiB", "gibibyte", "8589934592", "0"},
+		{"TiB", "tebibyte", "8796093022208", "0"},
+		{"PiB", "pebibyte", "9007199254740992", "0"},
+	}},
+}
+
+var (
+	units []*Unit
+	// bySymbol indexes units by symbol and alias, case sensitive as "mm"
+	// and "Mm" differ
+	bySymbol = map[string]*Unit{}
+	// byName indexes units by name and plural name, in lower case
+	byName = map[string]*Unit{}
+)
+
+func init() {
+	rat := func(s string) *big.Rat {
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			panic("units: invalid number " + s)
+		}
+		return r
+	}
+	for _, q := range catalog {
+		for _, def := range q.units {
+			u := &Unit{
+				Symbol:    def[0],
+				Name:      def[1],
+				Quantity:  q.quantity,
+				Aliases:   def[4:],
+				Dimension: q.dimension,
+				factor:    rat(def[2]),
+				offset:    rat(def[3]),
+			}
+			units = append(units, u)
+			for _, symbol := range append([]string{u.Symbol}, u.Aliases...) {
+				if _, ok := bySymbol[symbol]; ok {
+					panic("units: duplicate symbol " + symbol)
+				}
+				bySymbol[symbol] = u
+			}
+			name := strings.ToLower(u.Name)
+			byName[name] = u
+			byName[name+"s"] = u
+		}
+	}
+}
+
+// Units returns the catalog units, grouped by quantity in the order of
+// Quantities.
+func Units() []*Unit {
+	return append([]*Unit(nil), units...)
+}
+
+// Lookup returns the catalog unit known by name: its symbol or an alias, or
+// its name, singular or plural, in any case.
+func Lookup(name string) (*Unit, bool) {
+	if u, ok := bySymbol[name]; ok {
+		return u, true
+	}
+	u, ok := byName[strings.ToLower(name)]
+	return u, ok
+}
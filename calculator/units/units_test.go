@@ -0,0 +1,136 @@
+package units
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/grpc-go-new-course/calculator/units/unitspb"
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/grpc-go-new-course/rpcerror"
+	"github.com/grpc-go-new-course/validation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    float64
+		from, to string
+		want     float64
+		err      error
+	}{
+		{value: 1, from: "ft", to: "in", want: 12},
+		{value: 0.1, from: "mile", to: "feet", want: 528},
+		{value: 5, from: "km", to: "Metres", want: 5000},
+		{value: 1, from: "nautical mile", to: "m", want: 1852},
+		{value: 16, from: "oz", to: "lb", want: 1},
+		{value: 90, from: "min", to: "h", want: 1.5},
+		{value: 100, from: "°C", to: "degF", want: 212},
+		{value: -40, from: "fahrenheit", to: "celsius", want: -40},
+		{value: 0, from: "K", to: "°R", want: 0},
+		{value: 1, from: "GiB", to: "MB", want: 1073.741824},
+		{value: 100, from: "Mbit/s", to: "MB/s", want: 12.5},
+		{value: 36, from: "km/h", to: "m/s", want: 10},
+		{value: 1, from: "m/s^2", to: "km/h^2", want: 12960},
+		{value: 2, from: "1/ms", to: "1/s", want: 2000},
+		{value: 1, from: "kg*m/s^2", to: "g * cm / s^2", want: 100000},
+		{value: 1, from: "m", to: "s", err: ErrIncompatible},
+		{value: 1, from: "km/h", to: "km", err: ErrIncompatible},
+		{value: 1, from: "furlong", to: "m", err: ErrUnknownUnit},
+		{value: 1, from: "Mm", to: "m", err: ErrUnknownUnit},
+		{value: 1, from: "°C/s", to: "K/s", err: ErrOffsetInCompound},
+		{value: 1, from: "m^x", to: "m", err: ErrSyntax},
+		{value: 1, from: "m/", to: "m", err: ErrSyntax},
+		{value: -1, from: "K", to: "°C", err: ErrBelowAbsoluteZero},
+		{value: -300, from: "°C", to: "K", err: ErrBelowAbsoluteZero},
+		{value: 1e300, from: "PB^9", to: "bit^9", err: ErrOutOfRange},
+	}
+	for _, tt := range tests {
+		from, err := Parse(tt.from)
+		var to *Unit
+		if err == nil {
+			to, err = Parse(tt.to)
+		}
+		var got float64
+		if err == nil {
+			got, err = Convert(tt.value, from, to)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%v %s in %s : error %v, want %v", tt.value, tt.from, tt.to, err, tt.err)
+			continue
+		}
+		if err == nil && math.Abs(got-tt.want) > 1e-12*math.Abs(tt.want) {
+			t.Errorf("%v %s = %v %s, want %v", tt.value, tt.from, got, tt.to, tt.want)
+		}
+	}
+}
+
+func TestDimensionString(t *testing.T) {
+	tests := []struct {
+		unit, want string
+	}{
+		{"km", "length"},
+		{"m/s^2", "length/time^2"},
+		{"kg*m^2/s^2/K", "length^2*mass/time^2/temperature"},
+		{"1/s", "1/time"},
+		{"m/m", "dimensionless"},
+	}
+	for _, tt := range tests {
+		u, err := Parse(tt.unit)
+		if err != nil {
+			t.Fatalf("Parse(%q) : %v", tt.unit, err)
+		}
+		if got := u.Dimension.String(); got != tt.want {
+			t.Errorf("dimension of %s = %s, want %s", tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestService(t *testing.T) {
+	validator := Rules(validation.New())
+	conn := grpctest.Start(t, func(s *grpc.Server) {
+		unitspb.RegisterUnitServiceServer(s, NewService())
+	}, grpc.ChainUnaryInterceptor(validator.UnaryServerInterceptor()))
+	client := unitspb.NewUnitServiceClient(conn)
+
+	res, err := client.ConvertUnits(context.Background(), &unitspb.ConvertUnitsRequest{Value: 36, From: "km/h", To: "m/s"})
+	if err != nil {
+		t.Fatalf("ConvertUnits : %v", err)
+	}
+	if res.GetValue() != 10 || res.GetDimension() != "length/time" {
+		t.Errorf("got %v %s, want 10 length/time", res.GetValue(), res.GetDimension())
+	}
+
+	_, err = client.ConvertUnits(context.Background(), &unitspb.ConvertUnitsRequest{Value: 1, From: "kg", To: "s"})
+	if grpctest.AssertCode(t, err, codes.InvalidArgument) {
+		info := rpcerror.FromError(err).ErrorInfo
+		if info.GetReason() != "INCOMPATIBLE_UNITS" || info.GetMetadata()["to_dimension"] != "time" {
+			t.Errorf("got error info %v, want INCOMPATIBLE_UNITS to time", info)
+		}
+	}
+	_, err = client.ConvertUnits(context.Background(), &unitspb.ConvertUnitsRequest{Value: 1, To: "s"})
+	grpctest.AssertCode(t, err, codes.InvalidArgument)
+
+	list, err := client.ListUnits(context.Background(), &unitspb.ListUnitsRequest{Quantity: "temperature"})
+	if err != nil {
+		t.Fatalf("ListUnits : %v", err)
+	}
+	var symbols []string
+	for _, u := range list.GetUnits() {
+		symbols = append(symbols, u.GetSymbol())
+	}
+	if len(symbols) != 4 || symbols[0] != "K" {
+		t.Errorf("got temperature units %v, want K, °C, °F and °R", symbols)
+	}
+	all, err := client.ListUnits(context.Background(), &unitspb.ListUnitsRequest{})
+	if err != nil {
+		t.Fatalf("ListUnits : %v", err)
+	}
+	if len(all.GetUnits()) != len(Units()) {
+		t.Errorf("got %d units, want %d", len(all.GetUnits()), len(Units()))
+	}
+	_, err = client.ListUnits(context.Background(), &unitspb.ListUnitsRequest{Quantity: "volume"})
+	grpctest.AssertCode(t, err, codes.InvalidArgument)
+}
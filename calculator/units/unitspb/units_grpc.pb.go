@@ -0,0 +1,137 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package unitspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// UnitServiceClient is the client API for UnitService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type UnitServiceClient interface {
+	ConvertUnits(ctx context.Context, in *ConvertUnitsRequest, opts ...grpc.CallOption) (*ConvertUnitsResponse, error)
+	ListUnits(ctx context.Context, in *ListUnitsRequest, opts ...grpc.CallOption) (*ListUnitsResponse, error)
+}
+
+type unitServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUnitServiceClient(cc grpc.ClientConnInterface) UnitServiceClient {
+	return &unitServiceClient{cc}
+}
+
+func (c *unitServiceClient) ConvertUnits(ctx context.Context, in *ConvertUnitsRequest, opts ...grpc.CallOption) (*ConvertUnitsResponse, error) {
+	out := new(ConvertUnitsResponse)
+	err := c.cc.Invoke(ctx, "/units.UnitService/ConvertUnits", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *unitServiceClient) ListUnits(ctx context.Context, in *ListUnitsRequest, opts ...grpc.CallOption) (*ListUnitsResponse, error) {
+	out := new(ListUnitsResponse)
+	err := c.cc.Invoke(ctx, "/units.UnitService/ListUnits", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UnitServiceServer is the server API for UnitService service.
+// All implementations must embed UnimplementedUnitServiceServer
+// for forward compatibility
+type UnitServiceServer interface {
+	ConvertUnits(context.Context, *ConvertUnitsRequest) (*ConvertUnitsResponse, error)
+	ListUnits(context.Context, *ListUnitsRequest) (*ListUnitsResponse, error)
+	mustEmbedUnimplementedUnitServiceServer()
+}
+
+// UnimplementedUnitServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedUnitServiceServer struct {
+}
+
+func (UnimplementedUnitServiceServer) ConvertUnits(context.Context, *ConvertUnitsRequest) (*ConvertUnitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConvertUnits not implemented")
+}
+func (UnimplementedUnitServiceServer) ListUnits(context.Context, *ListUnitsRequest) (*ListUnitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUnits not implemented")
+}
+func (UnimplementedUnitServiceServer) mustEmbedUnimplementedUnitServiceServer() {}
+
+// UnsafeUnitServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to UnitServiceServer will
+// result in compilation errors.
+type UnsafeUnitServiceServer interface {
+	mustEmbedUnimplementedUnitServiceServer()
+}
+
+func RegisterUnitServiceServer(s grpc.ServiceRegistrar, srv UnitServiceServer) {
+	s.RegisterService(&UnitService_ServiceDesc, srv)
+}
+
+func _UnitService_ConvertUnits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertUnitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UnitServiceServer).ConvertUnits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/units.UnitService/ConvertUnits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UnitServiceServer).ConvertUnits(ctx, req.(*ConvertUnitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UnitService_ListUnits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUnitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UnitServiceServer).ListUnits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/units.UnitService/ListUnits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UnitServiceServer).ListUnits(ctx, req.(*ListUnitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// UnitService_ServiceDesc is the grpc.ServiceDesc for UnitService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var UnitService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "units.UnitService",
+	HandlerType: (*UnitServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ConvertUnits",
+			Handler:    _UnitService_ConvertUnits_Handler,
+		},
+		{
+			MethodName: "ListUnits",
+			Handler:    _UnitService_ListUnits_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "calculator/units/unitspb/units.proto",
+}
@@ -0,0 +1,81 @@
+// Package window groups streams of numbers into count or time windows,
+// tumbling or sliding, and aggregates each window.
+package window
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Aggregation is the function computed over each window.
+type Aggregation int
+
+const (
+	Max Aggregation = iota
+	Min
+	Sum
+	Mean
+	// TopK keeps the K largest values
+	TopK
+)
+
+// Window is a closed window and the values it holds.
+type Window struct {
+	// From and To delimit count windows: the values at positions From up
+	// to, but excluding, To of the stream.
+	From, To int64
+	// Start and End delimit time windows, End excluded.
+	Start, End time.Time
+	// Values are in stream order for count windows, in time order for
+	// time windows.
+	Values []float64
+	// Partial is set on the windows flushed when the stream ended before
+	// they were complete.
+	Partial bool
+}
+
+// Result is the aggregate of a window.
+type Result struct {
+	Count int
+	// Value is the maximum, minimum, sum or mean of the window
+	Value float64
+	// Top holds the K largest values of the window, largest first
+	Top []float64
+}
+
+// Aggregate computes agg over values; k is the count of values kept by
+// TopK.
+func Aggregate(agg Aggregation, k int, values []float64) Result {
+	r := Result{Count: len(values)}
+	if len(values) == 0 {
+		return r
+	}
+	switch agg {
+	case Max:
+		r.Value = math.Inf(-1)
+		for _, v := range values {
+			r.Value = math.Max(r.Value, v)
+		}
+	case Min:
+		r.Value = math.Inf(1)
+		for _, v := range values {
+			r.Value = math.Min(r.Value, v)
+		}
+	case Sum, Mean:
+		for _, v := range values {
+			r.Value += v
+		}
+		if agg == Mean {
+			r.Value /= float64(len(values))
+		}
+	case TopK:
+		top := append([]float64(nil), values...)
+		sort.Sort(sort.Reverse(sort.Float64Slice(top)))
+		if len(top) > k {
+			top = top[:k]
+		}
+		r.Top = top
+	}
+	return r
+}
@@ -0,0 +1,53 @@
+package window
+
+// CountWindows groups values by position: windows of Size values starting
+// every Slide values. Slide equal to Size gives tumbling windows, a smaller
+// Slide overlapping sliding ones.
+type CountWindows struct {
+	size, slide int64
+	// ring holds the last size values
+	ring     []float64
+	received int64
+	// emitted counts the windows closed so far
+	emitted int64
+}
+
+// NewCountWindows returns count windows of size values every slide values,
+// with 0 < slide <= size.
+func NewCountWindows(size, slide int) *CountWindows {
+	return &CountWindows{size: int64(size), slide: int64(slide), ring: make([]float64, size)}
+}
+
+// values returns the values at positions from up to to, which must still
+// be in the ring.
+func (w *CountWindows) values(from, to int64) []float64 {
+	values := make([]float64, 0, to-from)
+	for i := from; i < to; i++ {
+		values = append(values, w.ring[i%w.size])
+	}
+	return values
+}
+
+// Add records x and returns the window it completes, if any.
+func (w *CountWindows) Add(x float64) []Window {
+	w.ring[w.received%w.size] = x
+	w.received++
+
+	start := w.emitted * w.slide
+	if w.received != start+w.size {
+		return nil
+	}
+	w.emitted++
+	return []Window{{From: start, To: w.received, Values: w.values(start, w.received)}}
+}
+
+// Flush returns the earliest incomplete window, once the stream ended,
+// holding the values received since its start.
+func (w *CountWindows) Flush() []Window {
+	start := w.emitted * w.slide
+	if start >= w.received {
+		return nil
+	}
+	w.emitted++
+	return []Window{{From: start, To: w.received, Values: w.values(start, w.received), Partial: true}}
+}
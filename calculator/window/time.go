@@ -0,0 +1,184 @@
+package window
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrTooManyValues is returned when more values than the limit of a
+	// TimeWindows wait for their windows to close.
+	ErrTooManyValues = errors.New("too many values buffered")
+	// ErrOutOfRange is returned for values whose windows do not fit in the
+	// int64 nanoseconds since the Unix epoch windows are aligned on, roughly
+	// those before 1678 or after 2262.
+	ErrOutOfRange = errors.New("timestamp out of range")
+)
+
+// minTime and maxTime bound the times with nanoseconds since the Unix epoch
+// in an int64.
+var (
+	minTime = time.Unix(0, math.MinInt64)
+	maxTime = time.Unix(0, math.MaxInt64)
+)
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+// TimeWindows groups values by timestamp: windows of Size starting at every
+// multiple of Slide since the Unix epoch. Slide equal to Size gives tumbling
+// windows, a smaller Slide overlapping sliding ones.
+//
+// A window closes once the watermark, the latest time known to have passed,
+// reaches its end. Values older than the earliest open window are late:
+// they are dropped and counted. Windows without values are skipped.
+type TimeWindows struct {
+	size, slide time.Duration
+	limit       int
+
+	// samples are sorted by time, oldest first, values of the same time in
+	// arrival order
+	samples []sample
+	// next is the start of the earliest open window, zero before the first
+	// value
+	next      time.Time
+	watermark time.Time
+	late      int64
+}
+
+// NewTimeWindows returns time windows of size every slide, with
+// 0 < slide <= size, buffering at most limit values.
+func NewTimeWindows(size, slide time.Duration, limit int) *TimeWindows {
+	return &TimeWindows{size: size, slide: slide, limit: limit}
+}
+
+// firstStart returns the start of the earliest window holding t, which Add
+// checked to be in range.
+func (w *TimeWindows) firstStart(t time.Time) time.Time {
+	// windows holding t start in (t-size, t]
+	after := t.Add(-w.size).UnixNano()
+	start := after - mod(after, int64(w.slide)) + int64(w.slide)
+	return time.Unix(0, start)
+}
+
+func mod(a, b int64) int64 {
+	m := a % b
+	if m < 0 {
+		m += b
+	}
+	return m
+}
+
+// Add records x at t. Late values are dropped and reported by Late.
+func (w *TimeWindows) Add(x float64, t time.Time) error {
+	// the earliest window holding t starts after t-size
+	if t.Add(-w.size).Before(minTime) || t.After(maxTime) {
+		return ErrOutOfRange
+	}
+	if len(w.samples) == 0 {
+		// skip the empty windows since the previous value
+		if first := w.firstStart(t); w.next.IsZero() || first.After(w.next) {
+			w.next = first
+		}
+	}
+	if t.Before(w.next) {
+		w.late++
+		return nil
+	}
+	if len(w.samples) >= w.limit {
+		return ErrTooManyValues
+	}
+	// values mostly arrive in order, appending them
+	i := len(w.samples)
+	if i > 0 && t.Before(w.samples[i-1].at) {
+		i = w.after(t)
+	}
+	w.samples = append(w.samples, sample{})
+	copy(w.samples[i+1:], w.samples[i:])
+	w.samples[i] = sample{at: t, value: x}
+	return nil
+}
+
+// after returns the index of the first sample later than t.
+func (w *TimeWindows) after(t time.Time) int {
+	return sort.Search(len(w.samples), func(i int) bool { return w.samples[i].at.After(t) })
+}
+
+// from returns the index of the first sample at t or later.
+func (w *TimeWindows) from(t time.Time) int {
+	return sort.Search(len(w.samples), func(i int) bool { return !w.samples[i].at.Before(t) })
+}
+
+// Late returns the count of late values dropped since the previous call.
+func (w *TimeWindows) Late() int64 {
+	late := w.late
+	w.late = 0
+	return late
+}
+
+// NextEnd returns the end of the earliest open window holding values.
+func (w *TimeWindows) NextEnd() (time.Time, bool) {
+	if len(w.samples) == 0 {
+		return time.Time{}, false
+	}
+	return w.next.Add(w.size), true
+}
+
+// Advance moves the watermark to t, if later, and returns the windows it
+// closes. A value belongs to size/slide windows, so closing them may take a
+// while: Advance stops with the error of ctx once it is done.
+func (w *TimeWindows) Advance(ctx context.Context, t time.Time) ([]Window, error) {
+	if t.After(w.watermark) {
+		w.watermark = t
+	}
+	var closed []Window
+	for len(w.samples) > 0 && !w.next.Add(w.size).After(w.watermark) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		closed = append(closed, w.pop(false)...)
+	}
+	return closed, nil
+}
+
+// Flush returns the open windows holding values, once the stream ended. It
+// stops with the error of ctx once it is done.
+func (w *TimeWindows) Flush(ctx context.Context) ([]Window, error) {
+	var closed []Window
+	for len(w.samples) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		closed = append(closed, w.pop(true)...)
+	}
+	return closed, nil
+}
+
+// pop closes the earliest open window, returning it unless empty, and drops
+// the values no later window holds.
+func (w *TimeWindows) pop(partial bool) []Window {
+	start, end := w.next, w.next.Add(w.size)
+	win := Window{Start: start, End: end, Partial: partial}
+	for _, s := range w.samples[w.from(start):w.from(end)] {
+		win.Values = append(win.Values, s.value)
+	}
+
+	w.next = start.Add(w.slide)
+	w.samples = w.samples[w.from(w.next):]
+	if len(w.samples) > 0 {
+		// jump over the empty windows up to the next value
+		if first := w.firstStart(w.samples[0].at); first.After(w.next) {
+			w.next = first
+		}
+	}
+
+	if len(win.Values) == 0 {
+		return nil
+	}
+	return []Window{win}
+}
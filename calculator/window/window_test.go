@@ -0,0 +1,228 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// describe renders windows compactly, e.g. "[0,3)=[1 2 3]".
+func describe(windows []Window) string {
+	s := ""
+	for _, w := range windows {
+		if w.End.IsZero() {
+			s += fmt.Sprintf("[%d,%d)=%v", w.From, w.To, w.Values)
+		} else {
+			s += fmt.Sprintf("[%ds,%ds)=%v", w.Start.Unix(), w.End.Unix(), w.Values)
+		}
+		if w.Partial {
+			s += "*"
+		}
+		s += " "
+	}
+	return s
+}
+
+func TestCountWindows(t *testing.T) {
+	tests := []struct {
+		name        string
+		size, slide int
+		values      int
+		want        string
+	}{
+		{name: "tumbling", size: 3, slide: 3, values: 7, want: "[0,3)=[1 2 3] [3,6)=[4 5 6] [6,7)=[7]* "},
+		{name: "tumbling exact", size: 2, slide: 2, values: 4, want: "[0,2)=[1 2] [2,4)=[3 4] "},
+		{name: "sliding", size: 3, slide: 1, values: 5, want: "[0,3)=[1 2 3] [1,4)=[2 3 4] [2,5)=[3 4 5] [3,5)=[4 5]* "},
+		{name: "sliding by two", size: 4, slide: 2, values: 7, want: "[0,4)=[1 2 3 4] [2,6)=[3 4 5 6] [4,7)=[5 6 7]* "},
+		{name: "short stream", size: 5, slide: 1, values: 2, want: "[0,2)=[1 2]* "},
+		{name: "empty", size: 5, slide: 5},
+	}
+	for _, tt := range tests {
+		w := NewCountWindows(tt.size, tt.slide)
+		var got []Window
+		for i := 1; i <= tt.values; i++ {
+			got = append(got, w.Add(float64(i))...)
+		}
+		got = append(got, w.Flush()...)
+		if s := describe(got); s != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, s, tt.want)
+		}
+	}
+}
+
+func TestTimeWindows(t *testing.T) {
+	at := func(seconds float64) time.Time {
+		return time.Unix(0, int64(seconds*float64(time.Second)))
+	}
+	type event struct {
+		at    float64
+		value float64
+	}
+	tests := []struct {
+		name        string
+		size, slide time.Duration
+		events      []event
+		want        string
+		late        int64
+	}{
+		{
+			name: "tumbling", size: 10 * time.Second, slide: 10 * time.Second,
+			events: []event{{1, 1}, {5, 2}, {12, 3}, {19, 4}, {21, 5}},
+			want:   "[0s,10s)=[1 2] [10s,20s)=[3 4] [20s,30s)=[5]* ",
+		},
+		{
+			name: "sliding", size: 10 * time.Second, slide: 5 * time.Second,
+			events: []event{{1, 1}, {6, 2}, {11, 3}},
+			want:   "[-5s,5s)=[1] [0s,10s)=[1 2] [5s,15s)=[2 3]* [10s,20s)=[3]* ",
+		},
+		{
+			name: "gap skips empty windows", size: 10 * time.Second, slide: 10 * time.Second,
+			events: []event{{1, 1}, {1000, 2}},
+			want:   "[0s,10s)=[1] [1000s,1010s)=[2]* ",
+		},
+		{
+			// values come out in time order
+			name: "out of order within the window", size: 10 * time.Second, slide: 10 * time.Second,
+			events: []event{{5, 1}, {2, 2}, {11, 3}, {4, 4}},
+			want:   "[0s,10s)=[2 1] [10s,20s)=[3]* ",
+			late:   1,
+		},
+		{
+			name: "out of order sliding", size: 10 * time.Second, slide: 5 * time.Second,
+			events: []event{{7, 1}, {3, 2}, {12, 3}, {6, 4}},
+			want:   "[0s,10s)=[2 1] [5s,15s)=[4 1 3]* [10s,20s)=[3]* ",
+		},
+	}
+	for _, tt := range tests {
+		w := NewTimeWindows(tt.size, tt.slide, 100)
+		var got []Window
+		for _, e := range tt.events {
+			if err := w.Add(e.value, at(e.at)); err != nil {
+				t.Fatalf("%s: Add : %v", tt.name, err)
+			}
+			closed, err := w.Advance(context.Background(), at(e.at))
+			if err != nil {
+				t.Fatalf("%s: Advance : %v", tt.name, err)
+			}
+			got = append(got, closed...)
+		}
+		flushed, err := w.Flush(context.Background())
+		if err != nil {
+			t.Fatalf("%s: Flush : %v", tt.name, err)
+		}
+		got = append(got, flushed...)
+		if s := describe(got); s != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, s, tt.want)
+		}
+		if late := w.Late(); late != tt.late {
+			t.Errorf("%s: got %d late values, want %d", tt.name, late, tt.late)
+		}
+	}
+}
+
+func TestTimeWindowsLimit(t *testing.T) {
+	w := NewTimeWindows(time.Minute, time.Minute, 2)
+	now := time.Unix(0, 0)
+	for i := 0; i < 2; i++ {
+		if err := w.Add(1, now); err != nil {
+			t.Fatalf("Add : %v", err)
+		}
+	}
+	if err := w.Add(1, now); err != ErrTooManyValues {
+		t.Errorf("Add beyond the limit error = %v, want %v", err, ErrTooManyValues)
+	}
+	if end, ok := w.NextEnd(); !ok || !end.Equal(now.Add(time.Minute)) {
+		t.Errorf("NextEnd() = %v, %v, want %v", end, ok, now.Add(time.Minute))
+	}
+}
+
+func TestTimeWindowsOutOfRange(t *testing.T) {
+	tests := []struct {
+		name string
+		at   time.Time
+		err  error
+	}{
+		{name: "before 1678", at: time.Date(1600, 1, 1, 0, 0, 0, 0, time.UTC), err: ErrOutOfRange},
+		// the earliest window holding it would start before 1678
+		{name: "earliest nanosecond", at: time.Unix(0, math.MinInt64), err: ErrOutOfRange},
+		{name: "a window later", at: time.Unix(0, math.MinInt64).Add(time.Minute)},
+		{name: "latest", at: time.Unix(0, math.MaxInt64)},
+		{name: "after 2262", at: time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC), err: ErrOutOfRange},
+	}
+	for _, tt := range tests {
+		w := NewTimeWindows(time.Minute, time.Second, 10)
+		if err := w.Add(1, tt.at); err != tt.err {
+			t.Errorf("%s: Add error = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if tt.err != nil {
+			continue
+		}
+		// every window holding the value starts and ends around it
+		closed, err := w.Flush(context.Background())
+		if err != nil {
+			t.Fatalf("%s: Flush : %v", tt.name, err)
+		}
+		if len(closed) != 60 {
+			t.Fatalf("%s: got %d windows, want 60", tt.name, len(closed))
+		}
+		for _, win := range closed {
+			if win.Start.After(tt.at) || !win.End.After(tt.at) {
+				t.Errorf("%s: window [%v, %v) does not hold %v", tt.name, win.Start, win.End, tt.at)
+			}
+		}
+	}
+}
+
+func TestTimeWindowsCancel(t *testing.T) {
+	w := NewTimeWindows(time.Hour, time.Millisecond, 10)
+	if err := w.Add(1, time.Unix(0, 0)); err != nil {
+		t.Fatalf("Add : %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := w.Advance(ctx, time.Unix(7200, 0)); err != context.Canceled {
+		t.Errorf("Advance error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := w.Flush(ctx); err != context.Canceled {
+		t.Errorf("Flush error = %v, want %v", err, context.Canceled)
+	}
+}
+
+// BenchmarkTimeWindowsSliding closes the thousand windows of each of many
+// buffered values.
+func BenchmarkTimeWindowsSliding(b *testing.B) {
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		w := NewTimeWindows(time.Second, time.Millisecond, 10000)
+		for j := 0; j < 10000; j++ {
+			if err := w.Add(float64(j), time.Unix(0, int64(j)*int64(100*time.Microsecond))); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if _, err := w.Flush(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	values := []float64{3, -1, 7, 7, 2}
+	tests := []struct {
+		agg  Aggregation
+		want string
+	}{
+		{Max, "{5 7 []}"},
+		{Min, "{5 -1 []}"},
+		{Sum, "{5 18 []}"},
+		{Mean, "{5 3.6 []}"},
+		{TopK, "{5 0 [7 7 3]}"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(Aggregate(tt.agg, 3, values)); got != tt.want {
+			t.Errorf("Aggregate(%d) = %s, want %s", tt.agg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Command certgen creates a development CA and the certificates the TLS
+// servers and clients of this repository expect:
+//
+//	ssl/ca.crt, ssl/ca.key          the CA, reused by later runs
+//	ssl/server.crt, ssl/server.pem  the server certificate and key
+//	ssl/<client>.crt, <client>.pem  one client certificate per -clients name
+//
+// The certificates are only meant for local development.
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func main() {
+	outDir := flag.String("out", "ssl", "directory receiving the certificates")
+	hosts := flag.String("hosts", "localhost,127.0.0.1,::1", "comma separated DNS names and IPs of the server certificate")
+	clients := flag.String("clients", "client", "comma separated names of the client certificates to create for mutual TLS")
+	validFor := flag.Duration("valid-for", 365*24*time.Hour, "validity of the server and client certificates")
+	newCA := flag.Bool("new-ca", false, "create a new CA even if one already exists in -out")
+	flag.Parse()
+
+	clientNames := splitList(*clients)
+	for _, name := range clientNames {
+		if err := checkClientName(name); err != nil {
+			log.Fatalf("invalid client name %q : %v", name, err)
+		}
+	}
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		log.Fatalf("unable to create output directory : %v", err)
+	}
+
+	caCert, caKey, err := loadOrCreateCA(*outDir, *newCA)
+	if err != nil {
+		log.Fatalf("unable to set up CA : %v", err)
+	}
+
+	serverTemplate, err := template(splitList(*hosts)[0], *validFor)
+	if err != nil {
+		log.Fatalf("unable to create server certificate : %v", err)
+	}
+	serverTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, host := range splitList(*hosts) {
+		if ip := net.ParseIP(host); ip != nil {
+			serverTemplate.IPAddresses = append(serverTemplate.IPAddresses, ip)
+		} else {
+			serverTemplate.DNSNames = append(serverTemplate.DNSNames, host)
+		}
+	}
+	if err := issue(*outDir, "server", serverTemplate, caCert, caKey); err != nil {
+		log.Fatalf("unable to create server certificate : %v", err)
+	}
+
+	for _, name := range clientNames {
+		clientTemplate, err := template(name, *validFor)
+		if err != nil {
+			log.Fatalf("unable to create client certificate %q : %v", name, err)
+		}
+		clientTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		if err := issue(*outDir, name, clientTemplate, caCert, caKey); err != nil {
+			log.Fatalf("unable to create client certificate %q : %v", name, err)
+		}
+	}
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return []string{"localhost"}
+	}
+	return items
+}
+
+// checkClientName rejects client names that would not name a new file of
+// -out: path separators, dot files, and the names of the CA and server
+// files, which are compared without case for case-insensitive file systems.
+func checkClientName(name string) error {
+	if strings.ContainsAny(name, `/\`) || strings.ContainsRune(name, filepath.Separator) {
+		return fmt.Errorf("must not contain a path separator")
+	}
+	if strings.HasPrefix(name, ".") {
+		return fmt.Errorf("must not start with a dot")
+	}
+	switch strings.ToLower(name) {
+	case "ca", "server":
+		return fmt.Errorf("is reserved for the %s certificate", strings.ToLower(name))
+	}
+	return nil
+}
+
+func template(commonName string, validFor time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   commonName,
+			Organization: []string{"grpc-go-new-course development"},
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validFor),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+	}, nil
+}
+
+// loadOrCreateCA reuses the CA found in dir, so certificates issued by
+// earlier runs stay trusted, unless fresh is set or there is none.
+func loadOrCreateCA(dir string, fresh bool) (*x509.Certificate, crypto.Signer, error) {
+	certFile := filepath.Join(dir, "ca.crt")
+	keyFile := filepath.Join(dir, "ca.key")
+
+	if !fresh {
+		if pair, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+			cert, err := x509.ParseCertificate(pair.Certificate[0])
+			if err != nil {
+				return nil, nil, err
+			}
+			signer, ok := pair.PrivateKey.(crypto.Signer)
+			if !ok {
+				return nil, nil, fmt.Errorf("CA key in %s cannot sign", keyFile)
+			}
+			log.Printf("Reusing CA %s", certFile)
+			return cert, signer, nil
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	ca, err := template("grpc-go-new-course development CA", 10*365*24*time.Hour)
+	if err != nil {
+		return nil, nil, err
+	}
+	ca.IsCA = true
+	ca.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, ca, ca, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writeFiles(certFile, keyFile, der, key); err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// issue signs template with the CA and writes <name>.crt and <name>.pem.
+func issue(dir, name string, template *x509.Certificate, caCert *x509.Certificate, caKey crypto.Signer) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	if err != nil {
+		return err
+	}
+	return writeFiles(filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".pem"), der, key)
+}
+
+func writeFiles(certFile, keyFile string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(certFile, certPEM, 0644); err != nil {
+		return err
+	}
+	log.Printf("Wrote %s and %s", certFile, keyFile)
+	return nil
+}
@@ -0,0 +1,339 @@
+// Package fakegen generates fakes of the gRPC clients of proto files, built on
+// package fakegrpc. The fakes of package xpb go to package xfake, next to it:
+// greet/greetpb/greet.proto gives greet/greetfake/greet_fake.pb.go. Every
+// service gets a fake client answering calls with programmable <Method>Func
+// fields, and every streaming method a <Method>Stream fake of its client
+// stream.
+//
+// The generator runs as the protoc plugin protoc-gen-go-grpcfake, or from
+// compiled descriptors with Files.
+package fakegen
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+const (
+	contextPackage  = protogen.GoImportPath("context")
+	grpcPackage     = protogen.GoImportPath("google.golang.org/grpc")
+	fakegrpcPackage = protogen.GoImportPath("github.com/grpc-go-new-course/fakegrpc")
+)
+
+//go:generate go run ./genfakes -out ../..
+
+// Generate generates the fakes of every file gen is asked to generate that
+// declares services.
+func Generate(gen *protogen.Plugin) error {
+	for _, f := range gen.Files {
+		if !f.Generate || len(f.Services) == 0 {
+			continue
+		}
+		if err := generateFile(gen, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Files generates the fakes of the compiled files, as protoc would with
+// paths=source_relative, and returns their content by file name. The proto
+// files of this repository declare no Go import path, so importPaths gives
+// it by proto file path.
+func Files(files []protoreflect.FileDescriptor, importPaths map[string]string) (map[string][]byte, error) {
+	req := &pluginpb.CodeGeneratorRequest{}
+	params := []string{"paths=source_relative"}
+	seen := map[string]bool{}
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		// protogen needs the dependencies of a file before it
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(fd))
+	}
+	for _, fd := range files {
+		add(fd)
+		req.FileToGenerate = append(req.FileToGenerate, fd.Path())
+	}
+	for _, file := range sortedKeys(importPaths) {
+		params = append(params, "M"+file+"="+importPaths[file])
+	}
+	req.Parameter = proto.String(strings.Join(params, ","))
+
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := Generate(gen); err != nil {
+		return nil, err
+	}
+	res := gen.Response()
+	if res.Error != nil {
+		return nil, fmt.Errorf("generating fakes : %s", res.GetError())
+	}
+	out := make(map[string][]byte, len(res.File))
+	for _, f := range res.File {
+		out[f.GetName()] = []byte(f.GetContent())
+	}
+	return out, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fakeImportPath returns the import path of the fakes of the package at
+// importPath, which must end in "pb".
+func fakeImportPath(importPath protogen.GoImportPath) (protogen.GoImportPath, error) {
+	trimmed := strings.TrimSuffix(string(importPath), "pb")
+	if trimmed == string(importPath) {
+		return "", fmt.Errorf("go package %s does not end in pb", importPath)
+	}
+	return protogen.GoImportPath(trimmed + "fake"), nil
+}
+
+func generateFile(gen *protogen.Plugin, f *protogen.File) error {
+	importPath, err := fakeImportPath(f.GoImportPath)
+	if err != nil {
+		return fmt.Errorf("%s : %v", f.Desc.Path(), err)
+	}
+	pkg := path.Base(string(importPath))
+	dir := strings.TrimSuffix(path.Dir(f.GeneratedFilenamePrefix), "pb") + "fake"
+	g := gen.NewGeneratedFile(path.Join(dir, path.Base(f.GeneratedFilenamePrefix)+"_fake.pb.go"), importPath)
+
+	g.P("// Code generated by protoc-gen-go-grpcfake. DO NOT EDIT.")
+	g.P("// source: ", f.Desc.Path())
+	g.P()
+	if len(f.Services) == 1 {
+		service := f.Services[0]
+		name := strings.ToLower(strings.TrimSuffix(service.GoName, "Service"))
+		g.P("// Package ", pkg, " provides a fake ", f.GoPackageName, ".", service.GoName, "Client, and fakes of its")
+		g.P("// streams, for unit testing code that calls the ", name, " service without")
+		g.P("// running a server.")
+	} else {
+		g.P("// Package ", pkg, " provides fakes of the clients of ", f.GoPackageName, ", and of")
+		g.P("// their streams, for unit testing code that calls these services without")
+		g.P("// running a server.")
+	}
+	g.P("package ", pkg)
+	g.P()
+
+	for _, service := range f.Services {
+		// a file with several services prefixes the fakes with their names
+		prefix := ""
+		if len(f.Services) > 1 {
+			prefix = service.GoName
+		}
+		generateClient(g, f, service, prefix)
+		for _, method := range service.Methods {
+			generateStream(g, f, service, method, prefix)
+		}
+	}
+	return nil
+}
+
+// streamInterface returns the client stream interface of method generated
+// by protoc-gen-go-grpc.
+func streamInterface(f *protogen.File, service *protogen.Service, method *protogen.Method) protogen.GoIdent {
+	return f.GoImportPath.Ident(service.GoName + "_" + method.GoName + "Client")
+}
+
+// signature returns the parameters and results of the client method of
+// method, without the call options, the way Func fields declare them.
+func signature(g *protogen.GeneratedFile, f *protogen.File, service *protogen.Service, method *protogen.Method) (string, string) {
+	params := "ctx " + g.QualifiedGoIdent(contextPackage.Ident("Context"))
+	if !method.Desc.IsStreamingClient() {
+		params += ", in *" + g.QualifiedGoIdent(method.Input.GoIdent)
+	}
+	result := "*" + g.QualifiedGoIdent(method.Output.GoIdent)
+	if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+		result = g.QualifiedGoIdent(streamInterface(f, service, method))
+	}
+	return params, "(" + result + ", error)"
+}
+
+func generateClient(g *protogen.GeneratedFile, f *protogen.File, service *protogen.Service, prefix string) {
+	client := prefix + "Client"
+	clientInterface := g.QualifiedGoIdent(f.GoImportPath.Ident(service.GoName + "Client"))
+
+	g.P("// ", client, " is a fake ", clientInterface, ". Every call is recorded,")
+	g.P("// then answered by the matching Func field, or fails with Unimplemented")
+	g.P("// when that field is nil. Call options are ignored.")
+	g.P("type ", client, " struct {")
+	g.P(fakegrpcPackage.Ident("Recorder"))
+	g.P()
+	for _, method := range service.Methods {
+		params, results := signature(g, f, service, method)
+		g.P(method.GoName, "Func func(", params, ") ", results)
+	}
+	g.P("}")
+	g.P()
+	g.P("var _ ", clientInterface, " = (*", client, ")(nil)")
+	g.P()
+
+	for _, method := range service.Methods {
+		params, results := signature(g, f, service, method)
+		args, req := "ctx", "nil"
+		if !method.Desc.IsStreamingClient() {
+			args, req = "ctx, in", "in"
+		}
+		g.P("func (c *", client, ") ", method.GoName, "(", params, ", opts ...", grpcPackage.Ident("CallOption"), ") ", results, " {")
+		g.P("c.Record(ctx, ", fmt.Sprintf("%q", method.GoName), ", ", req, ")")
+		g.P("if c.", method.GoName, "Func == nil {")
+		g.P("return nil, ", fakegrpcPackage.Ident("Unimplemented"), "(", fmt.Sprintf("%q", method.GoName), ")")
+		g.P("}")
+		g.P("return c.", method.GoName, "Func(", args, ")")
+		g.P("}")
+		g.P()
+	}
+}
+
+func generateStream(g *protogen.GeneratedFile, f *protogen.File, service *protogen.Service, method *protogen.Method, prefix string) {
+	clientStreaming, serverStreaming := method.Desc.IsStreamingClient(), method.Desc.IsStreamingServer()
+	if !clientStreaming && !serverStreaming {
+		return
+	}
+	stream := prefix + method.GoName + "Stream"
+	iface := g.QualifiedGoIdent(streamInterface(f, service, method))
+	req := "*" + g.QualifiedGoIdent(method.Input.GoIdent)
+	res := "*" + g.QualifiedGoIdent(method.Output.GoIdent)
+
+	switch {
+	case !clientStreaming:
+		g.P("// ", stream, " is a fake ", iface, ".")
+		g.P("// Recv returns Responses in order, then Err, or io.EOF when Err is nil.")
+		g.P("type ", stream, " struct {")
+		g.P(fakegrpcPackage.Ident("ClientStream"))
+		g.P("Responses []", res)
+		g.P("Err error")
+		g.P()
+		g.P("cursor ", fakegrpcPackage.Ident("Cursor"))
+		g.P("}")
+		g.P()
+		g.P("var _ ", iface, " = (*", stream, ")(nil)")
+		g.P()
+		g.P("func (s *", stream, ") Recv() (", res, ", error) {")
+		g.P("i, err := s.cursor.Next(len(s.Responses), s.Err)")
+		g.P("if err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("return s.Responses[i], nil")
+		g.P("}")
+		g.P()
+		return
+
+	case !serverStreaming:
+		g.P("// ", stream, " is a fake ", iface, ".")
+		g.P("// It keeps the requests sent on it and answers CloseAndRecv with Reply,")
+		g.P("// or with Response and Err when Reply is nil.")
+		g.P("type ", stream, " struct {")
+		g.P(fakegrpcPackage.Ident("ClientStream"))
+		g.P("Reply func(sent []", req, ") (", res, ", error)")
+		g.P("Response ", res)
+		g.P("Err error")
+		g.P("// SendErr, when set, fails every Send.")
+		g.P("SendErr error")
+		g.P()
+		g.P("sent ", fakegrpcPackage.Ident("Messages"))
+		g.P("}")
+		g.P()
+		g.P("var _ ", iface, " = (*", stream, ")(nil)")
+		g.P()
+		g.P("func (s *", stream, ") Send(req ", req, ") error {")
+		g.P("if s.SendErr != nil {")
+		g.P("return s.SendErr")
+		g.P("}")
+		g.P("s.sent.Append(req)")
+		g.P("return nil")
+		g.P("}")
+		g.P()
+		generateSent(g, stream, req, "s.sent.List()")
+		g.P("func (s *", stream, ") CloseAndRecv() (", res, ", error) {")
+		g.P("s.CloseSend()")
+		g.P("if s.Reply != nil {")
+		g.P("return s.Reply(s.Sent())")
+		g.P("}")
+		g.P("return s.Response, s.Err")
+		g.P("}")
+		g.P()
+		return
+	}
+
+	g.P("// ", stream, " is a fake ", iface, ".")
+	g.P("// Every request sent is passed to Reply: a non-nil response is queued for")
+	g.P("// Recv and an error ends the stream with that error. Recv blocks like a")
+	g.P("// real stream, until a response is queued, the stream ends or its context")
+	g.P("// is done, and returns io.EOF once CloseSend was called and every response")
+	g.P("// received.")
+	g.P("type ", stream, " struct {")
+	g.P(fakegrpcPackage.Ident("ClientStream"))
+	g.P("Reply func(req ", req, ") (", res, ", error)")
+	g.P()
+	g.P("exchange ", fakegrpcPackage.Ident("Exchange"))
+	g.P("}")
+	g.P()
+	g.P("var _ ", iface, " = (*", stream, ")(nil)")
+	g.P()
+	g.P("func (s *", stream, ") Send(req ", req, ") error {")
+	g.P("if s.Reply == nil {")
+	g.P("return s.exchange.Send(req, nil)")
+	g.P("}")
+	g.P("return s.exchange.Send(req, func() (interface{}, error) {")
+	g.P("res, err := s.Reply(req)")
+	g.P("if res == nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("return res, err")
+	g.P("})")
+	g.P("}")
+	g.P()
+	generateSent(g, stream, req, "s.exchange.List()")
+	g.P("func (s *", stream, ") CloseSend() error {")
+	g.P("s.ClientStream.CloseSend()")
+	g.P("s.exchange.CloseSend()")
+	g.P("return nil")
+	g.P("}")
+	g.P()
+	g.P("func (s *", stream, ") Recv() (", res, ", error) {")
+	g.P("res, err := s.exchange.Recv(s.Context())")
+	g.P("if err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("return res.(", res, "), nil")
+	g.P("}")
+	g.P()
+}
+
+// generateSent writes the Sent method of stream, converting the messages
+// returned by list back to req.
+func generateSent(g *protogen.GeneratedFile, stream, req, list string) {
+	g.P("// Sent returns the requests sent so far.")
+	g.P("func (s *", stream, ") Sent() []", req, " {")
+	g.P("msgs := ", list)
+	g.P("sent := make([]", req, ", len(msgs))")
+	g.P("for i, msg := range msgs {")
+	g.P("sent[i] = msg.(", req, ")")
+	g.P("}")
+	g.P("return sent")
+	g.P("}")
+	g.P()
+}
@@ -0,0 +1,53 @@
+// Command genfakes regenerates the fakes of the services of this repository
+// from their compiled descriptors, so protoc is not needed:
+//
+//	go generate ./fakegrpc/fakegen
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/grpc-go-new-course/blog/blogpb"
+	"github.com/grpc-go-new-course/calculator/calculatorpb"
+	"github.com/grpc-go-new-course/fakegrpc/fakegen"
+	"github.com/grpc-go-new-course/greet/greetpb"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// services are the proto files whose fakes are checked in.
+var services = []protoreflect.FileDescriptor{
+	greetpb.File_greet_greetpb_greet_proto,
+	calculatorpb.File_calculator_calculatorpb_calculator_proto,
+	blogpb.File_blog_blogpb_blog_proto,
+}
+
+// importPaths are the Go packages of services, which their go_package
+// options leave out.
+var importPaths = map[string]string{
+	"greet/greetpb/greet.proto":                "github.com/grpc-go-new-course/greet/greetpb",
+	"calculator/calculatorpb/calculator.proto": "github.com/grpc-go-new-course/calculator/calculatorpb",
+	"blog/blogpb/blog.proto":                   "github.com/grpc-go-new-course/blog/blogpb",
+}
+
+func main() {
+	out := flag.String("out", ".", "root of the module to write the fakes to")
+	flag.Parse()
+
+	files, err := fakegen.Files(services, importPaths)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	for name, content := range files {
+		path := filepath.Join(*out, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+}
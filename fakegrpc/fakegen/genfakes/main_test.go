@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/grpc-go-new-course/fakegrpc/fakegen"
+)
+
+// TestFakesUpToDate fails when a proto file changed without its fakes being
+// regenerated.
+func TestFakesUpToDate(t *testing.T) {
+	files, err := fakegen.Files(services, importPaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != len(services) {
+		t.Errorf("got %d files, want one per service file", len(files))
+	}
+	for name, want := range files {
+		got, err := ioutil.ReadFile(filepath.Join("..", "..", "..", filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("%s : %v", name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date, run go generate ./fakegrpc/fakegen", name)
+		}
+	}
+}
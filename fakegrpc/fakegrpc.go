@@ -0,0 +1,295 @@
+// Package fakegrpc holds the pieces shared by the fake service clients of
+// greetfake, calculatorfake and blogfake: call recording, a base for fake
+// client streams and a blocking message queue.
+package fakegrpc
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Call is a recorded call of a fake client.
+type Call struct {
+	// Method is the RPC name, e.g. "Greet".
+	Method string
+	// Request is the request of unary and server streaming calls, nil for
+	// client and bidi streams whose messages are kept by their stream.
+	Request interface{}
+	// Metadata is the outgoing metadata of the call context.
+	Metadata metadata.MD
+}
+
+// Recorder records the calls made on a fake client. It is safe for
+// concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Record appends a call of method with req made with ctx.
+func (r *Recorder) Record(ctx context.Context, method string, req interface{}) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Request: req, Metadata: md})
+}
+
+// Calls returns every recorded call, oldest first.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns the recorded calls of method, oldest first.
+func (r *Recorder) CallsTo(method string) []Call {
+	var calls []Call
+	for _, call := range r.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// Unimplemented is the error of fake methods without a programmed response.
+func Unimplemented(method string) error {
+	return status.Errorf(codes.Unimplemented, "fake: no response programmed for %s", method)
+}
+
+// ClientStream implements the grpc.ClientStream methods of a fake stream.
+// Header and Trailer return the programmed metadata; SendMsg and RecvMsg are
+// not supported, fake streams implement the typed Send and Recv instead.
+type ClientStream struct {
+	Ctx       context.Context
+	HeaderMD  metadata.MD
+	TrailerMD metadata.MD
+	HeaderErr error
+
+	mu         sync.Mutex
+	closedSend bool
+}
+
+func (s *ClientStream) Header() (metadata.MD, error) {
+	return s.HeaderMD, s.HeaderErr
+}
+
+func (s *ClientStream) Trailer() metadata.MD {
+	return s.TrailerMD
+}
+
+func (s *ClientStream) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closedSend = true
+	return nil
+}
+
+// SendClosed reports whether CloseSend was called.
+func (s *ClientStream) SendClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closedSend
+}
+
+func (s *ClientStream) Context() context.Context {
+	if s.Ctx == nil {
+		return context.Background()
+	}
+	return s.Ctx
+}
+
+func (s *ClientStream) SendMsg(m interface{}) error {
+	return status.Error(codes.Unimplemented, "fake: SendMsg is not supported, use Send")
+}
+
+func (s *ClientStream) RecvMsg(m interface{}) error {
+	return status.Error(codes.Unimplemented, "fake: RecvMsg is not supported, use Recv")
+}
+
+// Queue hands messages from a producer to Pop, which blocks like the Recv of
+// a real stream until a message arrives, the queue is closed or the context
+// is done.
+type Queue struct {
+	mu     sync.Mutex
+	items  []interface{}
+	closed bool
+	err    error
+	wake   chan struct{}
+}
+
+// NewQueue returns a queue holding items.
+func NewQueue(items ...interface{}) *Queue {
+	return &Queue{items: items, wake: make(chan struct{})}
+}
+
+// Push appends an item. Items pushed after Close are dropped.
+func (q *Queue) Push(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.items = append(q.items, item)
+	q.signal()
+}
+
+// Close makes Pop return err, io.EOF when nil, once the queued items are
+// consumed. Only the first Close counts.
+func (q *Queue) Close(err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	if err == nil {
+		err = io.EOF
+	}
+	q.closed, q.err = true, err
+	q.signal()
+}
+
+// signal wakes the goroutines blocked in Pop. q.mu must be held.
+func (q *Queue) signal() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+// Pop returns the oldest item.
+func (q *Queue) Pop(ctx context.Context) (interface{}, error) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			item := q.items[0]
+			q.items = q.items[1:]
+			q.mu.Unlock()
+			return item, nil
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return nil, q.err
+		}
+		wake := q.wake
+		q.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// Cursor walks the programmed responses of a fake server stream.
+type Cursor struct {
+	mu   sync.Mutex
+	next int
+}
+
+// Next returns the index of the next of n responses, then err, or io.EOF
+// when err is nil, once every response was returned.
+func (c *Cursor) Next(n int, err error) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.next < n {
+		c.next++
+		return c.next - 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return 0, io.EOF
+}
+
+// Messages keeps the messages sent on a fake stream. It is safe for
+// concurrent use.
+type Messages struct {
+	mu   sync.Mutex
+	msgs []interface{}
+}
+
+// Append records msg.
+func (m *Messages) Append(msg interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.msgs = append(m.msgs, msg)
+}
+
+// List returns the recorded messages, oldest first.
+func (m *Messages) List() []interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]interface{}(nil), m.msgs...)
+}
+
+// Exchange runs a fake bidirectional stream. Every request sent is kept and
+// passed to a reply function: a non-nil response is queued for Recv and an
+// error ends the stream with that error. Recv blocks like a real stream,
+// until a response is queued, the stream ends or its context is done, and
+// returns io.EOF once CloseSend was called and every response received.
+type Exchange struct {
+	Messages
+
+	once  sync.Once
+	queue *Queue
+	mu    sync.Mutex
+	ended bool
+}
+
+func (e *Exchange) responses() *Queue {
+	e.once.Do(func() { e.queue = NewQueue() })
+	return e.queue
+}
+
+// Send records req and answers it with reply, which may be nil to only
+// record it.
+func (e *Exchange) Send(req interface{}, reply func() (interface{}, error)) error {
+	e.mu.Lock()
+	if e.ended {
+		e.mu.Unlock()
+		// like grpc-go, the status is only reported by Recv
+		return io.EOF
+	}
+	e.Append(req)
+	e.mu.Unlock()
+
+	if reply == nil {
+		return nil
+	}
+	res, err := reply()
+	if err != nil {
+		e.mu.Lock()
+		e.ended = true
+		e.mu.Unlock()
+		e.responses().Close(err)
+		return nil
+	}
+	if res != nil {
+		e.responses().Push(res)
+	}
+	return nil
+}
+
+// CloseSend ends the stream once the queued responses are received.
+func (e *Exchange) CloseSend() {
+	e.responses().Close(nil)
+}
+
+// Recv returns the next response.
+func (e *Exchange) Recv(ctx context.Context) (interface{}, error) {
+	return e.responses().Pop(ctx)
+}
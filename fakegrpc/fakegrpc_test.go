@@ -0,0 +1,50 @@
+package fakegrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestQueuePopWaitsForPush(t *testing.T) {
+	q := NewQueue()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Push("late")
+	}()
+
+	item, err := q.Pop(context.Background())
+	if err != nil || item != "late" {
+		t.Fatalf("got %v, %v", item, err)
+	}
+}
+
+func TestQueueClose(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "nil error is EOF", want: io.EOF},
+		{name: "error", err: boom, want: boom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQueue("queued")
+			q.Close(tt.err)
+			q.Push("dropped")
+
+			if item, err := q.Pop(context.Background()); err != nil || item != "queued" {
+				t.Fatalf("got %v, %v, want the item queued before Close", item, err)
+			}
+			for i := 0; i < 2; i++ {
+				if _, err := q.Pop(context.Background()); err != tt.want {
+					t.Fatalf("got error %v, want %v", err, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+// Command protoc-gen-go-grpcfake is a protoc plugin generating the fakes of
+// package fakegen:
+//
+//	protoc --go-grpcfake_out=. --go-grpcfake_opt=paths=source_relative,Mgreet/greetpb/greet.proto=github.com/grpc-go-new-course/greet/greetpb greet/greetpb/greet.proto
+//
+// The M option gives the import path the proto file does not declare.
+package main
+
+import (
+	"github.com/grpc-go-new-course/fakegrpc/fakegen"
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+func main() {
+	protogen.Options{}.Run(fakegen.Generate)
+}
@@ -2,27 +2,62 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"os"
 	"sync"
 	"time"
 
+	"github.com/grpc-go-new-course/apikey"
 	"github.com/grpc-go-new-course/greet/greetpb"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/rpcerror"
+	"github.com/grpc-go-new-course/tlsconfig"
+	"github.com/grpc-go-new-course/tracing"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/status"
 )
 
 func main() {
-	certFile := "ssl/ca.crt"
-	creds, err := credentials.NewClientTLSFromFile(certFile, "")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP gRPC collector receiving traces, e.g. localhost:4317")
+	traceFile := flag.String("trace-file", "", "file receiving traces as JSON lines when no OTLP endpoint is set")
+	addr := flag.String("addr", "localhost:50051", `server to dial, e.g. "unix:///run/greet/greet.sock" or "unix-abstract:greet"`)
+	apiKey := flag.String("api-key", os.Getenv("API_KEY"), "API key sent with every call, defaults to $API_KEY")
+	var tlsFlags tlsconfig.ClientFlags
+	tlsFlags.Register(flag.CommandLine, "ssl/ca.crt")
+	flag.Parse()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName:  "greet_client",
+		OTLPEndpoint: *otlpEndpoint,
+		File:         *traceFile,
+	})
+	if err != nil {
+		log.Fatalf("unable to set up tracing : %v", err)
+	}
+	defer shutdownTracing(context.Background())
 
+	creds, err := tlsFlags.DialOption()
 	if err != nil {
 		log.Fatalf("error loading client certificates : %v", err)
 	}
-	conn, err := grpc.Dial("localhost:50051", grpc.WithTransportCredentials(creds))
+	dialOpts := []grpc.DialOption{
+		creds,
+		grpc.WithChainUnaryInterceptor(
+			tracing.UnaryClientInterceptor(),
+			logging.UnaryClientInterceptor(),
+		),
+		grpc.WithChainStreamInterceptor(
+			tracing.StreamClientInterceptor(),
+			logging.StreamClientInterceptor(),
+		),
+	}
+	if *apiKey != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(apikey.Credentials(*apiKey)))
+	}
+	conn, err := grpc.Dial(*addr, dialOpts...)
 
 	if err != nil {
 		log.Fatalf("unable to establish channel connection : %v", err)
@@ -34,6 +69,7 @@ func main() {
 	client := greetpb.NewGreetServiceClient(conn)
 
 	//doUnary(client)
+	//doUnaryAsIdentity(client)
 	// doServerStreaming(client)
 	//	doClientStreaming(client)
 	//BiDi(client)
@@ -54,7 +90,21 @@ func doUnary(client greetpb.GreetServiceClient) {
 	resp, err := client.Greet(context.Background(), &req)
 
 	if err != nil {
-		log.Fatalf("error while calling Greet rpc : %v", err)
+		log.Fatalf("error while calling Greet rpc : %v", rpcerror.Describe(err))
+
+	}
+	log.Printf("Response from Greet: %v", resp)
+
+}
+
+// doUnaryAsIdentity sends no name, so a server running in mutual TLS mode
+// greets the identity of the client certificate.
+func doUnaryAsIdentity(client greetpb.GreetServiceClient) {
+
+	resp, err := client.Greet(context.Background(), &greetpb.GreetingRequest{})
+
+	if err != nil {
+		log.Fatalf("error while calling Greet rpc : %v", rpcerror.Describe(err))
 
 	}
 	log.Printf("Response from Greet: %v", resp)
@@ -75,16 +125,12 @@ func doUnaryWithDeadline(client greetpb.GreetServiceClient, timeout time.Duratio
 
 	if err != nil {
 
-		status, ok := status.FromError(err)
+		details := rpcerror.FromError(err)
 
-		if ok {
-			if status.Code() == codes.DeadlineExceeded {
-				log.Println(status.Message())
-				log.Fatalf("Timeout , DeadlineExceeded ! : %v", err)
-			}
-			log.Fatalf("unexpeceted error : %v", err)
+		if details.Code == codes.DeadlineExceeded {
+			log.Fatalf("Timeout , DeadlineExceeded ! : %v", details)
 		}
-		log.Fatalf("error occured calling rpc GreetWithDeadline  : %v", err)
+		log.Fatalf("error occured calling rpc GreetWithDeadline  : %v", details)
 	}
 
 	log.Printf(" Result of Response : %v", res.GetResult())
@@ -108,7 +154,7 @@ func doClientStreaming(client greetpb.GreetServiceClient) {
 	stream, err := client.LongGreet(context.Background())
 
 	if err != nil {
-		log.Fatalf("Error calling rpc LongGreet : %v", err)
+		log.Fatalf("Error calling rpc LongGreet : %v", rpcerror.Describe(err))
 
 	}
 
@@ -123,7 +169,7 @@ func doClientStreaming(client greetpb.GreetServiceClient) {
 	resp, err := stream.CloseAndRecv()
 
 	if err != nil {
-		log.Fatalf("error trying to receive response from LongGreet : %v", err)
+		log.Fatalf("error trying to receive response from LongGreet : %v", rpcerror.Describe(err))
 	}
 
 	log.Printf("LongGreet Response : %v", resp)
@@ -143,7 +189,7 @@ func doServerStreaming(client greetpb.GreetServiceClient) {
 	stream, err := client.GreetManyTimes(context.Background(), req)
 
 	if err != nil {
-		log.Fatalf("rpc GreetManyTimes call failed : %v", err)
+		log.Fatalf("rpc GreetManyTimes call failed : %v", rpcerror.Describe(err))
 
 	}
 
@@ -157,7 +203,7 @@ func doServerStreaming(client greetpb.GreetServiceClient) {
 
 		}
 		if err != nil {
-			log.Fatalf(" Streaming server side results failed : %v", err)
+			log.Fatalf(" Streaming server side results failed : %v", rpcerror.Describe(err))
 
 		}
 
@@ -174,7 +220,7 @@ func BiDi(client greetpb.GreetServiceClient) {
 	stream, err := client.GreetEveryone(context.Background())
 
 	if err != nil {
-		log.Fatalf("rpc Call to GreetEveryone failed : %v", err)
+		log.Fatalf("rpc Call to GreetEveryone failed : %v", rpcerror.Describe(err))
 
 	}
 
@@ -210,7 +256,7 @@ func BiDi(client greetpb.GreetServiceClient) {
 				break
 			}
 			if err != nil {
-				log.Fatalf("error trying to receive data streams from server : %v", err)
+				log.Fatalf("error trying to receive data streams from server : %v", rpcerror.Describe(err))
 			}
 			fmt.Printf("receiving message : %v \n", resp)
 
@@ -2,28 +2,57 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
-	"log"
-	"net"
+	"os"
+	"os/signal"
 	"time"
 
+	"github.com/grpc-go-new-course/apikey"
+	"github.com/grpc-go-new-course/apikey/apikeypb"
 	"github.com/grpc-go-new-course/greet/greetpb"
+	"github.com/grpc-go-new-course/interceptors"
+	"github.com/grpc-go-new-course/listener"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/metrics"
+	"github.com/grpc-go-new-course/ratelimit"
+	"github.com/grpc-go-new-course/rpcerror"
+	"github.com/grpc-go-new-course/tlsconfig"
+	"github.com/grpc-go-new-course/tracing"
+	"github.com/grpc-go-new-course/validation"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 )
 
 type server struct {
 	greetpb.UnimplementedGreetServiceServer
+	logger *zap.Logger
+	// step is the pause between the steps of GreetWithDeadline and the
+	// greetings of GreetManyTimes
+	step time.Duration
 }
 
-func (*server) Greet(ctx context.Context, req *greetpb.GreetingRequest) (*greetpb.GreetingResponse, error) {
+func (s *server) Greet(ctx context.Context, req *greetpb.GreetingRequest) (*greetpb.GreetingResponse, error) {
 
-	log.Printf("Client Request to Server :%v \n ", req)
+	logging.ForRequest(ctx, s.logger).Info("Greet request", zap.Stringer("request", req))
 	firstname := req.GetGreeting().GetFirstName()
+
+	// without a name, greet whoever the client certificate says is calling
+	if firstname == "" {
+		if id, ok := tlsconfig.PeerIdentity(ctx); ok {
+			firstname = id.Name()
+		}
+	}
+	if firstname == "" {
+		return nil, rpcerror.New(codes.InvalidArgument, "no first name given and no client certificate presented",
+			rpcerror.BadRequest("greeting.first_name", "is required without a client certificate"),
+		)
+	}
+
 	result := "Hello " + firstname
 
 	res := &greetpb.GreetingResponse{
@@ -33,14 +62,17 @@ func (*server) Greet(ctx context.Context, req *greetpb.GreetingRequest) (*greetp
 
 }
 
-func (*server) GreetWithDeadline(ctx context.Context, req *greetpb.GreetWithDeadlineRequest) (*greetpb.GreetWithDeadlineResponse, error) {
+func (s *server) GreetWithDeadline(ctx context.Context, req *greetpb.GreetWithDeadlineRequest) (*greetpb.GreetWithDeadlineResponse, error) {
 
 	for i := 0; i < 3; i++ {
-		if ctx.Err() == context.Canceled {
-			return nil, status.Errorf(codes.DeadlineExceeded, ctx.Err().Error())
+		if err := ctx.Err(); err != nil {
+			// the client gave up, either by cancelling or through its deadline
+			return nil, rpcerror.New(status.FromContextError(err).Code(), err.Error(),
+				rpcerror.ErrorInfo("GREETING_ABANDONED", "completed_steps", fmt.Sprint(i)),
+			)
 
 		}
-		time.Sleep(time.Second)
+		time.Sleep(s.step)
 
 	}
 
@@ -51,7 +83,7 @@ func (*server) GreetWithDeadline(ctx context.Context, req *greetpb.GreetWithDead
 }
 
 // GreetManyTimes :=> Server side streaming
-func (*server) GreetManyTimes(req *greetpb.GreetManyTimesRequest, stream greetpb.GreetService_GreetManyTimesServer) error {
+func (s *server) GreetManyTimes(req *greetpb.GreetManyTimesRequest, stream greetpb.GreetService_GreetManyTimesServer) error {
 	firstname := req.GetGreeting().GetFirstName()
 
 	for i := 0; i < 10; i++ {
@@ -59,8 +91,11 @@ func (*server) GreetManyTimes(req *greetpb.GreetManyTimesRequest, stream greetpb
 		res := &greetpb.GreetManyTimesResponse{
 			Result: result,
 		}
-		stream.Send(res)
-		time.Sleep(time.Second)
+		if err := stream.Send(res); err != nil {
+			logging.ForRequest(stream.Context(), s.logger).Warn("error sending data to client", zap.Error(err))
+			return status.Errorf(status.Code(err), "error sending data to client : %v", err)
+		}
+		time.Sleep(s.step)
 
 	}
 	return nil
@@ -69,7 +104,8 @@ func (*server) GreetManyTimes(req *greetpb.GreetManyTimesRequest, stream greetpb
 
 // GreetEveryOne
 
-func (*server) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) error {
+func (s *server) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) error {
+	logger := logging.ForRequest(stream.Context(), s.logger)
 	for {
 		req, err := stream.Recv()
 
@@ -77,7 +113,8 @@ func (*server) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) er
 			return nil
 		}
 		if err != nil {
-			log.Fatalf("error while reading client stream : %v", err)
+			logger.Warn("error while reading client stream", zap.Error(err))
+			return status.Errorf(status.Code(err), "error while reading client stream : %v", err)
 		}
 
 		result := fmt.Sprintf(" Hello %s !", req.GetGreeting().GetFirstName())
@@ -85,8 +122,8 @@ func (*server) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) er
 		if err := stream.Send(&greetpb.GreetEveryoneResponse{
 			Result: result,
 		}); err != nil {
-			log.Fatalf("error sending data to client : %v", err)
-			return nil
+			logger.Warn("error sending data to client", zap.Error(err))
+			return status.Errorf(status.Code(err), "error sending data to client : %v", err)
 		}
 
 	}
@@ -94,7 +131,7 @@ func (*server) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) er
 }
 
 // LongGreet :=> Client side Streaming
-func (*server) LongGreet(stream greetpb.GreetService_LongGreetServer) error {
+func (s *server) LongGreet(stream greetpb.GreetService_LongGreetServer) error {
 
 	result := ""
 	for {
@@ -108,34 +145,130 @@ func (*server) LongGreet(stream greetpb.GreetService_LongGreetServer) error {
 		}
 
 		if err != nil {
-			log.Fatalf("client side streaming failed : %v \n", err)
-
+			logging.ForRequest(stream.Context(), s.logger).Warn("client side streaming failed", zap.Error(err))
+			return status.Errorf(status.Code(err), "client side streaming failed : %v", err)
 		}
 		result += fmt.Sprintf(" Hello %s ! ", req.GetGreeting().GetFirstName())
 
 	}
 }
 
+// requestRules requires a first name on every greeting but Greet's, and caps
+// the length of the names.
+func requestRules() *validation.Validator {
+	firstName := []validation.Rule{
+		validation.Required("greeting.first_name"),
+		validation.Length("greeting.first_name", 1, 100),
+		validation.Length("greeting.last_name", 1, 100),
+	}
+	return validation.New().
+		// Greet falls back to the client certificate identity
+		Add(&greetpb.GreetingRequest{},
+			validation.Length("greeting.first_name", 1, 100),
+			validation.Length("greeting.last_name", 1, 100),
+		).
+		Add(&greetpb.GreetWithDeadlineRequest{}, firstName...).
+		Add(&greetpb.GreetManyTimesRequest{}, firstName...).
+		Add(&greetpb.LongGreetRequest{}, firstName...).
+		Add(&greetpb.GreetEveryoneRequest{}, firstName...)
+}
+
 func main() {
 
-	certFile := "ssl/server.crt"
-	keyFile := "ssl/server.pem"
+	metricsAddr := flag.String("metrics-addr", "0.0.0.0:9091", "address serving Prometheus metrics on /metrics")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP gRPC collector receiving traces, e.g. localhost:4317")
+	traceFile := flag.String("trace-file", "", "file receiving traces as JSON lines when no OTLP endpoint is set")
+	apiKeys := flag.String("api-keys", "", "JSON file of the API keys clients must present, empty disables API key authentication")
+	var listenFlags listener.Flags
+	listenFlags.Register(flag.CommandLine, "0.0.0.0:50051")
+	var tlsFlags tlsconfig.ServerFlags
+	tlsFlags.Register(flag.CommandLine, "ssl/server.crt", "ssl/server.pem")
+	flag.Parse()
+
+	logger, err := logging.New("greet_server")
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
 
-	lis, err := net.Listen("tcp", "0.0.0.0:50051")
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName:  "greet_server",
+		OTLPEndpoint: *otlpEndpoint,
+		File:         *traceFile,
+	})
+	if err != nil {
+		logger.Fatal("unable to set up tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Warn("error flushing traces", zap.Error(err))
+		}
+	}()
+
+	listeners, err := listenFlags.Listen()
 
 	if err != nil {
-		log.Fatalf("failed to listen %v", err)
+		logger.Fatal("failed to listen", zap.Error(err))
 	}
 
-	creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+	serverMetrics := metrics.New()
+	go func() {
+		logger.Fatal("metrics endpoint stopped", zap.Error(serverMetrics.Serve(*metricsAddr)))
+	}()
+
+	creds, err := tlsFlags.ServerOption(context.Background(), tlsconfig.ReloaderOptions{
+		Logger:   logger,
+		OnReload: serverMetrics.ObserveCertReload,
+	})
 	if err != nil {
-		log.Fatalf("error loading certificates : %v", err)
+		logger.Fatal("error loading certificates", zap.Error(err))
+	}
+
+	validator := apikey.Rules(requestRules())
+	limiter := ratelimit.New(ratelimit.Config{
+		Default: ratelimit.Limit{PerSecond: 20, Burst: 40},
+		StreamMessages: map[string]ratelimit.Limit{
+			"/greet.GreetService/GreetEveryone": {PerSecond: 10, Burst: 20},
+		},
+	})
+
+	var keyStore apikey.Store
+	if *apiKeys != "" {
+		keyStore, err = apikey.NewFileStore(*apiKeys)
+		if err != nil {
+			logger.Fatal("unable to load API keys", zap.Error(err))
+		}
+	}
+
+	serverOpts := interceptors.ServerOptions(interceptors.Config{
+		Logger:    logger,
+		Metrics:   serverMetrics,
+		KeyStore:  keyStore,
+		Limiter:   limiter,
+		Validator: validator,
+	})
+	grpcServer := grpc.NewServer(append(serverOpts, creds)...)
+	greetpb.RegisterGreetServiceServer(grpcServer, &server{logger: logger, step: time.Second})
+	if keyStore != nil {
+		apikeypb.RegisterApiKeyServiceServer(grpcServer, apikey.NewService(keyStore))
 	}
-	grpcServer := grpc.NewServer(grpc.Creds(creds))
-	greetpb.RegisterGreetServiceServer(grpcServer, &server{})
 
 	//Register reflection on grpcServer
 	reflection.Register(grpcServer)
 
-	log.Fatal(grpcServer.Serve(lis))
+	go func() {
+		logger.Info("starting gRPC server", zap.Strings("addresses", listener.Addrs(listeners)))
+		if err := listener.Serve(grpcServer, listeners); err != nil {
+			logger.Fatal("gRPC server stopped", zap.Error(err))
+		}
+	}()
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt)
+	<-ch
+
+	logger.Info("shutting down gRPC server")
+	grpcServer.Stop()
 }
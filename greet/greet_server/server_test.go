@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-go-new-course/greet/greetpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func startGreet(t *testing.T) greetpb.GreetServiceClient {
+	validator := requestRules()
+	conn := grpctest.Start(t, func(s *grpc.Server) {
+		greetpb.RegisterGreetServiceServer(s, &server{logger: zap.NewNop(), step: 10 * time.Millisecond})
+	},
+		grpc.ChainUnaryInterceptor(validator.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(validator.StreamServerInterceptor()),
+	)
+	return greetpb.NewGreetServiceClient(conn)
+}
+
+func greeting(firstName string) *greetpb.Greeting {
+	return &greetpb.Greeting{FirstName: firstName, LastName: "Mensah"}
+}
+
+func TestGreet(t *testing.T) {
+	client := startGreet(t)
+
+	tests := []struct {
+		name     string
+		greeting *greetpb.Greeting
+		want     string
+		code     codes.Code
+	}{
+		{name: "first name", greeting: greeting("Ama"), want: "Hello Ama"},
+		{name: "no name without certificate", greeting: &greetpb.Greeting{}, code: codes.InvalidArgument},
+		{name: "first name too long", greeting: greeting(strings.Repeat("a", 101)), code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.Greet(context.Background(), &greetpb.GreetingRequest{Greeting: tt.greeting})
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+			if res.GetResult() != tt.want {
+				t.Errorf("got %q, want %q", res.GetResult(), tt.want)
+			}
+		})
+	}
+}
+
+func TestGreetWithDeadline(t *testing.T) {
+	client := startGreet(t)
+
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		greeting *greetpb.Greeting
+		want     string
+		code     codes.Code
+	}{
+		{name: "within deadline", timeout: time.Second, greeting: greeting("Ama"), want: "Hello Ama !"},
+		{name: "deadline exceeded", timeout: 15 * time.Millisecond, greeting: greeting("Ama"), code: codes.DeadlineExceeded},
+		{name: "missing first name", timeout: time.Second, greeting: &greetpb.Greeting{}, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			res, err := client.GreetWithDeadline(ctx, &greetpb.GreetWithDeadlineRequest{Greeting: tt.greeting})
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+			if res.GetResult() != tt.want {
+				t.Errorf("got %q, want %q", res.GetResult(), tt.want)
+			}
+		})
+	}
+}
+
+func TestGreetManyTimes(t *testing.T) {
+	client := startGreet(t)
+
+	tests := []struct {
+		name     string
+		greeting *greetpb.Greeting
+		want     int
+		code     codes.Code
+	}{
+		{name: "ten greetings", greeting: greeting("Ama"), want: 10},
+		{name: "missing first name", greeting: &greetpb.Greeting{}, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.GreetManyTimes(context.Background(), &greetpb.GreetManyTimesRequest{Greeting: tt.greeting})
+			if err != nil {
+				t.Fatalf("GreetManyTimes : %v", err)
+			}
+
+			var results []string
+			for {
+				res, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					grpctest.AssertCode(t, err, tt.code)
+					return
+				}
+				results = append(results, res.GetResult())
+			}
+			grpctest.AssertCode(t, nil, tt.code)
+
+			if len(results) != tt.want {
+				t.Fatalf("got %d greetings, want %d", len(results), tt.want)
+			}
+			if results[0] != "Hello Ama number : 0" || results[9] != "Hello Ama number : 9" {
+				t.Errorf("unexpected greetings %q", results)
+			}
+		})
+	}
+}
+
+func TestLongGreet(t *testing.T) {
+	client := startGreet(t)
+
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+		code  codes.Code
+	}{
+		{name: "several names", names: []string{"Ama", "Kofi"}, want: " Hello Ama !  Hello Kofi ! "},
+		{name: "no names", want: ""},
+		{name: "missing first name", names: []string{"Ama", ""}, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.LongGreet(context.Background())
+			if err != nil {
+				t.Fatalf("LongGreet : %v", err)
+			}
+			for _, name := range tt.names {
+				if err := stream.Send(&greetpb.LongGreetRequest{Greeting: greeting(name)}); err != nil {
+					break
+				}
+			}
+
+			res, err := stream.CloseAndRecv()
+			if !grpctest.AssertCode(t, err, tt.code) || err != nil {
+				return
+			}
+			if res.GetResult() != tt.want {
+				t.Errorf("got %q, want %q", res.GetResult(), tt.want)
+			}
+		})
+	}
+}
+
+func TestGreetEveryone(t *testing.T) {
+	client := startGreet(t)
+
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+		code  codes.Code
+	}{
+		{name: "one reply per greeting", names: []string{"Ama", "Kofi"}, want: []string{" Hello Ama !", " Hello Kofi !"}},
+		{name: "missing first name", names: []string{""}, code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.GreetEveryone(context.Background())
+			if err != nil {
+				t.Fatalf("GreetEveryone : %v", err)
+			}
+
+			var got []string
+			for _, name := range tt.names {
+				if err := stream.Send(&greetpb.GreetEveryoneRequest{Greeting: greeting(name)}); err != nil {
+					break
+				}
+				res, err := stream.Recv()
+				if err != nil {
+					grpctest.AssertCode(t, err, tt.code)
+					return
+				}
+				got = append(got, res.GetResult())
+			}
+			if err := stream.CloseSend(); err != nil {
+				t.Fatalf("CloseSend : %v", err)
+			}
+			if _, err := stream.Recv(); err != io.EOF {
+				grpctest.AssertCode(t, err, tt.code)
+				return
+			}
+			grpctest.AssertCode(t, nil, tt.code)
+
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package greetfake
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/grpc-go-new-course/greet/greetpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientRecordsCalls(t *testing.T) {
+	client := &Client{
+		GreetFunc: func(ctx context.Context, in *greetpb.GreetingRequest) (*greetpb.GreetingResponse, error) {
+			return &greetpb.GreetingResponse{Result: "Hello " + in.GetGreeting().GetFirstName()}, nil
+		},
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "abc")
+	res, err := client.Greet(ctx, &greetpb.GreetingRequest{Greeting: &greetpb.Greeting{FirstName: "Ama"}})
+	if err != nil || res.GetResult() != "Hello Ama" {
+		t.Fatalf("got %v, %v", res, err)
+	}
+	_, err = client.GreetWithDeadline(ctx, &greetpb.GreetWithDeadlineRequest{})
+	grpctest.AssertCode(t, err, codes.Unimplemented)
+
+	calls := client.CallsTo("Greet")
+	if len(calls) != 1 || len(client.Calls()) != 2 {
+		t.Fatalf("got calls %v", client.Calls())
+	}
+	if calls[0].Request.(*greetpb.GreetingRequest).GetGreeting().GetFirstName() != "Ama" {
+		t.Errorf("recorded request %v", calls[0].Request)
+	}
+	if got := calls[0].Metadata.Get("x-request-id"); len(got) != 1 || got[0] != "abc" {
+		t.Errorf("recorded metadata %v", calls[0].Metadata)
+	}
+}
+
+func TestGreetEveryoneStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		want    []string
+		wantErr codes.Code
+	}{
+		{name: "one reply per request", names: []string{"Ama", "Kofi"}, want: []string{"Hello Ama", "Hello Kofi"}},
+		{name: "error ends the stream", names: []string{"Ama", "", "Kofi"}, want: []string{"Hello Ama"}, wantErr: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &GreetEveryoneStream{
+				Reply: func(req *greetpb.GreetEveryoneRequest) (*greetpb.GreetEveryoneResponse, error) {
+					if req.GetGreeting().GetFirstName() == "" {
+						return nil, status.Error(codes.InvalidArgument, "missing first name")
+					}
+					return &greetpb.GreetEveryoneResponse{Result: "Hello " + req.GetGreeting().GetFirstName()}, nil
+				},
+			}
+			for _, name := range tt.names {
+				if err := stream.Send(&greetpb.GreetEveryoneRequest{Greeting: &greetpb.Greeting{FirstName: name}}); err != nil {
+					break
+				}
+			}
+			stream.CloseSend()
+
+			var got []string
+			var err error
+			for {
+				var res *greetpb.GreetEveryoneResponse
+				if res, err = stream.Recv(); err != nil {
+					break
+				}
+				got = append(got, res.GetResult())
+			}
+			if tt.wantErr == codes.OK && err != io.EOF {
+				t.Fatalf("got error %v, want io.EOF", err)
+			}
+			if tt.wantErr != codes.OK {
+				grpctest.AssertCode(t, err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGreetEveryoneStreamRecvBlocksUntilContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &GreetEveryoneStream{}
+	stream.Ctx = ctx
+	cancel()
+
+	_, err := stream.Recv()
+	grpctest.AssertCode(t, err, codes.Canceled)
+}
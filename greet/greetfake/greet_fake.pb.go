@@ -0,0 +1,183 @@
+// Code generated by protoc-gen-go-grpcfake. DO NOT EDIT.
+// source: greet/greetpb/greet.proto
+
+// Package greetfake provides a fake greetpb.GreetServiceClient, and fakes of its
+// streams, for unit testing code that calls the greet service without
+// running a server.
+package greetfake
+
+import (
+	context "context"
+	fakegrpc "github.com/grpc-go-new-course/fakegrpc"
+	greetpb "github.com/grpc-go-new-course/greet/greetpb"
+	grpc "google.golang.org/grpc"
+)
+
+// Client is a fake greetpb.GreetServiceClient. Every call is recorded,
+// then answered by the matching Func field, or fails with Unimplemented
+// when that field is nil. Call options are ignored.
+type Client struct {
+	fakegrpc.Recorder
+
+	GreetFunc             func(ctx context.Context, in *greetpb.GreetingRequest) (*greetpb.GreetingResponse, error)
+	GreetWithDeadlineFunc func(ctx context.Context, in *greetpb.GreetWithDeadlineRequest) (*greetpb.GreetWithDeadlineResponse, error)
+	GreetManyTimesFunc    func(ctx context.Context, in *greetpb.GreetManyTimesRequest) (greetpb.GreetService_GreetManyTimesClient, error)
+	LongGreetFunc         func(ctx context.Context) (greetpb.GreetService_LongGreetClient, error)
+	GreetEveryoneFunc     func(ctx context.Context) (greetpb.GreetService_GreetEveryoneClient, error)
+}
+
+var _ greetpb.GreetServiceClient = (*Client)(nil)
+
+func (c *Client) Greet(ctx context.Context, in *greetpb.GreetingRequest, opts ...grpc.CallOption) (*greetpb.GreetingResponse, error) {
+	c.Record(ctx, "Greet", in)
+	if c.GreetFunc == nil {
+		return nil, fakegrpc.Unimplemented("Greet")
+	}
+	return c.GreetFunc(ctx, in)
+}
+
+func (c *Client) GreetWithDeadline(ctx context.Context, in *greetpb.GreetWithDeadlineRequest, opts ...grpc.CallOption) (*greetpb.GreetWithDeadlineResponse, error) {
+	c.Record(ctx, "GreetWithDeadline", in)
+	if c.GreetWithDeadlineFunc == nil {
+		return nil, fakegrpc.Unimplemented("GreetWithDeadline")
+	}
+	return c.GreetWithDeadlineFunc(ctx, in)
+}
+
+func (c *Client) GreetManyTimes(ctx context.Context, in *greetpb.GreetManyTimesRequest, opts ...grpc.CallOption) (greetpb.GreetService_GreetManyTimesClient, error) {
+	c.Record(ctx, "GreetManyTimes", in)
+	if c.GreetManyTimesFunc == nil {
+		return nil, fakegrpc.Unimplemented("GreetManyTimes")
+	}
+	return c.GreetManyTimesFunc(ctx, in)
+}
+
+func (c *Client) LongGreet(ctx context.Context, opts ...grpc.CallOption) (greetpb.GreetService_LongGreetClient, error) {
+	c.Record(ctx, "LongGreet", nil)
+	if c.LongGreetFunc == nil {
+		return nil, fakegrpc.Unimplemented("LongGreet")
+	}
+	return c.LongGreetFunc(ctx)
+}
+
+func (c *Client) GreetEveryone(ctx context.Context, opts ...grpc.CallOption) (greetpb.GreetService_GreetEveryoneClient, error) {
+	c.Record(ctx, "GreetEveryone", nil)
+	if c.GreetEveryoneFunc == nil {
+		return nil, fakegrpc.Unimplemented("GreetEveryone")
+	}
+	return c.GreetEveryoneFunc(ctx)
+}
+
+// GreetManyTimesStream is a fake greetpb.GreetService_GreetManyTimesClient.
+// Recv returns Responses in order, then Err, or io.EOF when Err is nil.
+type GreetManyTimesStream struct {
+	fakegrpc.ClientStream
+	Responses []*greetpb.GreetManyTimesResponse
+	Err       error
+
+	cursor fakegrpc.Cursor
+}
+
+var _ greetpb.GreetService_GreetManyTimesClient = (*GreetManyTimesStream)(nil)
+
+func (s *GreetManyTimesStream) Recv() (*greetpb.GreetManyTimesResponse, error) {
+	i, err := s.cursor.Next(len(s.Responses), s.Err)
+	if err != nil {
+		return nil, err
+	}
+	return s.Responses[i], nil
+}
+
+// LongGreetStream is a fake greetpb.GreetService_LongGreetClient.
+// It keeps the requests sent on it and answers CloseAndRecv with Reply,
+// or with Response and Err when Reply is nil.
+type LongGreetStream struct {
+	fakegrpc.ClientStream
+	Reply    func(sent []*greetpb.LongGreetRequest) (*greetpb.LongGreetResponse, error)
+	Response *greetpb.LongGreetResponse
+	Err      error
+	// SendErr, when set, fails every Send.
+	SendErr error
+
+	sent fakegrpc.Messages
+}
+
+var _ greetpb.GreetService_LongGreetClient = (*LongGreetStream)(nil)
+
+func (s *LongGreetStream) Send(req *greetpb.LongGreetRequest) error {
+	if s.SendErr != nil {
+		return s.SendErr
+	}
+	s.sent.Append(req)
+	return nil
+}
+
+// Sent returns the requests sent so far.
+func (s *LongGreetStream) Sent() []*greetpb.LongGreetRequest {
+	msgs := s.sent.List()
+	sent := make([]*greetpb.LongGreetRequest, len(msgs))
+	for i, msg := range msgs {
+		sent[i] = msg.(*greetpb.LongGreetRequest)
+	}
+	return sent
+}
+
+func (s *LongGreetStream) CloseAndRecv() (*greetpb.LongGreetResponse, error) {
+	s.CloseSend()
+	if s.Reply != nil {
+		return s.Reply(s.Sent())
+	}
+	return s.Response, s.Err
+}
+
+// GreetEveryoneStream is a fake greetpb.GreetService_GreetEveryoneClient.
+// Every request sent is passed to Reply: a non-nil response is queued for
+// Recv and an error ends the stream with that error. Recv blocks like a
+// real stream, until a response is queued, the stream ends or its context
+// is done, and returns io.EOF once CloseSend was called and every response
+// received.
+type GreetEveryoneStream struct {
+	fakegrpc.ClientStream
+	Reply func(req *greetpb.GreetEveryoneRequest) (*greetpb.GreetEveryoneResponse, error)
+
+	exchange fakegrpc.Exchange
+}
+
+var _ greetpb.GreetService_GreetEveryoneClient = (*GreetEveryoneStream)(nil)
+
+func (s *GreetEveryoneStream) Send(req *greetpb.GreetEveryoneRequest) error {
+	if s.Reply == nil {
+		return s.exchange.Send(req, nil)
+	}
+	return s.exchange.Send(req, func() (interface{}, error) {
+		res, err := s.Reply(req)
+		if res == nil {
+			return nil, err
+		}
+		return res, err
+	})
+}
+
+// Sent returns the requests sent so far.
+func (s *GreetEveryoneStream) Sent() []*greetpb.GreetEveryoneRequest {
+	msgs := s.exchange.List()
+	sent := make([]*greetpb.GreetEveryoneRequest, len(msgs))
+	for i, msg := range msgs {
+		sent[i] = msg.(*greetpb.GreetEveryoneRequest)
+	}
+	return sent
+}
+
+func (s *GreetEveryoneStream) CloseSend() error {
+	s.ClientStream.CloseSend()
+	s.exchange.CloseSend()
+	return nil
+}
+
+func (s *GreetEveryoneStream) Recv() (*greetpb.GreetEveryoneResponse, error) {
+	res, err := s.exchange.Recv(s.Context())
+	if err != nil {
+		return nil, err
+	}
+	return res.(*greetpb.GreetEveryoneResponse), nil
+}
@@ -0,0 +1,65 @@
+// Package grpctest serves gRPC services in process over bufconn, so tests
+// exercise the real client and server stacks, interceptors included,
+// without opening ports.
+package grpctest
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1 << 20
+
+// Start serves the services added by register on an in-memory listener and
+// returns a client connection to them. The server and the connection are
+// closed when the test ends.
+func Start(t testing.TB, register func(*grpc.Server), opts ...grpc.ServerOption) *grpc.ClientConn {
+	t.Helper()
+	return StartWithDialOptions(t, register, opts)
+}
+
+// StartWithDialOptions is Start with options for the client connection as
+// well, e.g. client interceptors.
+func StartWithDialOptions(t testing.TB, register func(*grpc.Server), serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(bufSize)
+	s := grpc.NewServer(serverOpts...)
+	register(s)
+	go s.Serve(lis)
+
+	conn, err := grpc.DialContext(context.Background(), "bufconn", append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, dialOpts...)...)
+	if err != nil {
+		s.Stop()
+		t.Fatalf("unable to dial bufconn : %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		s.Stop()
+	})
+	return conn
+}
+
+// AssertCode fails the test when the status code of err is not want. It
+// reports whether the codes matched, so callers can skip checking the
+// response of a failed call.
+func AssertCode(t testing.TB, err error, want codes.Code) bool {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Errorf("got code %v, want %v (err: %v)", got, want, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+// Package interceptors assembles the interceptor chains every server of this
+// repository runs its calls through.
+package interceptors
+
+import (
+	"github.com/grpc-go-new-course/apikey"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/metrics"
+	"github.com/grpc-go-new-course/ratelimit"
+	"github.com/grpc-go-new-course/tracing"
+	"github.com/grpc-go-new-course/validation"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// Config holds what the interceptors of a server are built from.
+type Config struct {
+	Logger  *zap.Logger
+	Metrics *metrics.Metrics
+	// KeyStore holds the API keys calls must present. Nil disables API key
+	// authentication, so the course examples keep working without keys.
+	KeyStore  apikey.Store
+	Limiter   *ratelimit.Limiter
+	Validator *validation.Validator
+}
+
+// ServerOptions returns the options installing the unary and stream chains
+// of cfg. Calls are traced, logged and measured first, so rejected calls
+// show up too, then authenticated, rate limited by the identity that
+// authentication established, and validated last.
+func ServerOptions(cfg Config) []grpc.ServerOption {
+	unary := []grpc.UnaryServerInterceptor{
+		tracing.UnaryServerInterceptor(),
+		logging.UnaryServerInterceptor(cfg.Logger),
+		cfg.Metrics.UnaryServerInterceptor(),
+	}
+	stream := []grpc.StreamServerInterceptor{
+		tracing.StreamServerInterceptor(),
+		logging.StreamServerInterceptor(cfg.Logger),
+		cfg.Metrics.StreamServerInterceptor(),
+	}
+	if cfg.KeyStore != nil {
+		auth := apikey.NewAuthenticator(cfg.KeyStore)
+		unary = append(unary, auth.UnaryServerInterceptor())
+		stream = append(stream, auth.StreamServerInterceptor())
+	}
+	unary = append(unary,
+		cfg.Limiter.UnaryServerInterceptor(),
+		cfg.Validator.UnaryServerInterceptor(),
+	)
+	stream = append(stream,
+		cfg.Limiter.StreamServerInterceptor(),
+		cfg.Validator.StreamServerInterceptor(),
+	)
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+}
@@ -0,0 +1,77 @@
+package interceptors
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/grpc-go-new-course/apikey"
+	"github.com/grpc-go-new-course/greet/greetpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/grpc-go-new-course/metrics"
+	"github.com/grpc-go-new-course/ratelimit"
+	"github.com/grpc-go-new-course/validation"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+type greeter struct {
+	greetpb.UnimplementedGreetServiceServer
+}
+
+func (greeter) Greet(ctx context.Context, req *greetpb.GreetingRequest) (*greetpb.GreetingResponse, error) {
+	return &greetpb.GreetingResponse{Result: "Hello " + req.GetGreeting().GetFirstName()}, nil
+}
+
+func TestServerOptionsOrder(t *testing.T) {
+	store, err := apikey.NewFileStore(filepath.Join(t.TempDir(), "apikeys.json"))
+	if err != nil {
+		t.Fatalf("NewFileStore : %v", err)
+	}
+	secrets := make([]string, 2)
+	for i := range secrets {
+		key, secret, err := apikey.New("test", []string{"*"})
+		if err != nil {
+			t.Fatalf("New : %v", err)
+		}
+		if err := store.Create(context.Background(), key); err != nil {
+			t.Fatalf("Create : %v", err)
+		}
+		secrets[i] = secret
+	}
+
+	conn := grpctest.Start(t, func(s *grpc.Server) {
+		greetpb.RegisterGreetServiceServer(s, greeter{})
+	}, ServerOptions(Config{
+		Logger:    zap.NewNop(),
+		Metrics:   metrics.New(),
+		KeyStore:  store,
+		Limiter:   ratelimit.New(ratelimit.Config{Default: ratelimit.Limit{PerSecond: 0.001, Burst: 1}}),
+		Validator: validation.New().Add(&greetpb.GreetingRequest{}, validation.Required("greeting.first_name")),
+	})...)
+	client := greetpb.NewGreetServiceClient(conn)
+
+	greet := func(secret, name string) error {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), apikey.MetadataKey, secret)
+		_, err := client.Greet(ctx, &greetpb.GreetingRequest{Greeting: &greetpb.Greeting{FirstName: name}})
+		return err
+	}
+	tests := []struct {
+		name   string
+		secret string
+		first  string
+		code   codes.Code
+	}{
+		// authentication comes before validation and rate limits
+		{name: "unknown key", secret: "gak_unknown", code: codes.Unauthenticated},
+		// validation comes after rate limits, so this takes the only token
+		{name: "invalid request", secret: secrets[0], code: codes.InvalidArgument},
+		{name: "same key", secret: secrets[0], first: "Ada", code: codes.ResourceExhausted},
+		{name: "another key", secret: secrets[1], first: "Ada", code: codes.OK},
+	}
+	for _, tt := range tests {
+		grpctest.AssertCode(t, greet(tt.secret, tt.first), tt.code)
+	}
+}
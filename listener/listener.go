@@ -0,0 +1,167 @@
+// Package listener opens the TCP and Unix domain socket listeners a server
+// accepts connections on.
+package listener
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+)
+
+// Flags holds the addresses a server listens on, read from the command line.
+type Flags struct {
+	// TCP is a host:port address, empty to skip TCP.
+	TCP string
+	// Unix is a socket path, or a Linux abstract socket name prefixed with
+	// "@". Empty skips the Unix socket.
+	Unix string
+	// Mode is applied to the socket file. Abstract sockets have no file and
+	// ignore it.
+	Mode os.FileMode
+}
+
+// Register adds the -addr, -unix-socket and -unix-socket-mode flags to fs.
+func (f *Flags) Register(fs *flag.FlagSet, defaultTCP string) {
+	f.Mode = 0660
+	fs.StringVar(&f.TCP, "addr", defaultTCP, "TCP address to listen on, empty to only listen on -unix-socket")
+	fs.StringVar(&f.Unix, "unix-socket", "", `Unix domain socket to listen on, "@name" for an abstract socket`)
+	fs.Var((*fileMode)(&f.Mode), "unix-socket-mode", "permissions of the Unix domain socket file, in octal")
+}
+
+// Listen opens every configured listener.
+func (f *Flags) Listen() ([]net.Listener, error) {
+	if f.TCP == "" && f.Unix == "" {
+		return nil, errors.New("no address to listen on, set -addr or -unix-socket")
+	}
+
+	var listeners []net.Listener
+	if f.TCP != "" {
+		lis, err := net.Listen("tcp", f.TCP)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, lis)
+	}
+	if f.Unix != "" {
+		lis, err := Unix(f.Unix, f.Mode)
+		if err != nil {
+			closeAll(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, lis)
+	}
+	return listeners, nil
+}
+
+// Unix listens on the socket at path with the given permissions. A socket
+// file left behind by a previous run is removed first; any other file at
+// path is an error. Names starting with "@" are Linux abstract sockets.
+func Unix(path string, mode os.FileMode) (net.Listener, error) {
+	if strings.HasPrefix(path, "@") {
+		return net.Listen("unix", path)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("removing stale socket : %v", err)
+		}
+	}
+
+	// bind in a private directory and move the socket into place once its
+	// permissions are set, so it never has the looser ones of the umask
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".sock")
+	if err != nil {
+		return nil, fmt.Errorf("creating socket directory : %v", err)
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "s")
+
+	lis, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	unixLis := lis.(*net.UnixListener)
+	// the listener would unlink tmp, not where the socket ends up
+	unixLis.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, mode); err != nil {
+		lis.Close()
+		return nil, fmt.Errorf("setting socket permissions : %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		lis.Close()
+		return nil, fmt.Errorf("moving socket into place : %v", err)
+	}
+	return &unixListener{UnixListener: unixLis, path: path}, nil
+}
+
+// unixListener removes its socket file when closed.
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// Addr reports the path clients dial rather than the one the socket was
+// bound to.
+func (l *unixListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: l.path, Net: "unix"}
+}
+
+// Serve serves s on every listener until one of them fails, and returns
+// that error.
+func Serve(s *grpc.Server, listeners []net.Listener) error {
+	errs := make(chan error, len(listeners))
+	for _, lis := range listeners {
+		go func(lis net.Listener) {
+			errs <- s.Serve(lis)
+		}(lis)
+	}
+	return <-errs
+}
+
+// Addrs returns the addresses of listeners, for logging.
+func Addrs(listeners []net.Listener) []string {
+	addrs := make([]string, len(listeners))
+	for i, lis := range listeners {
+		addrs[i] = lis.Addr().Network() + "://" + lis.Addr().String()
+	}
+	return addrs
+}
+
+func closeAll(listeners []net.Listener) {
+	for _, lis := range listeners {
+		lis.Close()
+	}
+}
+
+// fileMode is a flag.Value reading permissions in octal.
+type fileMode os.FileMode
+
+func (m *fileMode) String() string {
+	return fmt.Sprintf("%#o", uint32(*m))
+}
+
+func (m *fileMode) Set(s string) error {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0777 {
+		return fmt.Errorf("invalid permissions %q", s)
+	}
+	*m = fileMode(mode)
+	return nil
+}
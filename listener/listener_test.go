@@ -0,0 +1,76 @@
+package listener
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.sock")
+
+	lis, err := Unix(path, 0600)
+	if err != nil {
+		t.Fatalf("Unix : %v", err)
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("socket missing : %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0600 {
+		t.Errorf("got mode %v, want a socket with 0600", info.Mode())
+	}
+	if got := lis.Addr().String(); got != path {
+		t.Errorf("got address %s, want %s", got, path)
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("got %d entries in the socket directory, want only the socket", len(entries))
+	}
+
+	accepted := make(chan struct{})
+	go func(lis net.Listener) {
+		defer close(accepted)
+		if conn, err := lis.Accept(); err == nil {
+			conn.Close()
+		}
+	}(lis)
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial : %v", err)
+	}
+	conn.Close()
+	<-accepted
+
+	// a socket left behind by a crash is replaced
+	lis.Close()
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	replaced, err := Unix(path, 0600)
+	if err != nil {
+		t.Fatalf("Unix over a stale socket : %v", err)
+	}
+	replaced.Close()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("socket still present after Close : %v", err)
+	}
+}
+
+func TestUnixRefusesOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	if err := ioutil.WriteFile(path, []byte("keep me"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Unix(path, 0600); err == nil {
+		t.Fatal("Unix replaced a regular file")
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "keep me" {
+		t.Errorf("file changed to %q", data)
+	}
+}
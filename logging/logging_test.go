@@ -0,0 +1,209 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-go-new-course/greet/greetpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// greeter answers with the request ID its handlers see, failing Greet
+// without a first name.
+type greeter struct {
+	greetpb.UnimplementedGreetServiceServer
+}
+
+func (greeter) Greet(ctx context.Context, req *greetpb.GreetingRequest) (*greetpb.GreetingResponse, error) {
+	if req.GetGreeting().GetFirstName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing first name")
+	}
+	return &greetpb.GreetingResponse{Result: RequestID(ctx)}, nil
+}
+
+func (greeter) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) error {
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&greetpb.GreetEveryoneResponse{Result: RequestID(stream.Context())}); err != nil {
+			return err
+		}
+	}
+}
+
+// startLogged serves greeter behind the server interceptors, dialed through
+// the client ones, observing every log entry.
+func startLogged(t *testing.T) (greetpb.GreetServiceClient, *observer.ObservedLogs) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+	conn := grpctest.StartWithDialOptions(t, func(s *grpc.Server) {
+		greetpb.RegisterGreetServiceServer(s, greeter{})
+	},
+		[]grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(UnaryServerInterceptor(logger)),
+			grpc.ChainStreamInterceptor(StreamServerInterceptor(logger)),
+		},
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(StreamClientInterceptor()),
+	)
+	return greetpb.NewGreetServiceClient(conn), logs
+}
+
+// checkCompletion checks logs holds the single completion entry of an RPC.
+func checkCompletion(t *testing.T, logs *observer.ObservedLogs, method, id string, code codes.Code) {
+	t.Helper()
+	entries := logs.TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	level, message := zapcore.InfoLevel, "rpc completed"
+	if code != codes.OK {
+		level, message = zapcore.WarnLevel, "rpc failed"
+	}
+	if entry.Level != level || entry.Message != message {
+		t.Errorf("got %s %q, want %s %q", entry.Level, entry.Message, level, message)
+	}
+	fields := entry.ContextMap()
+	want := map[string]interface{}{
+		"grpc_method": method,
+		"grpc_code":   code.String(),
+		"request_id":  id,
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("got %s %v, want %v", key, fields[key], value)
+		}
+	}
+	if _, ok := fields["duration"].(time.Duration); !ok {
+		t.Errorf("got duration %v, want a time.Duration", fields["duration"])
+	}
+	if _, ok := fields["error"]; ok != (code != codes.OK) {
+		t.Errorf("got error field %v with code %s", fields["error"], code)
+	}
+}
+
+func TestUnaryInterceptors(t *testing.T) {
+	client, logs := startLogged(t)
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		firstName string
+		want      string
+		code      codes.Code
+	}{
+		{name: "context ID", ctx: WithRequestID(context.Background(), "checkout-42"), firstName: "Ama", want: "checkout-42"},
+		{name: "metadata ID", ctx: metadata.AppendToOutgoingContext(context.Background(), RequestIDKey, "abc.1"), firstName: "Ama", want: "abc.1"},
+		{name: "new ID", ctx: context.Background(), firstName: "Ama"},
+		{name: "invalid ID", ctx: metadata.AppendToOutgoingContext(context.Background(), RequestIDKey, "abc def"), firstName: "Ama"},
+		{name: "failed", ctx: WithRequestID(context.Background(), "checkout-43"), want: "checkout-43", code: codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header metadata.MD
+			res, err := client.Greet(tt.ctx, &greetpb.GreetingRequest{Greeting: &greetpb.Greeting{FirstName: tt.firstName}}, grpc.Header(&header))
+			grpctest.AssertCode(t, err, tt.code)
+
+			values := header.Get(RequestIDKey)
+			if len(values) != 1 {
+				t.Fatalf("got response header %v, want one request ID", values)
+			}
+			id := values[0]
+			if tt.want != "" && id != tt.want {
+				t.Errorf("got request ID %q, want %q", id, tt.want)
+			}
+			if tt.want == "" && (!validRequestID(id) || len(id) != 32) {
+				t.Errorf("got request ID %q, want a new one", id)
+			}
+			if err == nil && res.GetResult() != id {
+				t.Errorf("handler saw request ID %q, want %q", res.GetResult(), id)
+			}
+			checkCompletion(t, logs, "/greet.GreetService/Greet", id, tt.code)
+		})
+	}
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	client, logs := startLogged(t)
+
+	stream, err := client.GreetEveryone(WithRequestID(context.Background(), "checkout-42"))
+	if err != nil {
+		t.Fatalf("GreetEveryone : %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := stream.Send(&greetpb.GreetEveryoneRequest{}); err != nil {
+			t.Fatalf("Send : %v", err)
+		}
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv : %v", err)
+		}
+		if res.GetResult() != "checkout-42" {
+			t.Errorf("handler saw request ID %q, want checkout-42", res.GetResult())
+		}
+	}
+	// the header goes out with the first response
+	header, err := stream.Header()
+	if err != nil {
+		t.Fatalf("Header : %v", err)
+	}
+	if got := header.Get(RequestIDKey); len(got) != 1 || got[0] != "checkout-42" {
+		t.Errorf("got response header %v, want [checkout-42]", got)
+	}
+	stream.CloseSend()
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("Recv after CloseSend : %v", err)
+	}
+	checkCompletion(t, logs, "/greet.GreetService/GreetEveryone", "checkout-42", codes.OK)
+}
+
+func TestIncomingRequestID(t *testing.T) {
+	tests := []struct {
+		name string
+		sent []string
+		kept bool
+	}{
+		{name: "uuid", sent: []string{"5f0c6e2a-9b1d-4c1e-8f4e-2d7a3b9c0e11"}, kept: true},
+		{name: "dotted", sent: []string{"checkout.v2_retry-3"}, kept: true},
+		{name: "longest", sent: []string{strings.Repeat("a", maxRequestIDLength)}, kept: true},
+		{name: "none"},
+		{name: "empty", sent: []string{""}},
+		{name: "too long", sent: []string{strings.Repeat("a", maxRequestIDLength+1)}},
+		{name: "newline", sent: []string{"abc\nlevel=error fake entry"}},
+		{name: "control character", sent: []string{"abc\x1b[31m"}},
+		{name: "space", sent: []string{"abc def"}},
+		{name: "non ASCII", sent: []string{"café"}},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.sent != nil {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(RequestIDKey, tt.sent[0]))
+		}
+		got := incomingRequestID(ctx)
+		if tt.kept {
+			if got != tt.sent[0] {
+				t.Errorf("%s : got %q, want the client's %q", tt.name, got, tt.sent[0])
+			}
+			continue
+		}
+		if (tt.sent != nil && got == tt.sent[0]) || !validRequestID(got) || len(got) != 32 {
+			t.Errorf("%s : got %q, want a new request ID", tt.name, got)
+		}
+	}
+}
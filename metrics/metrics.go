@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics holds the Prometheus collectors recorded by the gRPC server
+// interceptors, by the blog store's MongoDB calls and by TLS certificate
+// reloads.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	handled    *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	inFlight   *prometheus.GaugeVec
+	streamMsgs *prometheus.CounterVec
+	mongo      *prometheus.HistogramVec
+
+	certReloads *prometheus.CounterVec
+	certExpiry  prometheus.Gauge
+}
+
+// New creates a Metrics with its own registry, including the Go runtime and
+// process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		handled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "Total number of RPCs completed on the server, by status code.",
+		}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Latency of RPCs handled by the server.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"grpc_type", "grpc_service", "grpc_method"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "grpc_server_in_flight",
+			Help: "Number of RPCs currently being handled by the server.",
+		}, []string{"grpc_type", "grpc_service", "grpc_method"}),
+		streamMsgs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_stream_msgs_total",
+			Help: "Total number of stream messages received from and sent to clients.",
+		}, []string{"grpc_service", "grpc_method", "direction"}),
+		mongo: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mongo_operation_seconds",
+			Help:    "Latency of MongoDB operations, by operation and result.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation", "result"}),
+		certReloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tls_certificate_reloads_total",
+			Help: "Total number of TLS certificate reload attempts, by result.",
+		}, []string{"result"}),
+		certExpiry: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tls_certificate_not_after_timestamp_seconds",
+			Help: "Expiry of the TLS certificate in use, as a Unix timestamp.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.handled,
+		m.latency,
+		m.inFlight,
+		m.streamMsgs,
+		m.mongo,
+		m.certReloads,
+		m.certExpiry,
+	)
+	return m
+}
+
+// Handler returns the HTTP handler exposing the registry.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve exposes the metrics on addr at /metrics. It blocks like
+// http.ListenAndServe.
+func (m *Metrics) Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	return http.ListenAndServe(addr, mux)
+}
+
+// ObserveMongo records the duration of a MongoDB operation started at start.
+func (m *Metrics) ObserveMongo(operation string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.mongo.WithLabelValues(operation, result).Observe(time.Since(start).Seconds())
+}
+
+// ObserveCertReload records a TLS certificate reload attempt and the expiry
+// of the certificate in use. Its signature matches
+// tlsconfig.ReloaderOptions.OnReload.
+func (m *Metrics) ObserveCertReload(notAfter time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.certReloads.WithLabelValues(result).Inc()
+	if !notAfter.IsZero() {
+		m.certExpiry.Set(float64(notAfter.Unix()))
+	}
+}
+
+// UnaryServerInterceptor records request counts, latency and in-flight
+// requests for unary RPCs.
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		service, method := splitMethod(info.FullMethod)
+
+		inFlight := m.inFlight.WithLabelValues("unary", service, method)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		m.latency.WithLabelValues("unary", service, method).Observe(time.Since(start).Seconds())
+		m.handled.WithLabelValues("unary", service, method, status.Code(err).String()).Inc()
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records request counts, latency, in-flight
+// requests and the number of messages received and sent for streaming RPCs.
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		service, method := splitMethod(info.FullMethod)
+		rpcType := streamType(info)
+
+		inFlight := m.inFlight.WithLabelValues(rpcType, service, method)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		err := handler(srv, &monitoredStream{
+			ServerStream: ss,
+			received:     m.streamMsgs.WithLabelValues(service, method, "received"),
+			sent:         m.streamMsgs.WithLabelValues(service, method, "sent"),
+		})
+
+		m.latency.WithLabelValues(rpcType, service, method).Observe(time.Since(start).Seconds())
+		m.handled.WithLabelValues(rpcType, service, method, status.Code(err).String()).Inc()
+		return err
+	}
+}
+
+// monitoredStream counts the messages flowing through a server stream.
+type monitoredStream struct {
+	grpc.ServerStream
+	received prometheus.Counter
+	sent     prometheus.Counter
+}
+
+func (s *monitoredStream) SendMsg(msg interface{}) error {
+	err := s.ServerStream.SendMsg(msg)
+	if err == nil {
+		s.sent.Inc()
+	}
+	return err
+}
+
+func (s *monitoredStream) RecvMsg(msg interface{}) error {
+	err := s.ServerStream.RecvMsg(msg)
+	if err == nil {
+		s.received.Inc()
+	}
+	return err
+}
+
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	default:
+		return "server_stream"
+	}
+}
+
+// splitMethod splits "/package.Service/Method" into its service and method.
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
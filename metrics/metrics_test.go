@@ -0,0 +1,176 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/grpc-go-new-course/greet/greetpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// greeter answers Greet, failing without a first name, and echoes
+// GreetEveryone.
+type greeter struct {
+	greetpb.UnimplementedGreetServiceServer
+}
+
+func (greeter) Greet(ctx context.Context, req *greetpb.GreetingRequest) (*greetpb.GreetingResponse, error) {
+	if req.GetGreeting().GetFirstName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing first name")
+	}
+	return &greetpb.GreetingResponse{Result: "Hello " + req.GetGreeting().GetFirstName()}, nil
+}
+
+func (greeter) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&greetpb.GreetEveryoneResponse{Result: "Hello " + req.GetGreeting().GetFirstName()}); err != nil {
+			return err
+		}
+	}
+}
+
+func startGreeter(t *testing.T, m *Metrics) greetpb.GreetServiceClient {
+	conn := grpctest.Start(t, func(s *grpc.Server) {
+		greetpb.RegisterGreetServiceServer(s, greeter{})
+	},
+		grpc.ChainUnaryInterceptor(m.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(m.StreamServerInterceptor()),
+	)
+	return greetpb.NewGreetServiceClient(conn)
+}
+
+// latencyCount returns the number of latencies observed under labels.
+func latencyCount(t *testing.T, m *Metrics, labels ...string) uint64 {
+	t.Helper()
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather : %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "grpc_server_handling_seconds" {
+			continue
+		}
+	metrics:
+		for _, metric := range family.GetMetric() {
+			// labels are sorted by name: grpc_method, grpc_service, grpc_type
+			for i, pair := range metric.GetLabel() {
+				if pair.GetValue() != labels[i] {
+					continue metrics
+				}
+			}
+			return metric.GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	m := New()
+	client := startGreeter(t, m)
+	ctx := context.Background()
+
+	for _, name := range []string{"Ama", "Kofi", ""} {
+		client.Greet(ctx, &greetpb.GreetingRequest{Greeting: &greetpb.Greeting{FirstName: name}})
+	}
+
+	tests := []struct {
+		code string
+		want float64
+	}{
+		{code: "OK", want: 2},
+		{code: "InvalidArgument", want: 1},
+		{code: "Internal", want: 0},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(m.handled.WithLabelValues("unary", "greet.GreetService", "Greet", tt.code)); got != tt.want {
+			t.Errorf("got %v calls handled with %s, want %v", got, tt.code, tt.want)
+		}
+	}
+	if got := latencyCount(t, m, "Greet", "greet.GreetService", "unary"); got != 3 {
+		t.Errorf("got %d latencies, want 3", got)
+	}
+	if got := testutil.ToFloat64(m.inFlight.WithLabelValues("unary", "greet.GreetService", "Greet")); got != 0 {
+		t.Errorf("got %v calls in flight after they completed", got)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	m := New()
+	client := startGreeter(t, m)
+
+	stream, err := client.GreetEveryone(context.Background())
+	if err != nil {
+		t.Fatalf("GreetEveryone : %v", err)
+	}
+	for _, name := range []string{"Ama", "Kofi", "Yaw"} {
+		if err := stream.Send(&greetpb.GreetEveryoneRequest{Greeting: &greetpb.Greeting{FirstName: name}}); err != nil {
+			t.Fatalf("Send : %v", err)
+		}
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("Recv : %v", err)
+		}
+	}
+	stream.CloseSend()
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("Recv after CloseSend : %v", err)
+	}
+
+	if got := testutil.ToFloat64(m.handled.WithLabelValues("bidi_stream", "greet.GreetService", "GreetEveryone", "OK")); got != 1 {
+		t.Errorf("got %v streams handled, want 1", got)
+	}
+	if got := latencyCount(t, m, "GreetEveryone", "greet.GreetService", "bidi_stream"); got != 1 {
+		t.Errorf("got %d latencies, want 1", got)
+	}
+	for _, direction := range []string{"received", "sent"} {
+		if got := testutil.ToFloat64(m.streamMsgs.WithLabelValues("greet.GreetService", "GreetEveryone", direction)); got != 3 {
+			t.Errorf("got %v messages %s, want 3", got, direction)
+		}
+	}
+}
+
+func TestSplitMethod(t *testing.T) {
+	tests := []struct {
+		fullMethod      string
+		service, method string
+	}{
+		{fullMethod: "/greet.GreetService/Greet", service: "greet.GreetService", method: "Greet"},
+		{fullMethod: "Greet", service: "unknown", method: "Greet"},
+	}
+	for _, tt := range tests {
+		if service, method := splitMethod(tt.fullMethod); service != tt.service || method != tt.method {
+			t.Errorf("splitMethod(%q) = %q, %q, want %q, %q", tt.fullMethod, service, method, tt.service, tt.method)
+		}
+	}
+}
+
+func TestObserveCertReload(t *testing.T) {
+	m := New()
+	notAfter := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.ObserveCertReload(notAfter, nil)
+	m.ObserveCertReload(time.Time{}, errors.New("bad key"))
+
+	if got := testutil.ToFloat64(m.certReloads.WithLabelValues("ok")); got != 1 {
+		t.Errorf("got %v successful reloads, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.certReloads.WithLabelValues("error")); got != 1 {
+		t.Errorf("got %v failed reloads, want 1", got)
+	}
+	// a failed reload keeps the expiry of the certificate still in use
+	if got := testutil.ToFloat64(m.certExpiry); got != float64(notAfter.Unix()) {
+		t.Errorf("got expiry %v, want %v", got, notAfter.Unix())
+	}
+}
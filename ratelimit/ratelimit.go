@@ -0,0 +1,286 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/grpc-go-new-course/apikey"
+	"github.com/grpc-go-new-course/rpcerror"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	// idleTimeout is how long a client's bucket is kept without any request.
+	idleTimeout = 10 * time.Minute
+	// fullSweepInterval is how often a Limiter whose buckets are all taken
+	// looks for idle ones, rather than on every new client.
+	fullSweepInterval = time.Minute
+	// defaultMaxBuckets bounds the buckets of a Limiter whose Config sets no
+	// MaxBuckets.
+	defaultMaxBuckets = 100000
+)
+
+// overflowClient is the client of the buckets shared by the callers that
+// arrive while every bucket is taken.
+const overflowClient = "overflow"
+
+// Limit is a token bucket refilled at PerSecond tokens per second holding up
+// to Burst tokens.
+type Limit struct {
+	PerSecond float64
+	Burst     int
+}
+
+// Config describes the limits enforced by a Limiter. Methods are keyed by
+// full method name, e.g. "/blog.BlogService/CreateBlog".
+type Config struct {
+	// Default applies to methods without an entry in Methods. A zero Default
+	// leaves those methods unlimited.
+	Default Limit
+	// Methods overrides the per-client call rate of specific methods.
+	Methods map[string]Limit
+	// StreamMessages limits the rate of messages a client may send on a
+	// single stream of the given methods.
+	StreamMessages map[string]Limit
+	// Key identifies the client a call is accounted to. It defaults to
+	// DefaultKey.
+	Key KeyFunc
+	// MaxBuckets bounds the number of client and method pairs tracked at
+	// once. Beyond it, new clients share one bucket per method until idle
+	// buckets expire. It defaults to 100000.
+	MaxBuckets int
+}
+
+// KeyFunc returns the identity a call is rate limited under, or "" when it
+// cannot tell.
+type KeyFunc func(ctx context.Context) string
+
+// ByMetadata keys clients by the value of a metadata entry. Clients choose
+// that value freely, so it only suits entries checked before the limiter
+// runs; API keys should be keyed with ByAPIKey instead.
+func ByMetadata(key string) KeyFunc {
+	return func(ctx context.Context) string {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get(key); len(values) > 0 && values[0] != "" {
+			return key + ":" + values[0]
+		}
+		return ""
+	}
+}
+
+// ByAPIKey keys clients by the ID of the API key an apikey.Authenticator
+// accepted, so its interceptor must run before the limiter's.
+func ByAPIKey(ctx context.Context) string {
+	if key, ok := apikey.FromContext(ctx); ok {
+		return "apikey:" + key.ID
+	}
+	return ""
+}
+
+// ByPrincipal keys clients by the subject of their TLS client certificate.
+func ByPrincipal(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ""
+	}
+	return "principal:" + tlsInfo.State.PeerCertificates[0].Subject.String()
+}
+
+// ByPeerIP keys clients by the IP address they connect from.
+func ByPeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "ip:" + host
+}
+
+// FirstOf returns the first non-empty key produced by keys.
+func FirstOf(keys ...KeyFunc) KeyFunc {
+	return func(ctx context.Context) string {
+		for _, key := range keys {
+			if k := key(ctx); k != "" {
+				return k
+			}
+		}
+		return ""
+	}
+}
+
+// DefaultKey keys clients by authenticated API key, then by certificate
+// principal, then by peer IP.
+var DefaultKey = FirstOf(ByAPIKey, ByPrincipal, ByPeerIP)
+
+type bucketKey struct {
+	client string
+	method string
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter enforces per-client, per-method call rates and per-stream message
+// rates.
+type Limiter struct {
+	cfg Config
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+// New returns a Limiter enforcing cfg.
+func New(cfg Config) *Limiter {
+	if cfg.Key == nil {
+		cfg.Key = DefaultKey
+	}
+	if cfg.MaxBuckets <= 0 {
+		cfg.MaxBuckets = defaultMaxBuckets
+	}
+	return &Limiter{cfg: cfg, buckets: make(map[bucketKey]*bucket), lastSweep: time.Now()}
+}
+
+func (l *Limiter) limitFor(method string) (Limit, bool) {
+	if limit, ok := l.cfg.Methods[method]; ok {
+		return limit, true
+	}
+	return l.cfg.Default, l.cfg.Default.PerSecond > 0
+}
+
+// allow takes a token from the caller's bucket for method and returns how
+// long to wait before retrying when none is left.
+func (l *Limiter) allow(ctx context.Context, method string) (time.Duration, bool) {
+	limit, ok := l.limitFor(method)
+	if !ok {
+		return 0, true
+	}
+	key := bucketKey{client: l.cfg.Key(ctx), method: method}
+	now := time.Now()
+
+	l.mu.Lock()
+	l.sweepLocked(now, idleTimeout)
+	b, ok := l.buckets[key]
+	if !ok && len(l.buckets) >= l.cfg.MaxBuckets {
+		l.sweepLocked(now, fullSweepInterval)
+		if len(l.buckets) >= l.cfg.MaxBuckets {
+			key.client = overflowClient
+			b, ok = l.buckets[key]
+		}
+	}
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.PerSecond), limit.Burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	l.mu.Unlock()
+
+	return reserve(b.limiter, now)
+}
+
+// sweepLocked forgets the buckets of clients that went idle, unless it
+// already did less than interval ago.
+func (l *Limiter) sweepLocked(now time.Time, interval time.Duration) {
+	if now.Sub(l.lastSweep) < interval {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func reserve(limiter *rate.Limiter, now time.Time) (time.Duration, bool) {
+	r := limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return time.Second, false
+	}
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return delay, false
+	}
+	return 0, true
+}
+
+func exhausted(method, what string, retryAfter time.Duration) error {
+	return rpcerror.New(codes.ResourceExhausted, fmt.Sprintf("%s rate limit exceeded for %s, retry in %v", what, method, retryAfter.Round(time.Millisecond)),
+		rpcerror.ErrorInfo("RATE_LIMITED", "method", method, "limit", what),
+		rpcerror.RetryInfo(retryAfter),
+	)
+}
+
+// UnaryServerInterceptor rejects calls beyond the caller's rate with
+// ResourceExhausted and a RetryInfo telling when to try again.
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if delay, ok := l.allow(ctx, info.FullMethod); !ok {
+			return nil, exhausted(info.FullMethod, "call", delay)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor applies the call rate when a stream opens and the
+// message rate of the method, if any, to every message the client sends.
+func (l *Limiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if delay, ok := l.allow(ss.Context(), info.FullMethod); !ok {
+			return exhausted(info.FullMethod, "call", delay)
+		}
+
+		limit, ok := l.cfg.StreamMessages[info.FullMethod]
+		if !ok || !info.IsClientStream {
+			return handler(srv, ss)
+		}
+
+		stream := &limitedStream{
+			ServerStream: ss,
+			method:       info.FullMethod,
+			limiter:      rate.NewLimiter(rate.Limit(limit.PerSecond), limit.Burst),
+		}
+		err := handler(srv, stream)
+		if stream.exceeded != nil {
+			// report the limit with its RetryInfo, whatever the handler made
+			// of the failed Recv
+			return stream.exceeded
+		}
+		return err
+	}
+}
+
+type limitedStream struct {
+	grpc.ServerStream
+	method   string
+	limiter  *rate.Limiter
+	exceeded error
+}
+
+func (s *limitedStream) RecvMsg(msg interface{}) error {
+	if err := s.ServerStream.RecvMsg(msg); err != nil {
+		return err
+	}
+	if delay, ok := reserve(s.limiter, time.Now()); !ok {
+		s.exceeded = exhausted(s.method, "message", delay)
+		return s.exceeded
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grpc-go-new-course/apikey"
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/grpc-go-new-course/rpcerror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const method = "/greet.GreetService/Greet"
+
+func peerContext(ip string, auth credentials.AuthInfo) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr:     &net.TCPAddr{IP: net.ParseIP(ip), Port: 1234},
+		AuthInfo: auth,
+	})
+}
+
+func TestDefaultKey(t *testing.T) {
+	withCert := credentials.TLSInfo{State: tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "alice"}}},
+	}}
+	// a header nobody validated must not pick the bucket
+	unchecked := metadata.NewIncomingContext(peerContext("10.0.0.1", nil), metadata.Pairs("x-api-key", "random"))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "peer IP", ctx: peerContext("10.0.0.1", nil), want: "ip:10.0.0.1"},
+		{name: "unchecked API key", ctx: unchecked, want: "ip:10.0.0.1"},
+		{name: "authenticated API key", ctx: apikey.NewContext(unchecked, &apikey.Key{ID: "k1"}), want: "apikey:k1"},
+		{name: "principal", ctx: peerContext("10.0.0.1", withCert), want: "principal:CN=alice"},
+		{name: "no peer", ctx: context.Background(), want: ""},
+	}
+	for _, tt := range tests {
+		if got := DefaultKey(tt.ctx); got != tt.want {
+			t.Errorf("%s : got key %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// byClient keys calls by the "client" metadata entry.
+var byClient = ByMetadata("client")
+
+func clientContext(client string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("client", client))
+}
+
+func TestLimiterAllow(t *testing.T) {
+	l := New(Config{
+		Default: Limit{PerSecond: 0.001, Burst: 2},
+		Methods: map[string]Limit{"/greet.GreetService/GreetManyTimes": {PerSecond: 0.001, Burst: 1}},
+		Key:     byClient,
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, ok := l.allow(clientContext("a"), method); !ok {
+			t.Fatalf("call %d of a rejected within the burst", i+1)
+		}
+	}
+	delay, ok := l.allow(clientContext("a"), method)
+	if ok || delay <= 0 {
+		t.Errorf("third call of a : got %v, %v, want a rejection with a delay", delay, ok)
+	}
+	if _, ok := l.allow(clientContext("b"), method); !ok {
+		t.Error("b rejected on the bucket of a")
+	}
+	if _, ok := l.allow(clientContext("a"), "/greet.GreetService/GreetManyTimes"); !ok {
+		t.Error("a rejected on another method")
+	}
+	if _, ok := l.allow(clientContext("a"), "/greet.GreetService/GreetManyTimes"); ok {
+		t.Error("second GreetManyTimes of a allowed beyond its method limit")
+	}
+}
+
+func TestLimiterUnlimitedWithoutDefault(t *testing.T) {
+	l := New(Config{Key: byClient})
+	for i := 0; i < 100; i++ {
+		if _, ok := l.allow(clientContext("a"), method); !ok {
+			t.Fatalf("call %d rejected without any limit", i+1)
+		}
+	}
+	if len(l.buckets) != 0 {
+		t.Errorf("got %d buckets for unlimited calls, want none", len(l.buckets))
+	}
+}
+
+func TestLimiterMaxBuckets(t *testing.T) {
+	l := New(Config{Default: Limit{PerSecond: 0.001, Burst: 1}, Key: byClient, MaxBuckets: 2})
+
+	for _, client := range []string{"a", "b", "c"} {
+		if _, ok := l.allow(clientContext(client), method); !ok {
+			t.Fatalf("first call of %s rejected", client)
+		}
+	}
+	// c took the overflow bucket, which d and e now share
+	for _, client := range []string{"d", "e"} {
+		if _, ok := l.allow(clientContext(client), method); ok {
+			t.Errorf("call of %s allowed on the exhausted overflow bucket", client)
+		}
+	}
+	if len(l.buckets) != 3 {
+		t.Errorf("got %d buckets, want 2 and the overflow bucket", len(l.buckets))
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	l := New(Config{Default: Limit{PerSecond: 1, Burst: 1}, Key: byClient})
+	l.allow(clientContext("a"), method)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweepLocked(time.Now().Add(idleTimeout/2), idleTimeout)
+	if len(l.buckets) != 1 {
+		t.Fatalf("got %d buckets before the idle timeout, want 1", len(l.buckets))
+	}
+	l.sweepLocked(time.Now().Add(2*idleTimeout), idleTimeout)
+	if len(l.buckets) != 0 {
+		t.Errorf("got %d buckets after the idle timeout, want 0", len(l.buckets))
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	l := New(Config{Default: Limit{PerSecond: 0.001, Burst: 1}, Key: byClient})
+	interceptor := l.UnaryServerInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "done", nil }
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+
+	if res, err := interceptor(clientContext("a"), nil, info, handler); err != nil || res != "done" {
+		t.Fatalf("first call : got %v, %v", res, err)
+	}
+	_, err := interceptor(clientContext("a"), nil, info, handler)
+	if grpctest.AssertCode(t, err, codes.ResourceExhausted) {
+		details := rpcerror.FromError(err)
+		if details.ErrorInfo.GetReason() != "RATE_LIMITED" || details.RetryInfo.GetRetryDelay().AsDuration() <= 0 {
+			t.Errorf("got details %v, want RATE_LIMITED with a retry delay", details)
+		}
+	}
+}
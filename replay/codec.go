@@ -0,0 +1,81 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// frame is a message kept in its wire form, so the proxy and the stub
+// forward messages of any service without knowing their types.
+type frame struct {
+	payload []byte
+}
+
+// codec passes frames through unchanged. It is registered under the name of
+// the proto codec so the content type seen by clients and servers stays
+// application/grpc+proto.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	f, ok := v.(*frame)
+	if !ok {
+		return nil, fmt.Errorf("replay: cannot marshal %T", v)
+	}
+	return f.payload, nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	f, ok := v.(*frame)
+	if !ok {
+		return fmt.Errorf("replay: cannot unmarshal into %T", v)
+	}
+	f.payload = append([]byte(nil), data...)
+	return nil
+}
+
+func (codec) Name() string {
+	return "proto"
+}
+
+// describe renders a message of method as JSON for people reading a
+// recording. It returns nil when the message type is not linked into the
+// binary.
+func describe(method string, fromClient bool, payload []byte) json.RawMessage {
+	parts := strings.Split(strings.TrimPrefix(method, "/"), "/")
+	if len(parts) != 2 {
+		return nil
+	}
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(parts[0]))
+	if err != nil {
+		return nil
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	md := service.Methods().ByName(protoreflect.Name(parts[1]))
+	if md == nil {
+		return nil
+	}
+	msgDesc := md.Output()
+	if fromClient {
+		msgDesc = md.Input()
+	}
+
+	msg := dynamicpb.NewMessage(msgDesc)
+	if err := proto.Unmarshal(payload, msg); err != nil {
+		return nil
+	}
+	out, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+// Package replay records the gRPC traffic flowing through a transparent proxy
+// and replays it from a stub server, so issues seen against a real service
+// can be reproduced offline.
+//
+// Recordings are JSON lines, one Exchange per call.
+package replay
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Sides of an Event.
+const (
+	FromClient = "client"
+	FromServer = "server"
+)
+
+// Exchange is a recorded call.
+type Exchange struct {
+	Method string    `json:"method"`
+	Start  time.Time `json:"start"`
+	// Metadata is the request metadata, with sensitive entries redacted.
+	Metadata metadata.MD `json:"metadata,omitempty"`
+	Header   metadata.MD `json:"header,omitempty"`
+	Trailer  metadata.MD `json:"trailer,omitempty"`
+	Events   []Event     `json:"events"`
+	Status   Status      `json:"status"`
+}
+
+// Event is a message sent by the client or the server during an exchange.
+type Event struct {
+	From string `json:"from"`
+	// Elapsed is the time since the start of the exchange.
+	Elapsed time.Duration `json:"elapsed"`
+	Message []byte        `json:"message"`
+	// JSON renders Message for readers. Replay ignores it.
+	JSON json.RawMessage `json:"json,omitempty"`
+}
+
+// Status is the final status of an exchange.
+type Status struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+	// Proto is the google.rpc.Status, details included, that replay returns.
+	Proto []byte `json:"proto,omitempty"`
+}
+
+func newStatus(err error) Status {
+	st := status.Convert(err)
+	s := Status{Code: st.Code().String(), Message: st.Message()}
+	if err != nil {
+		s.Proto, _ = proto.Marshal(st.Proto())
+	}
+	return s
+}
+
+// Err returns the recorded status as an error, nil for OK.
+func (s Status) Err() error {
+	if len(s.Proto) == 0 {
+		return nil
+	}
+	pb := &spb.Status{}
+	if err := proto.Unmarshal(s.Proto, pb); err != nil {
+		return status.Errorf(codes.Internal, "replay: corrupt status : %v", err)
+	}
+	return status.FromProto(pb).Err()
+}
+
+// encodeMD copies md, base64 encoding binary values, whose keys end in
+// "-bin", so they survive JSON.
+func encodeMD(md metadata.MD) metadata.MD {
+	if len(md) == 0 {
+		return nil
+	}
+	out := make(metadata.MD, len(md))
+	for key, values := range md {
+		for _, value := range values {
+			if strings.HasSuffix(key, "-bin") {
+				value = base64.StdEncoding.EncodeToString([]byte(value))
+			}
+			out[key] = append(out[key], value)
+		}
+	}
+	return out
+}
+
+// decodeMD reverses encodeMD.
+func decodeMD(md metadata.MD) metadata.MD {
+	out := make(metadata.MD, len(md))
+	for key, values := range md {
+		for _, value := range values {
+			if strings.HasSuffix(key, "-bin") {
+				if raw, err := base64.StdEncoding.DecodeString(value); err == nil {
+					value = string(raw)
+				}
+			}
+			out[key] = append(out[key], value)
+		}
+	}
+	return out
+}
+
+// Writer appends exchanges to a recording. It is safe for concurrent use.
+type Writer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriter writes exchanges to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{enc: json.NewEncoder(w)}
+}
+
+// Write appends ex.
+func (w *Writer) Write(ex *Exchange) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(ex)
+}
+
+// Read returns every exchange of a recording.
+func Read(r io.Reader) ([]*Exchange, error) {
+	dec := json.NewDecoder(r)
+	var exchanges []*Exchange
+	for {
+		ex := &Exchange{}
+		if err := dec.Decode(ex); err == io.EOF {
+			return exchanges, nil
+		} else if err != nil {
+			return nil, err
+		}
+		exchanges = append(exchanges, ex)
+	}
+}
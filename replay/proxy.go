@@ -0,0 +1,183 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultRedacted lists the request metadata kept out of recordings.
+var DefaultRedacted = []string{"authorization", "x-api-key"}
+
+// Proxy forwards every call it receives to an upstream connection and
+// records the exchange.
+type Proxy struct {
+	upstream *grpc.ClientConn
+	out      *Writer
+	redacted map[string]bool
+	// OnExchange, if set, is called after every recorded exchange, e.g. to
+	// log it, with the error of writing it.
+	OnExchange func(ex *Exchange, err error)
+}
+
+// NewProxy forwards calls to upstream and records them to out. The values
+// of the redacted request metadata keys are replaced in the recording; nil
+// means DefaultRedacted, an empty slice records every value.
+func NewProxy(upstream *grpc.ClientConn, out *Writer, redacted []string) *Proxy {
+	if redacted == nil {
+		redacted = DefaultRedacted
+	}
+	p := &Proxy{upstream: upstream, out: out, redacted: make(map[string]bool)}
+	for _, key := range redacted {
+		p.redacted[strings.ToLower(key)] = true
+	}
+	return p
+}
+
+// ServerOptions returns the options a gRPC server needs to proxy every
+// method through p. The server must not register any service.
+func (p *Proxy) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ForceServerCodec(codec{}),
+		grpc.UnknownServiceHandler(p.handle),
+	}
+}
+
+// userMetadata returns the entries of md set by applications, leaving out
+// those gRPC sets itself on every call.
+func userMetadata(md metadata.MD) metadata.MD {
+	out := metadata.MD{}
+	for key, values := range md {
+		if strings.HasPrefix(key, ":") || strings.HasPrefix(key, "grpc-") ||
+			key == "content-type" || key == "user-agent" || key == "te" {
+			continue
+		}
+		out[key] = append([]string(nil), values...)
+	}
+	return out
+}
+
+func (p *Proxy) redact(md metadata.MD) metadata.MD {
+	out := encodeMD(md)
+	for key := range out {
+		if p.redacted[key] {
+			out[key] = []string{"REDACTED"}
+		}
+	}
+	return out
+}
+
+// exchangeLog collects the events of an exchange from both directions.
+type exchangeLog struct {
+	mu     sync.Mutex
+	ex     *Exchange
+	closed bool
+}
+
+func (l *exchangeLog) add(from string, f *frame) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	l.ex.Events = append(l.ex.Events, Event{
+		From:    from,
+		Elapsed: time.Since(l.ex.Start),
+		Message: f.payload,
+		JSON:    describe(l.ex.Method, from == FromClient, f.payload),
+	})
+}
+
+// close stops collecting events, which a client still sending after the
+// upstream finished could otherwise add.
+func (l *exchangeLog) close() *Exchange {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	return l.ex
+}
+
+func (p *Proxy) handle(srv interface{}, ss grpc.ServerStream) error {
+	method, ok := grpc.MethodFromServerStream(ss)
+	if !ok {
+		return status.Error(codes.Internal, "replay: no method in stream")
+	}
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	md = userMetadata(md)
+
+	log := &exchangeLog{ex: &Exchange{Method: method, Start: time.Now(), Metadata: p.redact(md)}}
+
+	// the deadline of the client carries over through ss.Context()
+	ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(ss.Context(), md))
+	defer cancel()
+
+	cs, err := p.upstream.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, method, grpc.ForceCodec(codec{}))
+	if err != nil {
+		p.save(log, err)
+		return err
+	}
+
+	go func() {
+		for {
+			f := &frame{}
+			if err := ss.RecvMsg(f); err == io.EOF {
+				cs.CloseSend()
+				return
+			} else if err != nil {
+				cancel()
+				return
+			}
+			log.add(FromClient, f)
+			if err := cs.SendMsg(f); err != nil {
+				// the upstream ended the call, RecvMsg below returns why
+				return
+			}
+		}
+	}()
+
+	if header, err := cs.Header(); err == nil {
+		header = userMetadata(header)
+		log.ex.Header = encodeMD(header)
+		if len(header) > 0 {
+			ss.SendHeader(header)
+		}
+	}
+
+	var rpcErr error
+	for {
+		f := &frame{}
+		if err := cs.RecvMsg(f); err != nil {
+			if err != io.EOF {
+				rpcErr = err
+			}
+			break
+		}
+		log.add(FromServer, f)
+		if err := ss.SendMsg(f); err != nil {
+			rpcErr = err
+			break
+		}
+	}
+
+	trailer := cs.Trailer()
+	log.ex.Trailer = encodeMD(trailer)
+	ss.SetTrailer(trailer)
+	p.save(log, rpcErr)
+	return rpcErr
+}
+
+func (p *Proxy) save(log *exchangeLog, rpcErr error) {
+	ex := log.close()
+	ex.Status = newStatus(rpcErr)
+	err := p.out.Write(ex)
+	if p.OnExchange != nil {
+		p.OnExchange(ex, err)
+	}
+}
@@ -0,0 +1,120 @@
+// Command replay_proxy records the gRPC traffic of a service, or replays a
+// recording as a stub of that service.
+//
+// Record the calls made to the blog server through the proxy:
+//
+//	replay_proxy -mode record -upstream localhost:50051 -addr 0.0.0.0:50052 -file blog.jsonl
+//
+// then answer the same calls offline, without the blog server or MongoDB:
+//
+//	replay_proxy -mode replay -addr 0.0.0.0:50052 -file blog.jsonl
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/grpc-go-new-course/listener"
+	"github.com/grpc-go-new-course/logging"
+	"github.com/grpc-go-new-course/replay"
+	"github.com/grpc-go-new-course/tlsconfig"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	// linked in so recordings show their messages as JSON
+	_ "github.com/grpc-go-new-course/apikey/apikeypb"
+	_ "github.com/grpc-go-new-course/blog/blogpb"
+	_ "github.com/grpc-go-new-course/calculator/calculatorpb"
+	_ "github.com/grpc-go-new-course/greet/greetpb"
+)
+
+func main() {
+	mode := flag.String("mode", "record", `"record" to proxy and record calls, "replay" to answer them from -file`)
+	file := flag.String("file", "recording.jsonl", "recording to append to or replay from")
+	upstream := flag.String("upstream", "localhost:50051", "server the calls are forwarded to when recording")
+	redact := flag.String("redact", strings.Join(replay.DefaultRedacted, ","), "comma separated request metadata keys whose values are not recorded, empty to record every value")
+	timing := flag.Bool("timing", false, "when replaying, wait between server messages as long as the recorded server did")
+	var listenFlags listener.Flags
+	listenFlags.Register(flag.CommandLine, "0.0.0.0:50052")
+	var tlsFlags tlsconfig.ClientFlags
+	tlsFlags.Register(flag.CommandLine, "")
+	flag.Parse()
+
+	logger, err := logging.New("replay_proxy")
+	if err != nil {
+		panic(err)
+	}
+	defer logger.Sync()
+
+	var opts []grpc.ServerOption
+	switch *mode {
+	case "record":
+		out, err := os.OpenFile(*file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			logger.Fatal("unable to open recording", zap.Error(err))
+		}
+		defer out.Close()
+
+		creds, err := tlsFlags.DialOption()
+		if err != nil {
+			logger.Fatal("error loading client certificates", zap.Error(err))
+		}
+		conn, err := grpc.DialContext(context.Background(), *upstream, creds)
+		if err != nil {
+			logger.Fatal("unable to dial upstream", zap.Error(err))
+		}
+		defer conn.Close()
+
+		// not nil, which NewProxy would take for the default keys
+		redacted := []string{}
+		for _, key := range strings.Split(*redact, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				redacted = append(redacted, key)
+			}
+		}
+		proxy := replay.NewProxy(conn, replay.NewWriter(out), redacted)
+		proxy.OnExchange = func(ex *replay.Exchange, err error) {
+			if err != nil {
+				logger.Error("unable to record exchange", zap.String("method", ex.Method), zap.Error(err))
+				return
+			}
+			logger.Info("recorded exchange",
+				zap.String("method", ex.Method),
+				zap.Int("messages", len(ex.Events)),
+				zap.String("code", ex.Status.Code),
+			)
+		}
+		opts = proxy.ServerOptions()
+
+	case "replay":
+		in, err := os.Open(*file)
+		if err != nil {
+			logger.Fatal("unable to open recording", zap.Error(err))
+		}
+		exchanges, err := replay.Read(in)
+		in.Close()
+		if err != nil {
+			logger.Fatal("unable to read recording", zap.Error(err))
+		}
+		stub := replay.NewStub(exchanges)
+		stub.Timing = *timing
+		logger.Info("loaded recording", zap.String("file", *file), zap.Int("exchanges", len(exchanges)))
+		opts = stub.ServerOptions()
+
+	default:
+		logger.Fatal("unknown mode", zap.String("mode", *mode))
+	}
+
+	listeners, err := listenFlags.Listen()
+	if err != nil {
+		logger.Fatal("could not establish a listener", zap.Error(err))
+	}
+
+	grpcServer := grpc.NewServer(opts...)
+	logger.Info("starting gRPC server", zap.String("mode", *mode), zap.Strings("addresses", listener.Addrs(listeners)))
+	if err := listener.Serve(grpcServer, listeners); err != nil {
+		logger.Fatal("gRPC server stopped", zap.Error(err))
+	}
+}
@@ -0,0 +1,218 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/grpc-go-new-course/greet/greetpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/grpc-go-new-course/rpcerror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// greeter is a small GreetService the proxy records.
+type greeter struct {
+	greetpb.UnimplementedGreetServiceServer
+}
+
+func (greeter) Greet(ctx context.Context, req *greetpb.GreetingRequest) (*greetpb.GreetingResponse, error) {
+	name := req.GetGreeting().GetFirstName()
+	if name == "" {
+		return nil, rpcerror.New(codes.InvalidArgument, "missing first name",
+			rpcerror.BadRequest("greeting.first_name", "is required"),
+		)
+	}
+	grpc.SetHeader(ctx, metadata.Pairs("x-served-by", "greeter"))
+	grpc.SetTrailer(ctx, metadata.Pairs("x-checksum-bin", "\x00\x01"))
+	return &greetpb.GreetingResponse{Result: "Hello " + name}, nil
+}
+
+func (greeter) GreetManyTimes(req *greetpb.GreetManyTimesRequest, stream greetpb.GreetService_GreetManyTimesServer) error {
+	for i := 0; i < 3; i++ {
+		if err := stream.Send(&greetpb.GreetManyTimesResponse{Result: fmt.Sprintf("Hello %s %d", req.GetGreeting().GetFirstName(), i)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (greeter) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&greetpb.GreetEveryoneResponse{Result: "Hello " + req.GetGreeting().GetFirstName()}); err != nil {
+			return err
+		}
+	}
+}
+
+// transcript runs every kind of call against client and describes what it
+// saw, so recording and replay can be compared.
+func transcript(t *testing.T, client greetpb.GreetServiceClient) string {
+	t.Helper()
+	var out strings.Builder
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "secret")
+
+	for _, name := range []string{"Ama", "", "Kofi"} {
+		var header, trailer metadata.MD
+		res, err := client.Greet(ctx, &greetpb.GreetingRequest{Greeting: &greetpb.Greeting{FirstName: name}},
+			grpc.Header(&header), grpc.Trailer(&trailer))
+		fmt.Fprintf(&out, "Greet %q: %q %v %q %q %v\n", name, res.GetResult(), header.Get("x-served-by"),
+			trailer.Get("x-checksum-bin"), rpcerror.Describe(err), rpcerror.FromError(err).BadRequest)
+	}
+
+	stream, err := client.GreetManyTimes(ctx, &greetpb.GreetManyTimesRequest{Greeting: &greetpb.Greeting{FirstName: "Ama"}})
+	if err != nil {
+		t.Fatalf("GreetManyTimes : %v", err)
+	}
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			fmt.Fprintf(&out, "GreetManyTimes end: %v\n", err)
+			break
+		}
+		fmt.Fprintf(&out, "GreetManyTimes: %q\n", res.GetResult())
+	}
+
+	bidi, err := client.GreetEveryone(ctx)
+	if err != nil {
+		t.Fatalf("GreetEveryone : %v", err)
+	}
+	for _, name := range []string{"Ama", "Kofi"} {
+		bidi.Send(&greetpb.GreetEveryoneRequest{Greeting: &greetpb.Greeting{FirstName: name}})
+		res, err := bidi.Recv()
+		fmt.Fprintf(&out, "GreetEveryone %q: %q %v\n", name, res.GetResult(), err)
+	}
+	bidi.CloseSend()
+	_, err = bidi.Recv()
+	fmt.Fprintf(&out, "GreetEveryone end: %v\n", err)
+
+	_, err = client.GreetWithDeadline(ctx, &greetpb.GreetWithDeadlineRequest{})
+	fmt.Fprintf(&out, "GreetWithDeadline: %v\n", status.Code(err))
+	return out.String()
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	upstream := grpctest.Start(t, func(s *grpc.Server) {
+		greetpb.RegisterGreetServiceServer(s, greeter{})
+	})
+
+	var recording bytes.Buffer
+	proxy := NewProxy(upstream, NewWriter(&recording), nil)
+	proxied := grpctest.Start(t, func(*grpc.Server) {}, proxy.ServerOptions()...)
+	recorded := transcript(t, greetpb.NewGreetServiceClient(proxied))
+
+	if strings.Contains(recording.String(), "secret") {
+		t.Errorf("recording contains the API key")
+	}
+	if !strings.Contains(recording.String(), `"json":{"result":"Hello Kofi"}`) {
+		t.Errorf("recording does not describe messages as JSON")
+	}
+
+	exchanges, err := Read(&recording)
+	if err != nil {
+		t.Fatalf("Read : %v", err)
+	}
+	if len(exchanges) != 6 {
+		t.Fatalf("got %d exchanges, want 6", len(exchanges))
+	}
+
+	stub := NewStub(exchanges)
+	stubbed := grpctest.Start(t, func(*grpc.Server) {}, stub.ServerOptions()...)
+	replayed := transcript(t, greetpb.NewGreetServiceClient(stubbed))
+
+	if replayed != recorded {
+		t.Errorf("replay differs from recording\nrecorded:\n%s\nreplayed:\n%s", recorded, replayed)
+	}
+	if !strings.Contains(recorded, `Greet "Kofi": "Hello Kofi"`) || !strings.Contains(recorded, "InvalidArgument") {
+		t.Errorf("unexpected transcript\n%s", recorded)
+	}
+}
+
+func TestStubMatchesFirstMessage(t *testing.T) {
+	upstream := grpctest.Start(t, func(s *grpc.Server) {
+		greetpb.RegisterGreetServiceServer(s, greeter{})
+	})
+	var recording bytes.Buffer
+	proxied := grpctest.Start(t, func(*grpc.Server) {}, NewProxy(upstream, NewWriter(&recording), nil).ServerOptions()...)
+	client := greetpb.NewGreetServiceClient(proxied)
+	for _, name := range []string{"Ama", "Kofi"} {
+		if _, err := client.Greet(context.Background(), &greetpb.GreetingRequest{Greeting: &greetpb.Greeting{FirstName: name}}); err != nil {
+			t.Fatalf("Greet : %v", err)
+		}
+	}
+
+	exchanges, err := Read(&recording)
+	if err != nil {
+		t.Fatalf("Read : %v", err)
+	}
+	stubbed := grpctest.Start(t, func(*grpc.Server) {}, NewStub(exchanges).ServerOptions()...)
+	client = greetpb.NewGreetServiceClient(stubbed)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "Kofi", want: "Hello Kofi"},
+		{name: "Kofi", want: "Hello Kofi"},
+		{name: "Ama", want: "Hello Ama"},
+		// no match and every exchange used: start over
+		{name: "Yaw", want: "Hello Ama"},
+	}
+	for _, tt := range tests {
+		res, err := client.Greet(context.Background(), &greetpb.GreetingRequest{Greeting: &greetpb.Greeting{FirstName: tt.name}})
+		if err != nil {
+			t.Fatalf("Greet : %v", err)
+		}
+		if res.GetResult() != tt.want {
+			t.Errorf("Greet %q: got %q, want %q", tt.name, res.GetResult(), tt.want)
+		}
+	}
+}
+
+func TestProxyRedaction(t *testing.T) {
+	upstream := grpctest.Start(t, func(s *grpc.Server) {
+		greetpb.RegisterGreetServiceServer(s, greeter{})
+	})
+
+	tests := []struct {
+		name     string
+		redacted []string
+		kept     []string
+		dropped  []string
+	}{
+		{name: "default", kept: []string{"trace-7"}, dropped: []string{"secret"}},
+		{name: "nothing", redacted: []string{}, kept: []string{"secret", "trace-7"}},
+		{name: "custom", redacted: []string{"X-Trace"}, kept: []string{"secret"}, dropped: []string{"trace-7"}},
+	}
+	for _, tt := range tests {
+		var recording bytes.Buffer
+		proxied := grpctest.Start(t, func(*grpc.Server) {}, NewProxy(upstream, NewWriter(&recording), tt.redacted).ServerOptions()...)
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "secret", "x-trace", "trace-7")
+		if _, err := greetpb.NewGreetServiceClient(proxied).Greet(ctx, &greetpb.GreetingRequest{Greeting: &greetpb.Greeting{FirstName: "Ama"}}); err != nil {
+			t.Fatalf("%s : Greet : %v", tt.name, err)
+		}
+		for _, value := range tt.kept {
+			if !strings.Contains(recording.String(), value) {
+				t.Errorf("%s : recording lacks %q", tt.name, value)
+			}
+		}
+		for _, value := range tt.dropped {
+			if strings.Contains(recording.String(), value) {
+				t.Errorf("%s : recording contains %q", tt.name, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package replay
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Stub answers calls with recorded exchanges instead of a real service.
+//
+// A call is answered by an exchange of its method whose first client message
+// matches the first message of the call, unused ones first. Without a match,
+// the first unused exchange of the method answers; once every exchange was
+// used, they are used again in order.
+type Stub struct {
+	// Timing makes the stub wait before each server message as long as the
+	// recorded server did.
+	Timing bool
+
+	mu        sync.Mutex
+	exchanges map[string][]*Exchange
+	used      map[*Exchange]bool
+}
+
+// NewStub replays exchanges.
+func NewStub(exchanges []*Exchange) *Stub {
+	s := &Stub{exchanges: make(map[string][]*Exchange), used: make(map[*Exchange]bool)}
+	for _, ex := range exchanges {
+		s.exchanges[ex.Method] = append(s.exchanges[ex.Method], ex)
+	}
+	return s
+}
+
+// ServerOptions returns the options a gRPC server needs to answer every
+// method from s. The server must not register any service.
+func (s *Stub) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ForceServerCodec(codec{}),
+		grpc.UnknownServiceHandler(s.handle),
+	}
+}
+
+// startsWithClient reports whether every exchange of method starts with a
+// client message, in which case the first message of a call can be read
+// before choosing the exchange.
+func (s *Stub) startsWithClient(method string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ex := range s.exchanges[method] {
+		if len(ex.Events) == 0 || ex.Events[0].From != FromClient {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Stub) pick(method string, first *frame) *Exchange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	candidates := s.exchanges[method]
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var chosen, reused *Exchange
+	for _, ex := range candidates {
+		matches := first != nil && bytes.Equal(ex.Events[0].Message, first.payload)
+		switch {
+		case matches && !s.used[ex]:
+			chosen = ex
+		case matches && reused == nil:
+			reused = ex
+		}
+		if chosen != nil {
+			break
+		}
+	}
+	if chosen == nil {
+		chosen = reused
+	}
+	if chosen == nil {
+		for _, ex := range candidates {
+			if !s.used[ex] {
+				chosen = ex
+				break
+			}
+		}
+	}
+	if chosen == nil {
+		// every exchange was used, start over
+		for _, ex := range candidates {
+			delete(s.used, ex)
+		}
+		chosen = candidates[0]
+	}
+	s.used[chosen] = true
+	return chosen
+}
+
+func (s *Stub) handle(srv interface{}, ss grpc.ServerStream) error {
+	method, ok := grpc.MethodFromServerStream(ss)
+	if !ok {
+		return status.Error(codes.Internal, "replay: no method in stream")
+	}
+
+	var first *frame
+	if s.startsWithClient(method) {
+		first = &frame{}
+		if err := ss.RecvMsg(first); err == io.EOF {
+			first = nil
+		} else if err != nil {
+			return err
+		}
+	}
+	ex := s.pick(method, first)
+	if ex == nil {
+		return status.Errorf(codes.Unimplemented, "replay: no recorded exchange for %s", method)
+	}
+
+	if len(ex.Header) > 0 {
+		if err := ss.SendHeader(decodeMD(ex.Header)); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	pending := first != nil
+	for _, event := range ex.Events {
+		switch event.From {
+		case FromClient:
+			if pending {
+				pending = false
+				continue
+			}
+			// the call may send fewer messages than the recording did
+			if err := ss.RecvMsg(&frame{}); err != nil && err != io.EOF {
+				return err
+			}
+		case FromServer:
+			if s.Timing {
+				select {
+				case <-time.After(time.Until(start.Add(event.Elapsed))):
+				case <-ss.Context().Done():
+					return status.FromContextError(ss.Context().Err()).Err()
+				}
+			}
+			if err := ss.SendMsg(&frame{payload: event.Message}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(ex.Trailer) > 0 {
+		ss.SetTrailer(decodeMD(ex.Trailer))
+	}
+	return ex.Status.Err()
+}
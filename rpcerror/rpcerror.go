@@ -0,0 +1,144 @@
+package rpcerror
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Domain is the google.rpc.ErrorInfo domain of the errors raised by the
+// services of this repository.
+const Domain = "grpc-go-new-course"
+
+// New returns a status error with code and message carrying details. Details
+// that cannot be attached are dropped rather than hiding the error itself.
+func New(code codes.Code, msg string, details ...proto.Message) error {
+	st := status.New(code, msg)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// ErrorInfo describes the cause of an error as a machine readable reason,
+// e.g. "BLOG_NOT_FOUND", with optional key/value pairs.
+func ErrorInfo(reason string, keyvals ...string) *errdetails.ErrorInfo {
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: Domain}
+	if len(keyvals) > 0 {
+		info.Metadata = make(map[string]string, len(keyvals)/2)
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			info.Metadata[keyvals[i]] = keyvals[i+1]
+		}
+	}
+	return info
+}
+
+// ResourceInfo names the resource an error relates to.
+func ResourceInfo(resourceType, name, description string) *errdetails.ResourceInfo {
+	return &errdetails.ResourceInfo{
+		ResourceType: resourceType,
+		ResourceName: name,
+		Description:  description,
+	}
+}
+
+// RetryInfo tells clients how long to wait before retrying.
+func RetryInfo(delay time.Duration) *errdetails.RetryInfo {
+	return &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}
+}
+
+// BadRequest reports invalid request fields, given as field/description
+// pairs.
+func BadRequest(fieldDescriptions ...string) *errdetails.BadRequest {
+	br := &errdetails.BadRequest{}
+	for i := 0; i+1 < len(fieldDescriptions); i += 2 {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fieldDescriptions[i],
+			Description: fieldDescriptions[i+1],
+		})
+	}
+	return br
+}
+
+// Details is the unpacked form of a status error.
+type Details struct {
+	Code         codes.Code
+	Message      string
+	ErrorInfo    *errdetails.ErrorInfo
+	ResourceInfo *errdetails.ResourceInfo
+	RetryInfo    *errdetails.RetryInfo
+	BadRequest   *errdetails.BadRequest
+}
+
+// FromError unpacks the details carried by err. Errors that are not status
+// errors are reported with codes.Unknown.
+func FromError(err error) *Details {
+	st := status.Convert(err)
+	d := &Details{Code: st.Code(), Message: st.Message()}
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			d.ErrorInfo = detail
+		case *errdetails.ResourceInfo:
+			d.ResourceInfo = detail
+		case *errdetails.RetryInfo:
+			d.RetryInfo = detail
+		case *errdetails.BadRequest:
+			d.BadRequest = detail
+		}
+	}
+	return d
+}
+
+// RetryDelay returns the delay suggested by the server, if any.
+func (d *Details) RetryDelay() (time.Duration, bool) {
+	if d.RetryInfo == nil || d.RetryInfo.GetRetryDelay() == nil {
+		return 0, false
+	}
+	return d.RetryInfo.GetRetryDelay().AsDuration(), true
+}
+
+// String formats the error and its details on one line per detail.
+func (d *Details) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s : %s", d.Code, d.Message)
+	if d.ErrorInfo != nil {
+		fmt.Fprintf(&b, "\n  reason : %s (%s)", d.ErrorInfo.GetReason(), d.ErrorInfo.GetDomain())
+		keys := make([]string, 0, len(d.ErrorInfo.GetMetadata()))
+		for k := range d.ErrorInfo.GetMetadata() {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "\n    %s = %s", k, d.ErrorInfo.GetMetadata()[k])
+		}
+	}
+	if d.ResourceInfo != nil {
+		fmt.Fprintf(&b, "\n  resource : %s %q", d.ResourceInfo.GetResourceType(), d.ResourceInfo.GetResourceName())
+		if desc := d.ResourceInfo.GetDescription(); desc != "" {
+			fmt.Fprintf(&b, " : %s", desc)
+		}
+	}
+	if d.BadRequest != nil {
+		for _, fv := range d.BadRequest.GetFieldViolations() {
+			fmt.Fprintf(&b, "\n  field %s : %s", fv.GetField(), fv.GetDescription())
+		}
+	}
+	if delay, ok := d.RetryDelay(); ok {
+		fmt.Fprintf(&b, "\n  retry after : %v", delay)
+	}
+	return b.String()
+}
+
+// Describe formats err with its details, for client programs printing
+// failed calls.
+func Describe(err error) string {
+	return FromError(err).String()
+}
@@ -0,0 +1,97 @@
+package rpcerror
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromError(t *testing.T) {
+	err := New(codes.NotFound, "no blog 42",
+		ErrorInfo("BLOG_NOT_FOUND", "blog_id", "42", "dangling"),
+		ResourceInfo("blog", "42", "deleted"),
+		RetryInfo(2*time.Second),
+		BadRequest("blog_id", "must exist", "ignored"),
+	)
+	d := FromError(err)
+
+	if d.Code != codes.NotFound || d.Message != "no blog 42" {
+		t.Errorf("got %v %q, want NotFound no blog 42", d.Code, d.Message)
+	}
+	if d.ErrorInfo.GetReason() != "BLOG_NOT_FOUND" || d.ErrorInfo.GetDomain() != Domain {
+		t.Errorf("got error info %v", d.ErrorInfo)
+	}
+	if md := d.ErrorInfo.GetMetadata(); len(md) != 1 || md["blog_id"] != "42" {
+		t.Errorf("got metadata %v, want only blog_id = 42", md)
+	}
+	if d.ResourceInfo.GetResourceName() != "42" {
+		t.Errorf("got resource info %v", d.ResourceInfo)
+	}
+	if delay, ok := d.RetryDelay(); !ok || delay != 2*time.Second {
+		t.Errorf("got retry delay %v, %v, want 2s", delay, ok)
+	}
+	if fv := d.BadRequest.GetFieldViolations(); len(fv) != 1 || fv[0].GetField() != "blog_id" {
+		t.Errorf("got violations %v, want one on blog_id", fv)
+	}
+}
+
+func TestFromErrorWithoutDetails(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{name: "status", err: status.Error(codes.Unavailable, "down"), code: codes.Unavailable},
+		{name: "plain error", err: errors.New("boom"), code: codes.Unknown},
+	}
+	for _, tt := range tests {
+		d := FromError(tt.err)
+		if d.Code != tt.code || d.ErrorInfo != nil || d.BadRequest != nil {
+			t.Errorf("%s : got %+v, want code %v without details", tt.name, d, tt.code)
+		}
+		if _, ok := d.RetryDelay(); ok {
+			t.Errorf("%s : got a retry delay without RetryInfo", tt.name)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want []string
+	}{
+		{
+			name: "every detail",
+			err: New(codes.ResourceExhausted, "slow down",
+				ErrorInfo("RATE_LIMITED", "method", "/greet.GreetService/Greet", "limit", "call"),
+				ResourceInfo("blog", "42", ""),
+				BadRequest("number", "must be positive"),
+				RetryInfo(time.Second),
+			),
+			want: []string{
+				"ResourceExhausted : slow down",
+				"  reason : RATE_LIMITED (grpc-go-new-course)",
+				"    limit = call",
+				"    method = /greet.GreetService/Greet",
+				`  resource : blog "42"`,
+				"  field number : must be positive",
+				"  retry after : 1s",
+			},
+		},
+		{
+			name: "no detail",
+			err:  status.Error(codes.Internal, "oops"),
+			want: []string{"Internal : oops"},
+		},
+	}
+	for _, tt := range tests {
+		if got, want := Describe(tt.err), strings.Join(tt.want, "\n"); got != want {
+			t.Errorf("%s : got\n%s\nwant\n%s", tt.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package tlsconfig
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// ServerFlags holds the TLS settings of a server read from the command line.
+type ServerFlags struct {
+	ServerConfig
+	ReloadInterval time.Duration
+}
+
+// Register adds the -cert, -key, -client-ca and -cert-reload-interval flags
+// to fs. Leaving all of -cert, -key and -client-ca empty serves plaintext.
+func (f *ServerFlags) Register(fs *flag.FlagSet, defaultCert, defaultKey string) {
+	fs.StringVar(&f.CertFile, "cert", defaultCert, "server certificate, empty with -key to serve plaintext")
+	fs.StringVar(&f.KeyFile, "key", defaultKey, "server private key, empty with -cert to serve plaintext")
+	fs.StringVar(&f.ClientCAFile, "client-ca", "", "CA bundle verifying client certificates, enables mutual TLS")
+	fs.DurationVar(&f.ReloadInterval, "cert-reload-interval", 30*time.Second, "how often certificate files are checked for changes, 0 disables reloading")
+}
+
+// Enabled reports whether TLS was requested. Setting only some of the
+// flags is an error rather than a silent fallback to plaintext.
+func (f *ServerFlags) Enabled() (bool, error) {
+	switch {
+	case f.CertFile == "" && f.KeyFile == "" && f.ClientCAFile == "":
+		return false, nil
+	case f.CertFile == "" || f.KeyFile == "":
+		return false, errors.New("TLS needs both -cert and -key, or neither for plaintext")
+	}
+	return true, nil
+}
+
+// ServerOption returns the option making a gRPC server use the configured
+// certificates, reloading them in the background until ctx is done. Without
+// TLS it returns an option leaving the server in plaintext.
+func (f *ServerFlags) ServerOption(ctx context.Context, opts ReloaderOptions) (grpc.ServerOption, error) {
+	enabled, err := f.Enabled()
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return grpc.EmptyServerOption{}, nil
+	}
+	certs, err := NewReloader(f.ServerConfig, opts)
+	if err != nil {
+		return nil, err
+	}
+	if f.ReloadInterval > 0 {
+		go certs.Watch(ctx, f.ReloadInterval)
+	}
+	return grpc.Creds(certs.Credentials()), nil
+}
+
+// ClientFlags holds the TLS settings of a client read from the command line.
+type ClientFlags struct {
+	ClientConfig
+}
+
+// Register adds the -ca, -cert, -key and -server-name flags to fs. Leaving
+// them all empty dials in plaintext.
+func (f *ClientFlags) Register(fs *flag.FlagSet, defaultCA string) {
+	fs.StringVar(&f.CAFile, "ca", defaultCA, "CA bundle verifying the server certificate, empty to dial in plaintext")
+	fs.StringVar(&f.CertFile, "cert", "", "client certificate, for servers requiring mutual TLS")
+	fs.StringVar(&f.KeyFile, "key", "", "client private key, for servers requiring mutual TLS")
+	fs.StringVar(&f.ServerName, "server-name", "", "name expected in the server certificate, defaults to the dialed host")
+}
+
+// Enabled reports whether TLS was requested. Setting -cert, -key or
+// -server-name without -ca, or only one of -cert and -key, is an error
+// rather than a silent fallback to plaintext.
+func (f *ClientFlags) Enabled() (bool, error) {
+	if (f.CertFile == "") != (f.KeyFile == "") {
+		return false, errors.New("mutual TLS needs both -cert and -key")
+	}
+	if f.CAFile == "" {
+		if f.CertFile != "" || f.ServerName != "" {
+			return false, errors.New("TLS needs -ca to verify the server, set it or drop the other TLS flags for plaintext")
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// DialOption returns the transport credentials option for grpc.Dial.
+func (f *ClientFlags) DialOption() (grpc.DialOption, error) {
+	enabled, err := f.Enabled()
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return grpc.WithTransportCredentials(insecure.NewCredentials()), nil
+	}
+	creds, err := ClientCredentials(f.ClientConfig)
+	if err != nil {
+		return nil, err
+	}
+	return grpc.WithTransportCredentials(creds), nil
+}
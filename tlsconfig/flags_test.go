@@ -0,0 +1,49 @@
+package tlsconfig
+
+import "testing"
+
+func TestServerFlagsEnabled(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         ServerConfig
+		want, fails bool
+	}{
+		{name: "plaintext"},
+		{name: "TLS", cfg: ServerConfig{CertFile: "server.crt", KeyFile: "server.pem"}, want: true},
+		{name: "mutual TLS", cfg: ServerConfig{CertFile: "server.crt", KeyFile: "server.pem", ClientCAFile: "ca.crt"}, want: true},
+		{name: "certificate only", cfg: ServerConfig{CertFile: "server.crt"}, fails: true},
+		{name: "key only", cfg: ServerConfig{KeyFile: "server.pem"}, fails: true},
+		{name: "client CA only", cfg: ServerConfig{ClientCAFile: "ca.crt"}, fails: true},
+	}
+	for _, tt := range tests {
+		f := ServerFlags{ServerConfig: tt.cfg}
+		got, err := f.Enabled()
+		if (err != nil) != tt.fails || got != tt.want {
+			t.Errorf("%s : got %v, %v, want %v and failure %v", tt.name, got, err, tt.want, tt.fails)
+		}
+	}
+}
+
+func TestClientFlagsEnabled(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         ClientConfig
+		want, fails bool
+	}{
+		{name: "plaintext"},
+		{name: "TLS", cfg: ClientConfig{CAFile: "ca.crt"}, want: true},
+		{name: "mutual TLS", cfg: ClientConfig{CAFile: "ca.crt", CertFile: "client.crt", KeyFile: "client.pem"}, want: true},
+		{name: "server name", cfg: ClientConfig{CAFile: "ca.crt", ServerName: "localhost"}, want: true},
+		{name: "client certificate without CA", cfg: ClientConfig{CertFile: "client.crt", KeyFile: "client.pem"}, fails: true},
+		{name: "server name without CA", cfg: ClientConfig{ServerName: "localhost"}, fails: true},
+		{name: "certificate without key", cfg: ClientConfig{CAFile: "ca.crt", CertFile: "client.crt"}, fails: true},
+		{name: "key without certificate", cfg: ClientConfig{CAFile: "ca.crt", KeyFile: "client.pem"}, fails: true},
+	}
+	for _, tt := range tests {
+		f := ClientFlags{ClientConfig: tt.cfg}
+		got, err := f.Enabled()
+		if (err != nil) != tt.fails || got != tt.want {
+			t.Errorf("%s : got %v, %v, want %v and failure %v", tt.name, got, err, tt.want, tt.fails)
+		}
+	}
+}
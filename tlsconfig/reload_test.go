@@ -0,0 +1,168 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate for commonName and its key to
+// dir, and bumps their modification time so a poll notices the change even
+// within the file system's time granularity.
+func writeCert(t *testing.T, dir, commonName string, bump time.Duration) ServerConfig {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := ServerConfig{CertFile: filepath.Join(dir, "server.crt"), KeyFile: filepath.Join(dir, "server.pem")}
+	writeFile(t, cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), bump)
+	writeFile(t, cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), bump)
+	return cfg
+}
+
+func writeFile(t *testing.T, path string, data []byte, bump time.Duration) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	stamp := time.Now().Add(bump)
+	if err := os.Chtimes(path, stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// servedName returns the common name of the certificate a client receives
+// in a handshake with r.
+func servedName(t *testing.T, r *Reloader) string {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	go func() {
+		defer serverConn.Close()
+		tls.Server(serverConn, r.TLSConfig()).Handshake()
+	}()
+	client := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h2"}})
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("handshake : %v", err)
+	}
+	state := client.ConnectionState()
+	if state.NegotiatedProtocol != "h2" {
+		t.Errorf("negotiated %q, want h2", state.NegotiatedProtocol)
+	}
+	return state.PeerCertificates[0].Subject.CommonName
+}
+
+// reloads records the calls of ReloaderOptions.OnReload.
+type reloads struct {
+	mu    sync.Mutex
+	calls []error
+	last  time.Time
+}
+
+func (r *reloads) observe(notAfter time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, err)
+	r.last = notAfter
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	cfg := writeCert(t, dir, "first", 0)
+	var observed reloads
+	r, err := NewReloader(cfg, ReloaderOptions{OnReload: observed.observe})
+	if err != nil {
+		t.Fatalf("NewReloader : %v", err)
+	}
+	if got := servedName(t, r); got != "first" {
+		t.Fatalf("served %q, want first", got)
+	}
+
+	writeCert(t, dir, "second", time.Second)
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload : %v", err)
+	}
+	if got := servedName(t, r); got != "second" {
+		t.Errorf("served %q after reloading, want second", got)
+	}
+	secondNotAfter := r.notAfter()
+
+	writeFile(t, cfg.CertFile, []byte("not a certificate"), 2*time.Second)
+	if err := r.Reload(); err == nil {
+		t.Fatal("Reload of an invalid certificate succeeded")
+	}
+	if got := servedName(t, r); got != "second" {
+		t.Errorf("served %q after a failed reload, want the previous certificate", got)
+	}
+
+	observed.mu.Lock()
+	defer observed.mu.Unlock()
+	if len(observed.calls) != 3 || observed.calls[0] != nil || observed.calls[1] != nil || observed.calls[2] == nil {
+		t.Errorf("OnReload got errors %v, want success, success, failure", observed.calls)
+	}
+	if !observed.last.Equal(secondNotAfter) {
+		t.Errorf("OnReload reported expiry %v after the failure, want %v of the certificate in use", observed.last, secondNotAfter)
+	}
+}
+
+func TestNewReloaderFailsOnMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewReloader(ServerConfig{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: filepath.Join(dir, "missing.pem")}, ReloaderOptions{})
+	if err == nil {
+		t.Fatal("NewReloader succeeded without certificate")
+	}
+}
+
+func TestReloaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewReloader(writeCert(t, dir, "first", 0), ReloaderOptions{})
+	if err != nil {
+		t.Fatalf("NewReloader : %v", err)
+	}
+	if r.changed() {
+		t.Error("files reported changed right after loading them")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, 10*time.Millisecond)
+
+	writeCert(t, dir, "rotated", time.Second)
+	deadline := time.Now().Add(5 * time.Second)
+	for servedName(t, r) != "rotated" {
+		if time.Now().After(deadline) {
+			t.Fatal("Watch did not pick up the rotated certificate")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,153 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// ServerConfig locates the files used by a TLS server. When ClientCAFile is
+// set the server runs in mutual TLS mode: clients must present a certificate
+// signed by one of the CAs of the bundle.
+type ServerConfig struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+}
+
+// ClientConfig locates the files used by a TLS client. CertFile and KeyFile
+// are only needed when the server requires client certificates.
+type ClientConfig struct {
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// LoadCertPool reads a PEM bundle of CA certificates.
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA bundle : %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in CA bundle %q", file)
+	}
+	return pool, nil
+}
+
+// ServerTLS returns the tls.Config described by cfg.
+func ServerTLS(cfg ServerConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading server key pair : %v", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.ClientCAFile != "" {
+		pool, err := LoadCertPool(cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// ServerCredentials returns gRPC transport credentials for cfg.
+func ServerCredentials(cfg ServerConfig) (credentials.TransportCredentials, error) {
+	config, err := ServerTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// ClientTLS returns the tls.Config described by cfg.
+func ClientTLS(cfg ClientConfig) (*tls.Config, error) {
+	pool, err := LoadCertPool(cfg.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		RootCAs:    pool,
+		ServerName: cfg.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client key pair : %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// ClientCredentials returns gRPC transport credentials for cfg.
+func ClientCredentials(cfg ClientConfig) (credentials.TransportCredentials, error) {
+	config, err := ClientTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// Identity is what a verified client certificate says about its owner.
+type Identity struct {
+	Subject        pkix.Name
+	DNSNames       []string
+	EmailAddresses []string
+	URIs           []string
+}
+
+// Name returns the most readable name of the identity: the subject common
+// name, else the first email address, DNS name or URI.
+func (id *Identity) Name() string {
+	switch {
+	case id.Subject.CommonName != "":
+		return id.Subject.CommonName
+	case len(id.EmailAddresses) > 0:
+		return id.EmailAddresses[0]
+	case len(id.DNSNames) > 0:
+		return id.DNSNames[0]
+	case len(id.URIs) > 0:
+		return id.URIs[0]
+	}
+	return ""
+}
+
+// PeerIdentity returns the identity of the client certificate verified
+// during the handshake of the connection carrying ctx. It reports false for
+// connections without a verified client certificate.
+func PeerIdentity(ctx context.Context) (*Identity, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	id := &Identity{
+		Subject:        cert.Subject,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+	}
+	for _, uri := range cert.URIs {
+		id.URIs = append(id.URIs, uri.String())
+	}
+	return id, true
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// FileExporter writes finished spans as one JSON object per line, so traces
+// can be inspected offline without running a collector.
+type FileExporter struct {
+	mu  sync.Mutex
+	w   io.WriteCloser
+	enc *json.Encoder
+}
+
+// NewFileExporter returns an exporter writing to w. w is closed on Shutdown.
+func NewFileExporter(w io.WriteCloser) *FileExporter {
+	return &FileExporter{w: w, enc: json.NewEncoder(w)}
+}
+
+type spanRecord struct {
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id,omitempty"`
+	Name         string                 `json:"name"`
+	Kind         string                 `json:"kind"`
+	Service      string                 `json:"service,omitempty"`
+	Start        time.Time              `json:"start"`
+	End          time.Time              `json:"end"`
+	DurationMs   float64                `json:"duration_ms"`
+	Status       string                 `json:"status"`
+	Description  string                 `json:"description,omitempty"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Events       []eventRecord          `json:"events,omitempty"`
+}
+
+type eventRecord struct {
+	Name       string                 `json:"name"`
+	Time       time.Time              `json:"time"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// ExportSpans implements sdktrace.SpanExporter.
+func (e *FileExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, span := range spans {
+		record := spanRecord{
+			TraceID:     span.SpanContext().TraceID().String(),
+			SpanID:      span.SpanContext().SpanID().String(),
+			Name:        span.Name(),
+			Kind:        span.SpanKind().String(),
+			Start:       span.StartTime(),
+			End:         span.EndTime(),
+			DurationMs:  float64(span.EndTime().Sub(span.StartTime())) / float64(time.Millisecond),
+			Status:      span.Status().Code.String(),
+			Description: span.Status().Description,
+			Attributes:  make(map[string]interface{}),
+		}
+		if span.Parent().HasSpanID() {
+			record.ParentSpanID = span.Parent().SpanID().String()
+		}
+		if span.Resource() != nil {
+			for _, kv := range span.Resource().Attributes() {
+				if kv.Key == "service.name" {
+					record.Service = kv.Value.AsString()
+				}
+			}
+		}
+		for _, kv := range span.Attributes() {
+			record.Attributes[string(kv.Key)] = kv.Value.AsInterface()
+		}
+		for _, event := range span.Events() {
+			ev := eventRecord{Name: event.Name, Time: event.Time, Attributes: make(map[string]interface{})}
+			for _, kv := range event.Attributes {
+				ev.Attributes[string(kv.Key)] = kv.Value.AsInterface()
+			}
+			record.Events = append(record.Events, ev)
+		}
+
+		if err := e.enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown implements sdktrace.SpanExporter.
+func (e *FileExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.w.Close()
+}
@@ -0,0 +1,281 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// messageBatchSize is the number of stream messages grouped under one span.
+const messageBatchSize = 20
+
+var grpcStatusCodeKey = attribute.Key("rpc.grpc.status_code")
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// inject adds the trace context of ctx to its outgoing metadata.
+func inject(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// extract returns ctx with the trace context sent by the client, if any.
+func extract(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+func rpcAttributes(fullMethod string) []attribute.KeyValue {
+	service, method := "unknown", strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(method, "/"); i >= 0 {
+		service, method = method[:i], method[i+1:]
+	}
+	return []attribute.KeyValue{
+		semconv.RPCSystemKey.String("grpc"),
+		semconv.RPCServiceKey.String(service),
+		semconv.RPCMethodKey.String(method),
+	}
+}
+
+func spanName(fullMethod string) string {
+	return strings.TrimPrefix(fullMethod, "/")
+}
+
+func endRPC(span trace.Span, err error) {
+	span.SetAttributes(grpcStatusCodeKey.Int64(int64(status.Code(err))))
+	End(span, err)
+}
+
+// UnaryServerInterceptor starts a server span for each unary RPC, continuing
+// the trace propagated by the client.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := Tracer().Start(extract(ctx), spanName(info.FullMethod),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(rpcAttributes(info.FullMethod)...),
+		)
+
+		resp, err := handler(ctx, req)
+		endRPC(span, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor starts a server span for each streaming RPC, with
+// child spans for every batch of messages received and sent.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := Tracer().Start(extract(ss.Context()), spanName(info.FullMethod),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(rpcAttributes(info.FullMethod)...),
+		)
+
+		stream := &tracedServerStream{
+			ServerStream: ss,
+			ctx:          ctx,
+			received:     newMessageBatch(ctx, "received"),
+			sent:         newMessageBatch(ctx, "sent"),
+		}
+		err := handler(srv, stream)
+
+		stream.received.end()
+		stream.sent.end()
+		endRPC(span, err)
+		return err
+	}
+}
+
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received *messageBatch
+	sent     *messageBatch
+}
+
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *tracedServerStream) SendMsg(msg interface{}) error {
+	err := s.ServerStream.SendMsg(msg)
+	if err == nil {
+		s.sent.add()
+	}
+	return err
+}
+
+func (s *tracedServerStream) RecvMsg(msg interface{}) error {
+	err := s.ServerStream.RecvMsg(msg)
+	if err == nil {
+		s.received.add()
+	}
+	return err
+}
+
+// UnaryClientInterceptor starts a client span for each unary call and
+// propagates its trace context through the request metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := Tracer().Start(ctx, spanName(method),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(rpcAttributes(method)...),
+		)
+
+		err := invoker(inject(ctx), method, req, reply, cc, opts...)
+		endRPC(span, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor starts a client span for each streaming call, with
+// child spans for every batch of messages sent and received. The span ends
+// when the stream has been read to completion or fails.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, span := Tracer().Start(ctx, spanName(method),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(rpcAttributes(method)...),
+		)
+
+		cs, err := streamer(inject(ctx), desc, cc, method, opts...)
+		if err != nil {
+			endRPC(span, err)
+			return nil, err
+		}
+		return &tracedClientStream{
+			ClientStream: cs,
+			span:         span,
+			serverStream: desc.ServerStreams,
+			received:     newMessageBatch(ctx, "received"),
+			sent:         newMessageBatch(ctx, "sent"),
+		}, nil
+	}
+}
+
+type tracedClientStream struct {
+	grpc.ClientStream
+	span         trace.Span
+	serverStream bool
+	received     *messageBatch
+	sent         *messageBatch
+	once         sync.Once
+}
+
+func (s *tracedClientStream) SendMsg(msg interface{}) error {
+	err := s.ClientStream.SendMsg(msg)
+	if err == nil {
+		s.sent.add()
+	}
+	return err
+}
+
+func (s *tracedClientStream) CloseSend() error {
+	s.sent.end()
+	return s.ClientStream.CloseSend()
+}
+
+func (s *tracedClientStream) RecvMsg(msg interface{}) error {
+	err := s.ClientStream.RecvMsg(msg)
+	switch {
+	case err == nil:
+		s.received.add()
+		if !s.serverStream {
+			s.finish(nil)
+		}
+	case err == io.EOF:
+		s.finish(nil)
+	default:
+		s.finish(err)
+	}
+	return err
+}
+
+func (s *tracedClientStream) finish(err error) {
+	s.once.Do(func() {
+		s.received.end()
+		s.sent.end()
+		endRPC(s.span, err)
+	})
+}
+
+// messageBatch groups consecutive stream messages flowing in one direction
+// under a single span, so slow streams show where the time went without
+// creating a span per message.
+type messageBatch struct {
+	mu        sync.Mutex
+	ctx       context.Context
+	direction string
+	span      trace.Span
+	count     int
+}
+
+func newMessageBatch(ctx context.Context, direction string) *messageBatch {
+	return &messageBatch{ctx: ctx, direction: direction}
+}
+
+func (b *messageBatch) add() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.span == nil {
+		_, b.span = Tracer().Start(b.ctx, "messages."+b.direction)
+	}
+	b.count++
+	if b.count == messageBatchSize {
+		b.endLocked()
+	}
+}
+
+func (b *messageBatch) end() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.endLocked()
+}
+
+func (b *messageBatch) endLocked() {
+	if b.span == nil {
+		return
+	}
+	b.span.SetAttributes(
+		attribute.String("message.direction", b.direction),
+		attribute.Int("message.count", b.count),
+	)
+	b.span.End()
+	b.span = nil
+	b.count = 0
+}
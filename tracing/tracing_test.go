@@ -0,0 +1,259 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/grpc-go-new-course/greet/greetpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// greeter answers with the trace ID its handlers run under, so tests see
+// the trace context reach them.
+type greeter struct {
+	greetpb.UnimplementedGreetServiceServer
+}
+
+func (greeter) Greet(ctx context.Context, req *greetpb.GreetingRequest) (*greetpb.GreetingResponse, error) {
+	if req.GetGreeting().GetFirstName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing first name")
+	}
+	return &greetpb.GreetingResponse{Result: trace.SpanContextFromContext(ctx).TraceID().String()}, nil
+}
+
+func (greeter) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) error {
+	traceID := trace.SpanContextFromContext(stream.Context()).TraceID().String()
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&greetpb.GreetEveryoneResponse{Result: traceID}); err != nil {
+			return err
+		}
+	}
+}
+
+// startTraced serves greeter behind the server interceptors, dialed through
+// the client ones, recording every span.
+func startTraced(t *testing.T) (greetpb.GreetServiceClient, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	conn := grpctest.StartWithDialOptions(t, func(s *grpc.Server) {
+		greetpb.RegisterGreetServiceServer(s, greeter{})
+	},
+		[]grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(UnaryServerInterceptor()),
+			grpc.ChainStreamInterceptor(StreamServerInterceptor()),
+		},
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(StreamClientInterceptor()),
+	)
+	return greetpb.NewGreetServiceClient(conn), recorder
+}
+
+// endedSpan returns the ended span of the given name and kind.
+func endedSpan(t *testing.T, recorder *tracetest.SpanRecorder, name string, kind trace.SpanKind) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, span := range recorder.Ended() {
+		if span.Name() == name && span.SpanKind() == kind {
+			return span
+		}
+	}
+	t.Fatalf("no ended %v span %s", kind, name)
+	return nil
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestUnaryInterceptors(t *testing.T) {
+	client, recorder := startTraced(t)
+	ctx, caller := Tracer().Start(context.Background(), "caller")
+
+	res, err := client.Greet(ctx, &greetpb.GreetingRequest{Greeting: &greetpb.Greeting{FirstName: "Ama"}})
+	caller.End()
+	if err != nil {
+		t.Fatalf("Greet : %v", err)
+	}
+
+	clientSpan := endedSpan(t, recorder, "greet.GreetService/Greet", trace.SpanKindClient)
+	serverSpan := endedSpan(t, recorder, "greet.GreetService/Greet", trace.SpanKindServer)
+	traceID := caller.SpanContext().TraceID()
+
+	if clientSpan.Parent().SpanID() != caller.SpanContext().SpanID() {
+		t.Errorf("client span is not a child of the caller's")
+	}
+	if serverSpan.SpanContext().TraceID() != traceID || serverSpan.Parent().SpanID() != clientSpan.SpanContext().SpanID() || !serverSpan.Parent().IsRemote() {
+		t.Errorf("server span %v does not continue the client span %v", serverSpan.Parent(), clientSpan.SpanContext())
+	}
+	if res.GetResult() != traceID.String() {
+		t.Errorf("handler ran under trace %s, want %s", res.GetResult(), traceID)
+	}
+	for _, span := range []sdktrace.ReadOnlySpan{clientSpan, serverSpan} {
+		if got := attributeValue(span, semconv.RPCServiceKey).AsString(); got != "greet.GreetService" {
+			t.Errorf("%v span : got rpc.service %q", span.SpanKind(), got)
+		}
+		if got := attributeValue(span, semconv.RPCMethodKey).AsString(); got != "Greet" {
+			t.Errorf("%v span : got rpc.method %q", span.SpanKind(), got)
+		}
+		if got := attributeValue(span, grpcStatusCodeKey).AsInt64(); got != int64(codes.OK) {
+			t.Errorf("%v span : got status code %d, want OK", span.SpanKind(), got)
+		}
+	}
+}
+
+func TestUnaryInterceptorsRecordErrors(t *testing.T) {
+	client, recorder := startTraced(t)
+
+	_, err := client.Greet(context.Background(), &greetpb.GreetingRequest{})
+	grpctest.AssertCode(t, err, codes.InvalidArgument)
+
+	for _, kind := range []trace.SpanKind{trace.SpanKindClient, trace.SpanKindServer} {
+		span := endedSpan(t, recorder, "greet.GreetService/Greet", kind)
+		if span.Status().Code != otelcodes.Error {
+			t.Errorf("%v span : got status %v, want Error", kind, span.Status())
+		}
+		if got := attributeValue(span, grpcStatusCodeKey).AsInt64(); got != int64(codes.InvalidArgument) {
+			t.Errorf("%v span : got status code %d, want InvalidArgument", kind, got)
+		}
+	}
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	client, recorder := startTraced(t)
+
+	stream, err := client.GreetEveryone(context.Background())
+	if err != nil {
+		t.Fatalf("GreetEveryone : %v", err)
+	}
+	// one full batch of messages and part of another
+	const messages = messageBatchSize + 5
+	var handlerTrace string
+	for i := 0; i < messages; i++ {
+		if err := stream.Send(&greetpb.GreetEveryoneRequest{}); err != nil {
+			t.Fatalf("Send : %v", err)
+		}
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv : %v", err)
+		}
+		handlerTrace = res.GetResult()
+	}
+	stream.CloseSend()
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("Recv after CloseSend : %v", err)
+	}
+
+	clientSpan := endedSpan(t, recorder, "greet.GreetService/GreetEveryone", trace.SpanKindClient)
+	serverSpan := endedSpan(t, recorder, "greet.GreetService/GreetEveryone", trace.SpanKindServer)
+	if serverSpan.Parent().SpanID() != clientSpan.SpanContext().SpanID() {
+		t.Errorf("server span does not continue the client span")
+	}
+	if handlerTrace != clientSpan.SpanContext().TraceID().String() {
+		t.Errorf("handler ran under trace %s, want %s", handlerTrace, clientSpan.SpanContext().TraceID())
+	}
+
+	// every side batches the messages it received and sent under its span
+	counts := make(map[string][]int64)
+	for _, span := range recorder.Ended() {
+		if span.Name() != "messages.received" && span.Name() != "messages.sent" {
+			continue
+		}
+		var side string
+		switch span.Parent().SpanID() {
+		case clientSpan.SpanContext().SpanID():
+			side = "client "
+		case serverSpan.SpanContext().SpanID():
+			side = "server "
+		default:
+			t.Errorf("%s span outside the RPC spans", span.Name())
+			continue
+		}
+		counts[side+span.Name()] = append(counts[side+span.Name()], attributeValue(span, "message.count").AsInt64())
+	}
+	for _, name := range []string{"client messages.sent", "client messages.received", "server messages.received", "server messages.sent"} {
+		if got := counts[name]; len(got) != 2 || got[0] != messageBatchSize || got[1] != 5 {
+			t.Errorf("got %s batches of %v, want [%d 5]", name, got, messageBatchSize)
+		}
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestFileExporter(t *testing.T) {
+	var out bytes.Buffer
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(NewFileExporter(nopCloser{&out})),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String("greet"))),
+	)
+	tracer := provider.Tracer("test")
+	ctx, parent := tracer.Start(context.Background(), "parent", trace.WithSpanKind(trace.SpanKindServer))
+	_, child := tracer.Start(ctx, "child", trace.WithAttributes(attribute.Int("message.count", 3)))
+	End(child, status.Error(codes.Internal, "boom"))
+	parent.End()
+
+	dec := json.NewDecoder(&out)
+	var records []spanRecord
+	for {
+		var r spanRecord
+		if err := dec.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("decoding : %v", err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d spans, want 2", len(records))
+	}
+	child0, parent0 := records[0], records[1]
+	if child0.Name != "child" || child0.ParentSpanID != parent0.SpanID || child0.TraceID != parent0.TraceID {
+		t.Errorf("got child %+v of parent %+v", child0, parent0)
+	}
+	if child0.Status != "Error" || child0.Description == "" || child0.Attributes["message.count"] != float64(3) {
+		t.Errorf("got child status %s %q and attributes %v", child0.Status, child0.Description, child0.Attributes)
+	}
+	if len(child0.Events) != 1 || child0.Events[0].Name != "exception" {
+		t.Errorf("got child events %+v, want the recorded error", child0.Events)
+	}
+	if parent0.Kind != "server" || parent0.Service != "greet" || parent0.ParentSpanID != "" {
+		t.Errorf("got parent %+v", parent0)
+	}
+}
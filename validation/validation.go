@@ -0,0 +1,229 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grpc-go-new-course/rpcerror"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Rule constrains a single field of a message. Field is a dotted path of
+// proto field names, e.g. "blog.title".
+type Rule struct {
+	Field string
+	check func(v protoreflect.Value, present bool) string
+}
+
+// Required rejects fields that are unset or hold their zero value.
+func Required(field string) Rule {
+	return Rule{Field: field, check: func(v protoreflect.Value, present bool) string {
+		if !present {
+			return "is required"
+		}
+		return ""
+	}}
+}
+
+// Length bounds the number of characters of a non-empty string field.
+func Length(field string, min, max int) Rule {
+	return Rule{Field: field, check: func(v protoreflect.Value, present bool) string {
+		if !present {
+			return ""
+		}
+		if n := utf8.RuneCountInString(v.String()); n < min || n > max {
+			return fmt.Sprintf("must be between %d and %d characters long, got %d", min, max, n)
+		}
+		return ""
+	}}
+}
+
+// Range bounds a numeric field. Unset fields are checked as zero.
+func Range(field string, min, max float64) Rule {
+	return Rule{Field: field, check: func(v protoreflect.Value, present bool) string {
+		var n float64
+		switch x := v.Interface().(type) {
+		case int32:
+			n = float64(x)
+		case int64:
+			n = float64(x)
+		case uint32:
+			n = float64(x)
+		case uint64:
+			n = float64(x)
+		case float32:
+			n = float64(x)
+		case float64:
+			n = x
+		default:
+			return "is not a number"
+		}
+		if n < min || n > max {
+			return fmt.Sprintf("must be between %s and %s, got %s", formatNumber(min), formatNumber(max), formatNumber(n))
+		}
+		return ""
+	}}
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// Pattern requires a non-empty string field to match expr.
+func Pattern(field string, expr string) Rule {
+	re := regexp.MustCompile(expr)
+	return Rule{Field: field, check: func(v protoreflect.Value, present bool) string {
+		if !present {
+			return ""
+		}
+		if !re.MatchString(v.String()) {
+			return fmt.Sprintf("must match %q", expr)
+		}
+		return ""
+	}}
+}
+
+// Validator holds the rules declared for each request message.
+type Validator struct {
+	rules map[protoreflect.FullName][]Rule
+}
+
+// New returns a Validator without any rule.
+func New() *Validator {
+	return &Validator{rules: make(map[protoreflect.FullName][]Rule)}
+}
+
+// Add declares rules for the type of msg. It panics if a rule names a field
+// that msg does not have, so mistakes surface when the server starts.
+func (v *Validator) Add(msg proto.Message, rules ...Rule) *Validator {
+	desc := msg.ProtoReflect().Descriptor()
+	for _, rule := range rules {
+		if _, err := resolve(desc, rule.Field); err != nil {
+			panic(fmt.Sprintf("validation: %s: %v", desc.FullName(), err))
+		}
+	}
+	v.rules[desc.FullName()] = append(v.rules[desc.FullName()], rules...)
+	return v
+}
+
+// Validate checks msg against its rules. It returns an InvalidArgument
+// status carrying a google.rpc.BadRequest with one violation per failed
+// rule, or nil when msg is valid.
+func (v *Validator) Validate(msg interface{}) error {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil
+	}
+	rules := v.rules[m.ProtoReflect().Descriptor().FullName()]
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, rule := range rules {
+		value, present := lookup(m.ProtoReflect(), rule.Field)
+		if desc := rule.check(value, present); desc != "" {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       rule.Field,
+				Description: desc,
+			})
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	fields := make([]string, len(violations))
+	for i, fv := range violations {
+		fields[i] = fv.GetField()
+	}
+	name := string(m.ProtoReflect().Descriptor().Name())
+	return rpcerror.New(codes.InvalidArgument, fmt.Sprintf("invalid %s : %s", name, strings.Join(fields, ", ")),
+		rpcerror.ErrorInfo("INVALID_REQUEST", "message", name),
+		&errdetails.BadRequest{FieldViolations: violations},
+	)
+}
+
+// UnaryServerInterceptor rejects invalid requests before they reach the
+// handler.
+func (v *Validator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := v.Validate(req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor validates every message received on a stream.
+// The first invalid message fails the RPC with its validation error.
+func (v *Validator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		stream := &validatingStream{ServerStream: ss, validator: v}
+		err := handler(srv, stream)
+		if stream.invalid != nil {
+			// the handler may have wrapped Recv's error with status.Errorf,
+			// losing the BadRequest, so report the violations themselves
+			return stream.invalid
+		}
+		return err
+	}
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+	validator *Validator
+	invalid   error
+}
+
+func (s *validatingStream) RecvMsg(msg interface{}) error {
+	if err := s.ServerStream.RecvMsg(msg); err != nil {
+		return err
+	}
+	if err := s.validator.Validate(msg); err != nil {
+		s.invalid = err
+		return err
+	}
+	return nil
+}
+
+// resolve returns the descriptor of the field at path.
+func resolve(desc protoreflect.MessageDescriptor, path string) (protoreflect.FieldDescriptor, error) {
+	names := strings.Split(path, ".")
+	var fd protoreflect.FieldDescriptor
+	for i, name := range names {
+		if desc == nil {
+			return nil, fmt.Errorf("%q is not a message field", strings.Join(names[:i], "."))
+		}
+		fd = desc.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, fmt.Errorf("unknown field %q", strings.Join(names[:i+1], "."))
+		}
+		desc = fd.Message()
+	}
+	return fd, nil
+}
+
+// lookup returns the value at path and whether it is set. A field inside an
+// unset message is reported as unset with its default value.
+func lookup(m protoreflect.Message, path string) (protoreflect.Value, bool) {
+	names := strings.Split(path, ".")
+	present := true
+	for i, name := range names {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		present = present && m.Has(fd)
+		if i == len(names)-1 {
+			return m.Get(fd), present
+		}
+		m = m.Get(fd).Message()
+	}
+	return protoreflect.Value{}, false
+}
@@ -0,0 +1,147 @@
+package validation
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/grpc-go-new-course/greet/greetpb"
+	"github.com/grpc-go-new-course/grpctest"
+	"github.com/grpc-go-new-course/rpcerror"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func greeting(first, last string) *greetpb.GreetingRequest {
+	return &greetpb.GreetingRequest{Greeting: &greetpb.Greeting{FirstName: first, LastName: last}}
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		msg  proto.Message
+		// want is the description of the violation, empty when valid
+		want string
+	}{
+		{name: "required set", rule: Required("greeting.first_name"), msg: greeting("Ada", "")},
+		{name: "required empty", rule: Required("greeting.first_name"), msg: greeting("", ""), want: "is required"},
+		{name: "required in unset message", rule: Required("greeting.first_name"), msg: &greetpb.GreetingRequest{}, want: "is required"},
+		{name: "required message", rule: Required("greeting"), msg: &greetpb.GreetingRequest{}, want: "is required"},
+		{name: "required repeated", rule: Required("values"), msg: &structpb.ListValue{}, want: "is required"},
+
+		{name: "length within", rule: Length("greeting.last_name", 1, 5), msg: greeting("", "Love")},
+		{name: "length counts characters", rule: Length("greeting.last_name", 1, 5), msg: greeting("", "Gödel")},
+		{name: "length too long", rule: Length("greeting.last_name", 1, 5), msg: greeting("", "Lovelace"), want: "must be between 1 and 5 characters long, got 8"},
+		{name: "length skips unset", rule: Length("greeting.last_name", 1, 5), msg: greeting("Ada", "")},
+
+		{name: "range int32", rule: Range("value", 1, 10), msg: wrapperspb.Int32(10)},
+		{name: "range int32 below", rule: Range("value", 1, 10), msg: wrapperspb.Int32(-3), want: "must be between 1 and 10, got -3"},
+		{name: "range unset is zero", rule: Range("value", 1, 10), msg: wrapperspb.Int64(0), want: "must be between 1 and 10, got 0"},
+		{name: "range uint64", rule: Range("value", 0, 100), msg: wrapperspb.UInt64(101), want: "must be between 0 and 100, got 101"},
+		{name: "range double", rule: Range("value", 0, 1), msg: wrapperspb.Double(0.5)},
+		{name: "range double above", rule: Range("value", 0, 1), msg: wrapperspb.Double(1.5), want: "must be between 0 and 1, got 1.5"},
+		{name: "range not a number", rule: Range("value", 0, 1), msg: wrapperspb.String("1"), want: "is not a number"},
+
+		{name: "pattern match", rule: Pattern("value", "^[a-f0-9]+$"), msg: wrapperspb.String("c0ffee")},
+		{name: "pattern mismatch", rule: Pattern("value", "^[a-f0-9]+$"), msg: wrapperspb.String("tea"), want: `must match "^[a-f0-9]+$"`},
+		{name: "pattern skips unset", rule: Pattern("value", "^[a-f0-9]+$"), msg: wrapperspb.String("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New().Add(tt.msg, tt.rule).Validate(tt.msg)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("got %v, want no violation", err)
+				}
+				return
+			}
+			if !grpctest.AssertCode(t, err, codes.InvalidArgument) {
+				return
+			}
+			violations := rpcerror.FromError(err).BadRequest.GetFieldViolations()
+			if len(violations) != 1 || violations[0].GetField() != tt.rule.Field || violations[0].GetDescription() != tt.want {
+				t.Errorf("got violations %v, want %s %s", violations, tt.rule.Field, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryViolation(t *testing.T) {
+	v := New().Add(&greetpb.GreetingRequest{},
+		Required("greeting.first_name"),
+		Length("greeting.last_name", 1, 3),
+	)
+	err := v.Validate(greeting("", "Lovelace"))
+	details := rpcerror.FromError(err)
+	if details.ErrorInfo.GetReason() != "INVALID_REQUEST" || details.ErrorInfo.GetMetadata()["message"] != "GreetingRequest" {
+		t.Errorf("got error info %v, want INVALID_REQUEST for GreetingRequest", details.ErrorInfo)
+	}
+	if n := len(details.BadRequest.GetFieldViolations()); n != 2 {
+		t.Errorf("got %d violations, want 2", n)
+	}
+
+	if err := v.Validate(&greetpb.LongGreetRequest{}); err != nil {
+		t.Errorf("message without rules : got %v", err)
+	}
+}
+
+func TestAddPanicsOnUnknownField(t *testing.T) {
+	for _, field := range []string{"greeting.nickname", "greeting.first_name.x", "salutation"} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(r.(string), "GreetingRequest") {
+					t.Errorf("Add of %q : got panic %v, want one naming the message", field, r)
+				}
+			}()
+			New().Add(&greetpb.GreetingRequest{}, Required(field))
+		}()
+	}
+}
+
+// everyone echoes the first names it receives.
+type everyone struct {
+	greetpb.UnimplementedGreetServiceServer
+}
+
+func (everyone) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// wrapping drops the details, which the interceptor restores
+			return status.Errorf(codes.Internal, "receiving : %v", err)
+		}
+		if err := stream.Send(&greetpb.GreetEveryoneResponse{Result: req.GetGreeting().GetFirstName()}); err != nil {
+			return err
+		}
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	v := New().Add(&greetpb.GreetEveryoneRequest{}, Required("greeting.first_name"))
+	conn := grpctest.Start(t, func(s *grpc.Server) {
+		greetpb.RegisterGreetServiceServer(s, everyone{})
+	}, grpc.ChainStreamInterceptor(v.StreamServerInterceptor()))
+
+	stream, err := greetpb.NewGreetServiceClient(conn).GreetEveryone(context.Background())
+	if err != nil {
+		t.Fatalf("GreetEveryone : %v", err)
+	}
+	stream.Send(&greetpb.GreetEveryoneRequest{Greeting: &greetpb.Greeting{FirstName: "Ada"}})
+	if res, err := stream.Recv(); err != nil || res.GetResult() != "Ada" {
+		t.Fatalf("got %v, %v, want Ada", res, err)
+	}
+	stream.Send(&greetpb.GreetEveryoneRequest{})
+	_, err = stream.Recv()
+	if grpctest.AssertCode(t, err, codes.InvalidArgument) && rpcerror.FromError(err).BadRequest == nil {
+		t.Error("got no BadRequest details on the stream error")
+	}
+}